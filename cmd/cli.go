@@ -0,0 +1,874 @@
+package main
+
+import (
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "sort"
+    "strconv"
+    "strings"
+    "text/tabwriter"
+    "time"
+
+    "github.com/katena-chain/sdk-go-client/crypto/X25519"
+    "github.com/katena-chain/sdk-go-client/entity"
+    entityApi "github.com/katena-chain/sdk-go-client/entity/api"
+    "github.com/katena-chain/sdk-go-client/entity/certify"
+    "github.com/katena-chain/sdk-go-client/utils"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+// Exit codes of the command line mode
+const (
+    exitOk       = 0
+    exitError    = 1
+    exitUsage    = 2
+    exitRejected = 3
+    exitMismatch = 4
+    exitInvalid  = 5
+)
+
+type cliCommand struct {
+    usage string
+    run   func(args []string) int
+}
+
+var cliCommands = map[string]cliCommand{
+    "cert prepare":     {"cert prepare -uuid UUID -signature TEXT -signer TEXT -out FILE", runCertPrepare},
+    "cert send":        {"cert send -uuid UUID -signature TEXT -signer TEXT", runCertSend},
+    "cert get":         {"cert get -uuid UUID [-verify]", runCertGet},
+    "cert file":        {"cert file -signer TEXT [-algorithm sha256|sha512|blake2b] FILE...", runCertFile},
+    "cert verify-file": {"cert verify-file -uuid UUID [-file FILE]", runCertVerifyFile},
+    "cert import":      {"cert import -file FILE [-format csv|jsonl] [-dry-run] [-workers N] [-rate N] [-report FILE] [-wait] [-timeout DURATION]", runCertImport},
+    "secret prepare":   {"secret prepare -uuid UUID -content TEXT|-file FILE [-compress] -recipient-public-key KEY|-recipient NAME -sender-public-key KEY -sender-private-key KEY -out FILE", runSecretPrepare},
+    "secret send":      {"secret send -uuid UUID -content TEXT|-file FILE [-compress] -recipient-public-key KEY -recipient-private-key KEY|-recipient NAME|-handoff FILE -sender-public-key KEY -sender-private-key KEY", runSecretSend},
+    "secret send-many": {"secret send-many [-group UUID] -content TEXT|-file FILE [-compress] -recipients NAME,NAME... -sender-public-key KEY -sender-private-key KEY [-dry-run]", runSecretSendMany},
+    "secret get":       {"secret get -uuid UUID [-decrypt [-save-dir DIR]]", runSecretGet},
+    "secret import":    {"secret import -file FILE", runSecretImport},
+    "secret inbox":     {"secret inbox [-sources SOURCE,...] [-preview N] | -add SOURCE,... | -remove SOURCE,... | -list", runSecretInbox},
+    "secret groups":    {"secret groups [-group UUID]", runSecretGroups},
+    "lookup":           {"lookup -uuid UUID [-from-company-chain-id ID] [-recipient-private-key KEY] [-track]", runLookup},
+
+    "keys generate": {"keys generate -type ed25519|x25519 [-save NAME]", runKeysGenerate},
+    "keys import":   {"keys import -name NAME -type ed25519|x25519 -key KEY", runKeysImport},
+    "keys list":     {"keys list [-type ed25519|x25519]", runKeysList},
+    "keys delete":   {"keys delete -name NAME", runKeysDelete},
+
+    "profile list":   {"profile list", runProfileList},
+    "profile save":   {"profile save -name NAME [-company-chain-id ID] [-chain-id ID] [-api-url URL]", runProfileSave},
+    "profile use":    {"profile use -name NAME", runProfileUse},
+    "profile delete": {"profile delete -name NAME", runProfileDelete},
+
+    "tx sign":      {"tx sign -file FILE -out FILE", runTxSign},
+    "tx broadcast": {"tx broadcast -file FILE|- [-check] [-recipient-private-key KEY]", runTxBroadcast},
+
+    "contacts add":    {"contacts add -name NAME -public-key KEY [-company ID] [-notes TEXT]", runContactsAdd},
+    "contacts update": {"contacts update -name NAME [-public-key KEY] [-company ID] [-notes TEXT]", runContactsUpdate},
+    "contacts list":   {"contacts list [-search TEXT]", runContactsList},
+    "contacts delete": {"contacts delete -name NAME", runContactsDelete},
+    "contacts export": {"contacts export -file FILE|-", runContactsExport},
+    "contacts import": {"contacts import -file FILE|- [-dry-run]", runContactsImport},
+
+    "db export": {"db export -file FILE [-encrypt] [-no-keys]", runDbExport},
+    "db import": {"db import -file FILE [-dry-run]", runDbImport},
+    "db verify": {"db verify [-anchors]", runDbVerify},
+    "db anchor": {"db anchor [-force]", runDbAnchor},
+
+    "history":         {"history [-search TEXT] [-kind certificate|secret] [-status STATUS] [-since DURATION|DATE] [-sort COLUMN] [-desc] [-limit N]", runHistory},
+    "history show":    {"history show -id ID [-raw]", runHistoryShow},
+    "history refresh": {"history refresh", runHistoryRefresh},
+
+    "mock-server": {"mock-server [-listen ADDRESS] [-db FILE] [-latency DURATION] [-failure-rate RATE] [-error-rate RATE]", runMockServer},
+}
+
+func isCliInvocation(args []string) bool {
+    // Tells whether the arguments ask for the command line mode : a known command or a help flag. Other arguments,
+    // like the -psn_* process serial number macOS gives to an application opened from the Finder, leave the
+    // graphical interface starting.
+
+    if len(args) == 0 {
+        return false
+    }
+    switch args[0] {
+    case "help", "-h", "-help", "--help":
+        return true
+    }
+    for name := range cliCommands {
+        if strings.SplitN(name, " ", 2)[0] == args[0] {
+            return true
+        }
+    }
+    return false
+}
+
+func runCli(args []string) int {
+    // Finds the command matching the first arguments and runs it with the remaining ones
+
+    if len(args) >= 2 {
+        if command, ok := cliCommands[args[0]+" "+args[1]]; ok {
+            return command.run(args[2:])
+        }
+    }
+    if len(args) >= 1 {
+        if command, ok := cliCommands[args[0]]; ok {
+            return command.run(args[1:])
+        }
+    }
+
+    printUsage()
+    return exitUsage
+}
+
+func printUsage() {
+    // Prints the list of available commands on stderr
+
+    names := make([]string, 0, len(cliCommands))
+    for name := range cliCommands {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    fmt.Fprintln(os.Stderr, "Usage: transactor-ui [command] [flags]")
+    fmt.Fprintln(os.Stderr, "Without any command, the graphical interface is started.")
+    fmt.Fprintln(os.Stderr, "\nCommands :")
+    for _, name := range names {
+        fmt.Fprintln(os.Stderr, "  "+cliCommands[name].usage)
+    }
+    fmt.Fprintln(os.Stderr, "\nRun 'transactor-ui [command] -h' to list the flags of a command.")
+}
+
+func envOrDefault(name string, defaultValue string) string {
+    // Returns the value of an environment variable or the default one if unset
+
+    if value, ok := os.LookupEnv(name); ok {
+        return value
+    }
+    return defaultValue
+}
+
+type cliFlags struct {
+    *flag.FlagSet
+    profile        *string
+    privKey        *string
+    keyName        *string
+    companyChainID *string
+    chainID        *string
+    apiUrl         *string
+    output         *string
+}
+
+func newCliFlags(name string) *cliFlags {
+    // Builds a flag set holding the configuration flags shared by every command
+
+    flags := flag.NewFlagSet(name, flag.ContinueOnError)
+    return &cliFlags{
+        FlagSet:        flags,
+        profile:        flags.String("profile", "", "configuration profile, the active one by default (env KATENA_PROFILE)"),
+        privKey:        flags.String("private-key", "", "base64 ED25519 transactor private key (env KATENA_PRIVATE_KEY)"),
+        keyName:        flags.String("key-name", "", "name of the transactor key in the keystore, instead of -private-key (env KATENA_KEY_NAME)"),
+        companyChainID: flags.String("company-chain-id", "", "company chain id (env KATENA_COMPANY_CHAIN_ID)"),
+        chainID:        flags.String("chain-id", "", "chain id, "+libs.DefaultChainID+" by default (env KATENA_CHAIN_ID)"),
+        apiUrl:         flags.String("api-url", "", "API URL, "+libs.DefaultApiUrl+" by default (env KATENA_API_URL)"),
+        output:         flags.String("output", "table", "output format : table or json"),
+    }
+}
+
+func (flags *cliFlags) parse(args []string) bool {
+    // Parses the arguments and checks the output format, printing the errors
+
+    if err := flags.Parse(args); err != nil {
+        return false
+    }
+    if *flags.output != "table" && *flags.output != "json" {
+        fmt.Fprintln(os.Stderr, "unknown output format:", *flags.output)
+        return false
+    }
+    return true
+}
+
+func firstNonEmpty(values ...string) string {
+    // Returns the first non empty value
+
+    for _, value := range values {
+        if value != "" {
+            return value
+        }
+    }
+    return ""
+}
+
+func (flags *cliFlags) profileConfig() (libs.Config, error) {
+    // Resolves each value from its flag, then the environment, then the profile and finally the defaults
+
+    store, err := libs.LoadProfiles()
+    if err != nil {
+        return libs.Config{}, err
+    }
+    profile := store.GetActive()
+    if profileName := firstNonEmpty(*flags.profile, os.Getenv("KATENA_PROFILE")); profileName != "" {
+        profile, err = store.Get(profileName)
+        if err != nil {
+            return libs.Config{}, err
+        }
+    }
+    if profile == nil {
+        profile = &libs.Profile{}
+    }
+
+    // An explicit private key takes precedence over the keystore key of the profile
+    privKey := firstNonEmpty(*flags.privKey, os.Getenv("KATENA_PRIVATE_KEY"))
+    keyName := firstNonEmpty(*flags.keyName, os.Getenv("KATENA_KEY_NAME"))
+    if privKey == "" && keyName == "" {
+        keyName = profile.KeyName
+    }
+
+    return libs.Config{
+        Profile:        profile.Name,
+        PrivKey:        privKey,
+        KeyName:        keyName,
+        CompanyChainID: firstNonEmpty(*flags.companyChainID, os.Getenv("KATENA_COMPANY_CHAIN_ID"), profile.CompanyChainID),
+        ChainID:        firstNonEmpty(*flags.chainID, os.Getenv("KATENA_CHAIN_ID"), profile.ChainID, libs.DefaultChainID),
+        ApiUrl:         firstNonEmpty(*flags.apiUrl, os.Getenv("KATENA_API_URL"), profile.ApiUrl, libs.DefaultApiUrl),
+    }, nil
+}
+
+func (flags *cliFlags) config(needPrivKey bool) (libs.Config, bool) {
+    // Resolves and checks the configuration, printing what is missing
+
+    config, err := flags.profileConfig()
+    if err == nil {
+        err = config.Check()
+    }
+    if err == nil && needPrivKey && config.PrivKey == "" && config.KeyName == "" {
+        err = fmt.Errorf("missing private key")
+    }
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        return config, false
+    }
+    return config, true
+}
+
+func requireFlags(flags *cliFlags, names ...string) bool {
+    // Checks that every given flag has a value, printing the missing ones
+
+    ok := true
+    for _, name := range names {
+        if flags.Lookup(name).Value.String() == "" {
+            fmt.Fprintln(os.Stderr, "missing required flag: -"+name)
+            ok = false
+        }
+    }
+    return ok
+}
+
+func fail(err error) int {
+    // Prints an error on stderr and returns the matching exit code
+
+    fmt.Fprintln(os.Stderr, "Error:", err)
+    return exitError
+}
+
+func warn(err error) {
+    // Prints an error on stderr that does not change the exit code
+
+    fmt.Fprintln(os.Stderr, "Warning:", err)
+}
+
+func writeFile(path string, write func(file *os.File) error) error {
+    // Creates or replaces a file readable by its owner only and writes it
+
+    file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+    if err != nil {
+        return err
+    }
+    if err := write(file); err != nil {
+        _ = file.Close()
+        return err
+    }
+    return file.Close()
+}
+
+func printJSON(value interface{}) int {
+    // Prints the indented JSON representation of a value on stdout
+
+    data, err := json.MarshalIndent(value, "", "    ")
+    if err != nil {
+        return fail(err)
+    }
+    fmt.Println(string(data))
+    return exitOk
+}
+
+func printTable(header []string, rows [][]string) int {
+    // Prints aligned columns on stdout
+
+    writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+    fmt.Fprintln(writer, strings.Join(header, "\t"))
+    for _, row := range rows {
+        fmt.Fprintln(writer, strings.Join(row, "\t"))
+    }
+    if err := writer.Flush(); err != nil {
+        return fail(err)
+    }
+    return exitOk
+}
+
+type cliTransactionStatus struct {
+    Uuid          string `json:"uuid"`
+    RequestDigest string `json:"request_digest"`
+    Code          uint32 `json:"code"`
+    Message       string `json:"message"`
+    Status        string `json:"status"`
+}
+
+type cliWaitFlags struct {
+    wait    *bool
+    timeout *time.Duration
+}
+
+func newCliWaitFlags(flags *cliFlags) cliWaitFlags {
+    // Adds the flags of the send commands following their transaction until it is committed
+
+    return cliWaitFlags{
+        wait:    flags.Bool("wait", false, "wait until the transaction is committed or rejected"),
+        timeout: flags.Duration("timeout", libs.DefaultPollTimeout, "how long -wait follows the transaction"),
+    }
+}
+
+func (waitFlags cliWaitFlags) track(databaseDAO *libs.DatabaseDAO, config libs.Config, kind string, uuid string,
+    signed *libs.SignedTransaction, transactionStatus *entityApi.TransactionStatus) string {
+    // Records the status of a sent transaction, following it until it is committed if asked to
+
+    if !*waitFlags.wait {
+        status := libs.StatusPending
+        if transactionStatus.Code != 0 {
+            status = libs.StatusFailed
+        }
+        _ = databaseDAO.SetStatus(kind, uuid, status)
+        return status
+    }
+
+    tracker := libs.NewStatusTracker(databaseDAO, config)
+    tracker.Timeout = *waitFlags.timeout
+    status, detail := tracker.Track(kind, uuid, signed, transactionStatus)
+    switch status {
+    case libs.StatusFailed:
+        fmt.Fprintln(os.Stderr, "Transaction failed:", detail)
+    case libs.StatusTimedOut:
+        fmt.Fprintln(os.Stderr, "Transaction timed out, it may still be committed:", detail)
+    }
+    return status
+}
+
+func printTransactionStatus(output string, uuid string, signed *libs.SignedTransaction, transactionStatus *entityApi.TransactionStatus, status string) int {
+    // Prints the status returned by a send and turns a rejection into a dedicated exit code, a transaction not
+    // committed before the timeout being an error
+
+    result := cliTransactionStatus{
+        Uuid:          uuid,
+        RequestDigest: signed.Digest(),
+        Code:          transactionStatus.Code,
+        Message:       transactionStatus.Message,
+        Status:        status,
+    }
+
+    var code int
+    if output == "json" {
+        code = printJSON(result)
+    } else {
+        code = printTable([]string{"UUID", "REQUEST DIGEST", "CODE", "MESSAGE", "STATUS"}, [][]string{
+            {result.Uuid, result.RequestDigest, strconv.FormatUint(uint64(result.Code), 10), result.Message, result.Status},
+        })
+    }
+    if code == exitOk && (transactionStatus.Code != 0 || status == libs.StatusFailed) {
+        return exitRejected
+    }
+    if code == exitOk && status == libs.StatusTimedOut {
+        return exitError
+    }
+    return code
+}
+
+func formatStatus(status *entityApi.TransactionStatus) (string, string) {
+    // Returns the code and message of a possibly missing status
+
+    if status == nil {
+        return "", ""
+    }
+    return strconv.FormatUint(uint64(status.Code), 10), status.Message
+}
+
+func formatNonceTime(nonceTime *entity.Time) string {
+    // Returns a possibly missing nonce time in the local timezone
+
+    if nonceTime == nil {
+        return ""
+    }
+    return nonceTime.Local().Format(libs.DisplayTimeFormat)
+}
+
+func runCertSend(args []string) int {
+    // Sends a certificate and records it in the database
+
+    flags := newCliFlags("cert send")
+    uuidFlag := flags.String("uuid", "", "certificate UUID")
+    signature := flags.String("signature", "", "data signature")
+    signer := flags.String("signer", "", "data signer")
+    waitFlags := newCliWaitFlags(flags)
+    if !flags.parse(args) || !requireFlags(flags, "uuid", "signature", "signer") {
+        return exitUsage
+    }
+    config, ok := flags.config(true)
+    if !ok {
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    if config.KeyName != "" && !unlockKeystore(&databaseDAO) {
+        return exitError
+    }
+    config.Keystore = databaseDAO.Keystore
+    config.History = &databaseDAO
+    if err := databaseDAO.CheckNewEntry(libs.EntryCertificate, *uuidFlag); err != nil {
+        return fail(err)
+    }
+    certificateData := libs.CertificateHandler{
+        Config:        config,
+        UuidText:      *uuidFlag,
+        SignatureText: *signature,
+        SignerText:    *signer,
+    }
+
+    transactionStatus, err := certificateData.SendCertificate()
+    if transactionStatus == nil {
+        return fail(err)
+    }
+    // The API answered : the certificate is recorded even if the history could not keep the transaction
+    if err := databaseDAO.AddCertificateEntry(certificateData.UuidText, certificateData.SignatureText, certificateData.SignerText,
+        certificateData.Signed.Digest(), config.Profile); err != nil {
+        return fail(err)
+    }
+    // The transaction went out : a history error is only reported so that it is not sent again
+    if err != nil {
+        warn(err)
+    }
+    status := waitFlags.track(&databaseDAO, config, libs.EntryCertificate, certificateData.UuidText, certificateData.Signed, transactionStatus)
+
+    return printTransactionStatus(*flags.output, certificateData.UuidText, certificateData.Signed, transactionStatus, status)
+}
+
+func runCertGet(args []string) int {
+    // Retrieves a certificate from the API
+
+    flags := newCliFlags("cert get")
+    uuidFlag := flags.String("uuid", "", "certificate UUID")
+    verify := flags.Bool("verify", false, "compare the certificate with the database and check its seal")
+    if !flags.parse(args) || !requireFlags(flags, "uuid") {
+        return exitUsage
+    }
+    config, ok := flags.config(false)
+    if !ok {
+        return exitUsage
+    }
+
+    certificateData := libs.CertificateHandler{
+        Config:   config,
+        UuidText: *uuidFlag,
+    }
+    var databaseDAO libs.DatabaseDAO
+    if *verify {
+        var err error
+        if databaseDAO, err = libs.InitDb(); err != nil {
+            return fail(err)
+        }
+        // A tracked certificate of another company is retrieved from it unless a company is given
+        if *flags.companyChainID == "" {
+            certificateData.Config = databaseDAO.EntryConfig(libs.EntryCertificate, *uuidFlag, config)
+        }
+    }
+    transactionWrapper, err := certificateData.GetCertificate()
+    if err != nil {
+        return fail(err)
+    }
+    if *verify {
+        verification, err := certificateData.VerifyCertificate(transactionWrapper, &databaseDAO)
+        if err != nil {
+            return fail(err)
+        }
+        return printVerification(*flags.output, certificateData.UuidText, verification)
+    }
+    if *flags.output == "json" {
+        return printJSON(transactionWrapper)
+    }
+
+    certificate, err := libs.CertificateOf(transactionWrapper.Transaction)
+    if err != nil {
+        return fail(err)
+    }
+    code, statusMessage := formatStatus(transactionWrapper.Status)
+    return printTable([]string{"UUID", "COMPANY CHAIN ID", "SIGNATURE", "SIGNER", "CODE", "MESSAGE"}, [][]string{
+        {certificate.Uuid, certificate.CompanyChainID, string(certificate.Seal.Signature), string(certificate.Seal.Signer), code, statusMessage},
+    })
+}
+
+type cliVerification struct {
+    Uuid       string   `json:"uuid"`
+    Result     string   `json:"result"`
+    SealValid  bool     `json:"seal_valid"`
+    Invalid    []string `json:"invalid"`
+    Mismatches []string `json:"mismatches"`
+}
+
+func printVerification(output string, uuid string, verification *libs.CertificateVerification) int {
+    // Prints a certificate verification and turns a certificate invalid on chain or differing from the local record
+    // into a dedicated exit code
+
+    var code int
+    if output == "json" {
+        code = printJSON(cliVerification{
+            Uuid:       uuid,
+            Result:     verification.Result,
+            SealValid:  verification.SealValid,
+            Invalid:    verification.Invalid,
+            Mismatches: verification.Mismatches,
+        })
+    } else {
+        code = printTable([]string{"UUID", "RESULT", "SEAL", "INVALID", "MISMATCHES"}, [][]string{
+            {uuid, verification.Result, strconv.FormatBool(verification.SealValid), strings.Join(verification.Invalid, ", "),
+                strings.Join(verification.Mismatches, ", ")},
+        })
+    }
+    if code != exitOk {
+        return code
+    }
+    if len(verification.Invalid) > 0 {
+        return exitInvalid
+    }
+    if len(verification.Mismatches) > 0 {
+        return exitMismatch
+    }
+    return code
+}
+
+func runSecretSend(args []string) int {
+    // Seals and sends a secret and records its recipient private key in the database
+
+    flags := newCliFlags("secret send")
+    uuidFlag := flags.String("uuid", "", "certificate UUID the secret is attached to")
+    contentFlags := newCliContentFlags(flags)
+    recipientPublic := flags.String("recipient-public-key", "", "base64 X25519 recipient public key")
+    recipientPrivate := flags.String("recipient-private-key", "", "base64 X25519 recipient private key, stored in the database")
+    recipient := flags.String("recipient", "", "name of the recipient contact, instead of the recipient keys")
+    handoff := flags.String("handoff", "", "hand-off bundle to write the recipient private key generated for this secret to, "+
+        "instead of the recipient keys (passphrase from env KATENA_HANDOFF_PASSPHRASE)")
+    senderPublic := flags.String("sender-public-key", "", "base64 X25519 sender public key")
+    senderPrivate := flags.String("sender-private-key", envOrDefault("KATENA_SENDER_PRIVATE_KEY", ""), "base64 X25519 sender private key (env KATENA_SENDER_PRIVATE_KEY)")
+    senderKeyName := flags.String("sender-key-name", "", "name of the X25519 sender key in the keystore, instead of the sender keys")
+    waitFlags := newCliWaitFlags(flags)
+    if !flags.parse(args) || !requireFlags(flags, "uuid") || !contentFlags.given() {
+        return exitUsage
+    }
+    if *handoff != "" && (*recipient != "" || *recipientPublic != "" || *recipientPrivate != "") {
+        fmt.Fprintln(os.Stderr, "-handoff generates the recipient keys, it cannot be given with -recipient or the recipient keys")
+        return exitUsage
+    }
+    if *recipient == "" && *handoff == "" && !requireFlags(flags, "recipient-public-key", "recipient-private-key") {
+        return exitUsage
+    }
+    if *senderKeyName == "" && !requireFlags(flags, "sender-public-key", "sender-private-key") {
+        return exitUsage
+    }
+    config, ok := flags.config(true)
+    if !ok {
+        return exitUsage
+    }
+
+    contentBytes, err := contentFlags.read()
+    if err != nil {
+        return fail(err)
+    }
+
+    // The keystore encrypts the recipient private key recorded in the database
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    if !unlockKeystore(&databaseDAO) {
+        return exitError
+    }
+    config.Keystore = databaseDAO.Keystore
+    config.History = &databaseDAO
+    if err := databaseDAO.CheckNewEntry(libs.EntrySecret, *uuidFlag); err != nil {
+        return fail(err)
+    }
+    if *recipient != "" {
+        // The contact holds its private key, none is recorded
+        contact, err := databaseDAO.GetContact(*recipient)
+        if err != nil {
+            return fail(err)
+        }
+        *recipientPublic, *recipientPrivate = contact.PublicKey, ""
+    }
+    if *handoff != "" {
+        // The bundle is written before sending so that the generated key cannot be lost, the sender keeping no copy
+        if *recipientPublic, err = writeNewHandoff(*handoff, *uuidFlag, config.CompanyChainID); err != nil {
+            return fail(err)
+        }
+    }
+
+    secretData := libs.SecretHandler{
+        Config:   config,
+        UuidText: *uuidFlag,
+        Content:  contentBytes,
+    }
+    if err := secretKeys(&databaseDAO, &secretData, *recipientPublic, *senderPublic, *senderPrivate, *senderKeyName); err != nil {
+        return fail(err)
+    }
+
+    transactionStatus, err := secretData.SendSecret()
+    if transactionStatus == nil {
+        return fail(err)
+    }
+    // The API answered : the secret is recorded even if the history could not keep the transaction
+    if err := databaseDAO.AddSecretEntry(secretData.UuidText, *recipientPrivate, secretData.Signed.Digest(), config.Profile); err != nil {
+        return fail(err)
+    }
+    // The transaction went out : a history error is only reported so that it is not sent again
+    if err != nil {
+        warn(err)
+    }
+    status := waitFlags.track(&databaseDAO, config, libs.EntrySecret, secretData.UuidText, secretData.Signed, transactionStatus)
+
+    return printTransactionStatus(*flags.output, secretData.UuidText, secretData.Signed, transactionStatus, status)
+}
+
+func secretKeys(databaseDAO *libs.DatabaseDAO, secretData *libs.SecretHandler, recipientPublic string, senderPublic string,
+    senderPrivate string, senderKeyName string) error {
+    // Sets the recipient and sender keys of a secret, the sender ones being read from the keystore when a name is given
+
+    var err error
+    secretData.SenderPubKey, secretData.SenderPrivKey, err = senderKeys(databaseDAO, senderPublic, senderPrivate, senderKeyName)
+    if err != nil {
+        return err
+    }
+    secretData.RecipientPubKey, err = utils.CreatePublicKeyX25519FromBase64(recipientPublic)
+    return err
+}
+
+func senderKeys(databaseDAO *libs.DatabaseDAO, senderPublic string, senderPrivate string, senderKeyName string) (*X25519.PublicKey,
+    *X25519.PrivateKey, error) {
+    // Returns the sender keys of a secret, read from the keystore when a name is given
+
+    if senderKeyName != "" {
+        return databaseDAO.Keystore.GetX25519Key(senderKeyName)
+    }
+    senderPublicKey, err := utils.CreatePublicKeyX25519FromBase64(senderPublic)
+    if err != nil {
+        return nil, nil, err
+    }
+    senderPrivateKey, err := utils.CreatePrivateKeyX25519FromBase64(senderPrivate)
+    if err != nil {
+        return nil, nil, err
+    }
+    return senderPublicKey, senderPrivateKey, nil
+}
+
+type cliContentFlags struct {
+    content  *string
+    file     *string
+    compress *bool
+}
+
+func newCliContentFlags(flags *cliFlags) cliContentFlags {
+    // Adds the flags giving the content of a secret, as text or as a file
+
+    return cliContentFlags{
+        content:  flags.String("content", "", "secret content, - to read it from stdin"),
+        file:     flags.String("file", "", "file sent as the secret content, with its name and MIME type, instead of -content"),
+        compress: flags.Bool("compress", false, "gzip the -file data before sealing it"),
+    }
+}
+
+func (contentFlags cliContentFlags) given() bool {
+    // Checks that the content is given one way, printing the problem otherwise
+
+    switch {
+    case *contentFlags.content == "" && *contentFlags.file == "":
+        fmt.Fprintln(os.Stderr, "missing required flag: -content or -file")
+        return false
+    case *contentFlags.content != "" && *contentFlags.file != "":
+        fmt.Fprintln(os.Stderr, "-content and -file cannot be given together")
+        return false
+    }
+    return true
+}
+
+func (contentFlags cliContentFlags) read() ([]byte, error) {
+    // Returns the content of the secret, the file being wrapped with its name and MIME type, and checks that its
+    // transaction is not too large for the chain
+
+    var content []byte
+    var err error
+    if *contentFlags.file != "" {
+        file, err := libs.ReadSecretFile(*contentFlags.file)
+        if err != nil {
+            return nil, err
+        }
+        if content, err = file.Envelope(*contentFlags.compress); err != nil {
+            return nil, err
+        }
+        fmt.Fprintf(os.Stderr, "File %s, %d bytes sealed\n", file, len(content))
+    } else if content, err = readContent(*contentFlags.content); err != nil {
+        return nil, err
+    }
+    return content, libs.CheckSecretContentSize(len(content))
+}
+
+func readContent(content string) ([]byte, error) {
+    // Returns the content given on the command line, or read from stdin for -
+
+    if content == "-" {
+        return ioutil.ReadAll(os.Stdin)
+    }
+    return []byte(content), nil
+}
+
+func runSecretGet(args []string) int {
+    // Retrieves the secrets attached to a certificate UUID from the API
+
+    flags := newCliFlags("secret get")
+    uuidFlag := flags.String("uuid", "", "certificate UUID the secrets are attached to")
+    decrypt := flags.Bool("decrypt", false, "open the secrets with the recipient private key stored in the database")
+    saveDir := flags.String("save-dir", "", "with -decrypt, directory the files carried by the secrets are restored to")
+    if !flags.parse(args) || !requireFlags(flags, "uuid") {
+        return exitUsage
+    }
+    config, ok := flags.config(false)
+    if !ok {
+        return exitUsage
+    }
+
+    secretData := libs.SecretHandler{
+        Config:   config,
+        UuidText: *uuidFlag,
+    }
+    if *decrypt {
+        databaseDAO, err := libs.InitDb()
+        if err != nil {
+            return fail(err)
+        }
+        if !unlockKeystore(&databaseDAO) {
+            return exitError
+        }
+        recipientPrivKey, err := databaseDAO.GetSecretDecryptingKey(secretData.UuidText)
+        if err != nil {
+            return fail(err)
+        }
+        if *flags.companyChainID == "" {
+            secretData.Config = databaseDAO.EntryConfig(libs.EntrySecret, secretData.UuidText, config)
+        }
+        decryptedSecrets, err := secretData.DecryptSecrets(recipientPrivKey)
+        if err != nil {
+            return fail(err)
+        }
+        return printDecryptedSecrets(*flags.output, decryptedSecrets, *saveDir)
+    }
+
+    transactionWrappers, err := secretData.GetSecrets()
+    if err != nil {
+        return fail(err)
+    }
+    if *flags.output == "json" {
+        return printJSON(transactionWrappers)
+    }
+
+    rows := make([][]string, 0, len(transactionWrappers.Transactions))
+    for _, transactionWrapper := range transactionWrappers.Transactions {
+        message, ok := transactionWrapper.Transaction.Message.(*certify.MsgCreateSecret)
+        if !ok {
+            return fail(fmt.Errorf("bad message type: %s", transactionWrapper.Transaction.Message.GetType()))
+        }
+        secret, ok := message.Secret.(*certify.SecretV1)
+        if !ok {
+            return fail(fmt.Errorf("bad secret type: %s", message.Secret.GetType()))
+        }
+        code, statusMessage := formatStatus(transactionWrapper.Status)
+        rows = append(rows, []string{
+            secret.CertificateUuid,
+            secret.CompanyChainID,
+            formatNonceTime(transactionWrapper.Transaction.NonceTime),
+            code,
+            statusMessage,
+        })
+    }
+    return printTable([]string{"UUID", "COMPANY CHAIN ID", "NONCE TIME", "CODE", "MESSAGE"}, rows)
+}
+
+type cliDecryptedSecret struct {
+    Uuid           string `json:"uuid"`
+    CompanyChainID string `json:"company_chain_id"`
+    NonceTime      string `json:"nonce_time"`
+    Code           string `json:"code"`
+    Message        string `json:"message"`
+    Content        string `json:"content,omitempty"`
+    // Metadata of the file carried by the secret, restored with -save-dir
+    File  *libs.SecretFile `json:"file,omitempty"`
+    Saved string           `json:"saved,omitempty"`
+    Error string           `json:"error,omitempty"`
+}
+
+func printDecryptedSecrets(output string, decryptedSecrets []libs.DecryptedSecret, saveDir string) int {
+    // Prints the opened secrets, restoring the files they carry to a directory if one is given, and fails if any of
+    // them could not be opened or restored
+
+    secrets := make([]cliDecryptedSecret, 0, len(decryptedSecrets))
+    failed := false
+    for _, decrypted := range decryptedSecrets {
+        code, statusMessage := formatStatus(decrypted.Status)
+        secret := cliDecryptedSecret{
+            Uuid:           decrypted.Uuid,
+            CompanyChainID: decrypted.CompanyChainID,
+            NonceTime:      decrypted.NonceTime.Local().Format(libs.DisplayTimeFormat),
+            Code:           code,
+            Message:        statusMessage,
+            Content:        decrypted.Text(),
+            File:           decrypted.File,
+        }
+        if decrypted.File != nil && saveDir != "" {
+            if secret.Saved, decrypted.Err = decrypted.File.Save(saveDir); decrypted.Err == nil {
+                secret.Content += " saved to " + secret.Saved
+            }
+        }
+        if decrypted.Err != nil {
+            secret.Error = decrypted.Err.Error()
+            failed = true
+        }
+        secrets = append(secrets, secret)
+    }
+
+    var code int
+    if output == "json" {
+        code = printJSON(secrets)
+    } else {
+        rows := make([][]string, 0, len(secrets))
+        for _, secret := range secrets {
+            content := secret.Content
+            if secret.Error != "" {
+                content = "error: " + secret.Error
+            }
+            rows = append(rows, []string{secret.Uuid, secret.NonceTime, secret.Code, content})
+        }
+        code = printTable([]string{"UUID", "NONCE TIME", "CODE", "CONTENT"}, rows)
+    }
+    if code == exitOk && failed {
+        return exitError
+    }
+    return code
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "strconv"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+func runDbVerify(args []string) int {
+    // Recomputes the audit log chain of the database and reports the rows modified or deleted outside the
+    // application, the first one first
+
+    flags := newCliFlags("db verify")
+    anchors := flags.Bool("anchors", false, "also compare the anchors with the certificates retrieved from the chain")
+    if !flags.parse(args) {
+        return exitUsage
+    }
+    config, ok := flags.config(false)
+    if !ok {
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    report, err := databaseDAO.VerifyAuditLog()
+    if err != nil {
+        return fail(err)
+    }
+    if *anchors {
+        problems, err := databaseDAO.VerifyAuditAnchors(config)
+        if err != nil {
+            return fail(err)
+        }
+        report.Problems = append(report.Problems, problems...)
+    }
+    fmt.Fprintln(os.Stderr, report.Summary())
+
+    var code int
+    if *flags.output == "json" {
+        if report.Problems == nil {
+            report.Problems = []libs.AuditProblem{}
+        }
+        code = printJSON(report)
+    } else {
+        rows := make([][]string, len(report.Problems))
+        for i, problem := range report.Problems {
+            seq := ""
+            if problem.Seq > 0 {
+                seq = "#" + strconv.FormatInt(problem.Seq, 10)
+            }
+            rows[i] = []string{seq, problem.Table, problem.Key, problem.Reason}
+        }
+        code = printTable([]string{"ENTRY", "TABLE", "KEY", "PROBLEM"}, rows)
+    }
+    if code == exitOk && len(report.Problems) > 0 {
+        return exitMismatch
+    }
+    return code
+}
+
+func runDbAnchor(args []string) int {
+    // Certifies the head of the audit log on chain, to be run periodically
+
+    flags := newCliFlags("db anchor")
+    force := flags.Bool("force", false, "anchor even if no entry was added since the last anchor")
+    if !flags.parse(args) {
+        return exitUsage
+    }
+    config, ok := flags.config(true)
+    if !ok {
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    due, err := databaseDAO.AnchorDue()
+    if err != nil {
+        return fail(err)
+    }
+    if !due && !*force {
+        fmt.Fprintln(os.Stderr, "The audit log head is already anchored")
+        return exitOk
+    }
+    if config.KeyName != "" && !unlockKeystore(&databaseDAO) {
+        return exitError
+    }
+    config.Keystore = databaseDAO.Keystore
+    config.History = &databaseDAO
+
+    anchor, err := databaseDAO.AnchorAuditLog(config)
+    if err != nil {
+        return fail(err)
+    }
+    if *flags.output == "json" {
+        return printJSON(anchor)
+    }
+    return printTable([]string{"ENTRY", "HASH", "UUID", "REQUEST DIGEST"}, [][]string{
+        {"#" + strconv.FormatInt(anchor.Seq, 10), anchor.Hash, anchor.Uuid, anchor.RequestDigest},
+    })
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "strconv"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+type cliBackupSummary struct {
+    File         string `json:"file"`
+    Encrypted    bool   `json:"encrypted"`
+    Certificates int    `json:"certificates"`
+    Secrets      int    `json:"secrets"`
+    Keys         int    `json:"keys"`
+    Contacts     int    `json:"contacts"`
+}
+
+func readBackupPassphrase(confirm bool) (string, error) {
+    // Reads the passphrase of a backup from KATENA_BACKUP_PASSPHRASE or the terminal
+
+    return readPassphrase("KATENA_BACKUP_PASSPHRASE", "Backup passphrase: ", confirm)
+}
+
+func readPassphrase(variable string, prompt string, confirm bool) (string, error) {
+    // Reads a passphrase from an environment variable or the terminal, asking for it twice when it is chosen
+
+    if passphrase, ok := os.LookupEnv(variable); ok {
+        return passphrase, nil
+    }
+    passphrase, err := readSecretLine(prompt)
+    if err != nil || !confirm {
+        return passphrase, err
+    }
+    confirmation, err := readSecretLine("Confirm passphrase: ")
+    if err != nil {
+        return "", err
+    }
+    if confirmation != passphrase {
+        return "", fmt.Errorf("passphrases do not match")
+    }
+    return passphrase, nil
+}
+
+func writeBackup(backup *libs.Backup, path string) error {
+    // Writes a backup file readable by its owner only, as it may hold keys in clear
+
+    return writeFile(path, func(file *os.File) error {
+        return backup.Write(file)
+    })
+}
+
+func readBackup(path string) (*libs.Backup, error) {
+    // Reads a backup file
+
+    file, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer func() {
+        _ = file.Close()
+    }()
+    backup, err := libs.ReadBackup(file)
+    if err != nil {
+        return nil, fmt.Errorf("%s: %s", path, err)
+    }
+    return backup, nil
+}
+
+func runDbExport(args []string) int {
+    // Writes the certificates, secrets and keystore keys of the database to a JSON backup
+
+    flags := newCliFlags("db export")
+    file := flags.String("file", "", "backup file to write")
+    encrypt := flags.Bool("encrypt", false, "encrypt the key material with a passphrase (env KATENA_BACKUP_PASSPHRASE)")
+    noKeys := flags.Bool("no-keys", false, "leave the keystore keys out of the backup")
+    if !flags.parse(args) || !requireFlags(flags, "file") {
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    hasKeyMaterial, err := databaseDAO.HasKeyMaterial()
+    if err != nil {
+        return fail(err)
+    }
+    initialized, err := databaseDAO.Keystore.Initialized()
+    if err != nil {
+        return fail(err)
+    }
+    if hasKeyMaterial && initialized && !unlockKeystore(&databaseDAO) {
+        return exitError
+    }
+
+    passphrase := ""
+    if *encrypt {
+        if passphrase, err = readBackupPassphrase(true); err != nil {
+            return fail(err)
+        }
+    } else if hasKeyMaterial {
+        fmt.Fprintln(os.Stderr, "Warning: the private keys are written in clear, use -encrypt to protect them.")
+    }
+    backup, err := databaseDAO.ExportBackup(passphrase, !*noKeys)
+    if err != nil {
+        return fail(err)
+    }
+    if err := writeBackup(backup, *file); err != nil {
+        return fail(err)
+    }
+
+    result := cliBackupSummary{
+        File:         *file,
+        Encrypted:    backup.Encryption != nil,
+        Certificates: len(backup.Certificates),
+        Secrets:      len(backup.Secrets),
+        Keys:         len(backup.Keys),
+        Contacts:     len(backup.Contacts),
+    }
+    if *flags.output == "json" {
+        return printJSON(result)
+    }
+    return printTable([]string{"FILE", "ENCRYPTED", "CERTIFICATES", "SECRETS", "KEYS", "CONTACTS"}, [][]string{
+        {result.File, strconv.FormatBool(result.Encrypted), strconv.Itoa(result.Certificates), strconv.Itoa(result.Secrets), strconv.Itoa(result.Keys),
+            strconv.Itoa(result.Contacts)},
+    })
+}
+
+func runDbImport(args []string) int {
+    // Merges a JSON backup into the database, reporting the entries added, skipped or in conflict
+
+    flags := newCliFlags("db import")
+    file := flags.String("file", "", "backup file to merge")
+    dryRun := flags.Bool("dry-run", false, "only report what would be merged")
+    if !flags.parse(args) || !requireFlags(flags, "file") {
+        return exitUsage
+    }
+
+    backup, err := readBackup(*file)
+    if err != nil {
+        return fail(err)
+    }
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    if backup.HasKeyMaterial() && !unlockKeystore(&databaseDAO) {
+        return exitError
+    }
+    passphrase := ""
+    if backup.Encryption != nil {
+        if passphrase, err = readBackupPassphrase(false); err != nil {
+            return fail(err)
+        }
+    }
+    report, err := databaseDAO.ImportBackup(backup, passphrase, *dryRun)
+    if err != nil {
+        return fail(err)
+    }
+
+    var code int
+    if *flags.output == "json" {
+        code = printJSON(report.Entries)
+    } else {
+        rows := make([][]string, len(report.Entries))
+        for i, entry := range report.Entries {
+            rows[i] = []string{entry.Kind, entry.Id, entry.Result, entry.Reason}
+        }
+        code = printTable([]string{"KIND", "ID", "RESULT", "REASON"}, rows)
+    }
+    fmt.Fprintln(os.Stderr, report.Summary())
+    if code == exitOk && report.Count(libs.BackupConflict) > 0 {
+        return exitMismatch
+    }
+    return code
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "io"
+    "os"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+func readContacts(path string) ([]libs.Contact, error) {
+    // Reads a contacts file, - standing for stdin
+
+    var reader io.Reader = os.Stdin
+    if path != "-" {
+        file, err := os.Open(path)
+        if err != nil {
+            return nil, err
+        }
+        defer func() {
+            _ = file.Close()
+        }()
+        reader = file
+    }
+    contacts, err := libs.ReadContacts(reader)
+    if err != nil {
+        return nil, fmt.Errorf("%s: %s", path, err)
+    }
+    return contacts, nil
+}
+
+func runContactsAdd(args []string) int {
+    // Adds a named recipient with its X25519 public key to the address book
+
+    flags := newCliFlags("contacts add")
+    name := flags.String("name", "", "contact name")
+    publicKey := flags.String("public-key", "", "base64 X25519 public key of the contact")
+    company := flags.String("company", "", "company chain id of the contact")
+    notes := flags.String("notes", "", "free notes")
+    if !flags.parse(args) || !requireFlags(flags, "name", "public-key") {
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    contact := libs.Contact{Name: *name, PublicKey: *publicKey, CompanyChainID: *company, Notes: *notes}
+    if err := databaseDAO.AddContact(contact); err != nil {
+        return fail(err)
+    }
+    return printContact(*flags.output, databaseDAO, *name)
+}
+
+func runContactsUpdate(args []string) int {
+    // Changes the public key, company or notes of a contact, the flags not given being left as they are
+
+    flags := newCliFlags("contacts update")
+    name := flags.String("name", "", "contact name")
+    publicKey := flags.String("public-key", "", "base64 X25519 public key of the contact")
+    company := flags.String("company", "", "company chain id of the contact")
+    notes := flags.String("notes", "", "free notes")
+    if !flags.parse(args) || !requireFlags(flags, "name") {
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    contact, err := databaseDAO.GetContact(*name)
+    if err != nil {
+        return fail(err)
+    }
+    flags.Visit(func(given *flag.Flag) {
+        switch given.Name {
+        case "public-key":
+            contact.PublicKey = *publicKey
+        case "company":
+            contact.CompanyChainID = *company
+        case "notes":
+            contact.Notes = *notes
+        }
+    })
+    if err := databaseDAO.UpdateContact(*contact); err != nil {
+        return fail(err)
+    }
+    return printContact(*flags.output, databaseDAO, *name)
+}
+
+func runContactsList(args []string) int {
+    // Lists the address book, optionally only the contacts matching a text
+
+    flags := newCliFlags("contacts list")
+    search := flags.String("search", "", "part of the name, fingerprint, public key, company chain id or notes")
+    if !flags.parse(args) {
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    contacts, err := databaseDAO.ListContacts(*search)
+    if err != nil {
+        return fail(err)
+    }
+    return printContacts(*flags.output, contacts)
+}
+
+func printContacts(output string, contacts []libs.Contact) int {
+    if output == "json" {
+        return printJSON(contacts)
+    }
+    rows := make([][]string, len(contacts))
+    for i, contact := range contacts {
+        rows[i] = []string{contact.Name, contact.Fingerprint, contact.CompanyChainID, contact.PublicKey, contact.Notes}
+    }
+    return printTable([]string{"NAME", "FINGERPRINT", "COMPANY CHAIN ID", "PUBLIC KEY", "NOTES"}, rows)
+}
+
+func printContact(output string, databaseDAO libs.DatabaseDAO, name string) int {
+    // Prints a contact as recorded, with its fingerprint
+
+    contact, err := databaseDAO.GetContact(name)
+    if err != nil {
+        return fail(err)
+    }
+    return printContacts(output, []libs.Contact{*contact})
+}
+
+func runContactsDelete(args []string) int {
+    // Removes a contact from the address book
+
+    flags := newCliFlags("contacts delete")
+    name := flags.String("name", "", "contact name")
+    if !flags.parse(args) || !requireFlags(flags, "name") {
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    if err := databaseDAO.RemoveContact(*name); err != nil {
+        return fail(err)
+    }
+    return exitOk
+}
+
+func runContactsExport(args []string) int {
+    // Writes the address book to a JSON file, or stdout for -
+
+    flags := newCliFlags("contacts export")
+    file := flags.String("file", "", "contacts file to write, - for stdout")
+    if !flags.parse(args) || !requireFlags(flags, "file") {
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    if *file == "-" {
+        err = databaseDAO.ExportContacts(os.Stdout)
+    } else {
+        err = writeFile(*file, func(file *os.File) error {
+            return databaseDAO.ExportContacts(file)
+        })
+    }
+    if err != nil {
+        return fail(err)
+    }
+    return exitOk
+}
+
+func runContactsImport(args []string) int {
+    // Merges a JSON contacts file into the address book, reporting the contacts added, skipped or in conflict
+
+    flags := newCliFlags("contacts import")
+    file := flags.String("file", "", "contacts file to merge, - for stdin")
+    dryRun := flags.Bool("dry-run", false, "only report what would be merged")
+    if !flags.parse(args) || !requireFlags(flags, "file") {
+        return exitUsage
+    }
+
+    contacts, err := readContacts(*file)
+    if err != nil {
+        return fail(err)
+    }
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    report, err := databaseDAO.ImportContacts(contacts, *dryRun)
+    if err != nil {
+        return fail(err)
+    }
+
+    var code int
+    if *flags.output == "json" {
+        code = printJSON(report.Entries)
+    } else {
+        rows := make([][]string, len(report.Entries))
+        for i, entry := range report.Entries {
+            rows[i] = []string{entry.Id, entry.Result, entry.Reason}
+        }
+        code = printTable([]string{"NAME", "RESULT", "REASON"}, rows)
+    }
+    fmt.Fprintln(os.Stderr, report.Summary())
+    if code == exitOk && report.Count(libs.BackupConflict) > 0 {
+        return exitMismatch
+    }
+    return code
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+
+    entityApi "github.com/katena-chain/sdk-go-client/entity/api"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+type cliFileCertificate struct {
+    File      string `json:"file"`
+    Algorithm string `json:"algorithm"`
+    Digest    string `json:"digest"`
+    cliTransactionStatus
+}
+
+func runCertFile(args []string) int {
+    // Certifies files by their digest, each one under a new UUID
+
+    flags := newCliFlags("cert file")
+    algorithm := flags.String("algorithm", libs.HashSHA256, "hash algorithm: "+strings.Join(libs.HashAlgorithms, ", "))
+    signer := flags.String("signer", "", "data signer")
+    waitFlags := newCliWaitFlags(flags)
+    if !flags.parse(args) || !requireFlags(flags, "signer") {
+        return exitUsage
+    }
+    paths := flags.Args()
+    if len(paths) == 0 {
+        fmt.Fprintln(os.Stderr, "missing files to certify")
+        return exitUsage
+    }
+    config, ok := flags.config(true)
+    if !ok {
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    if config.KeyName != "" && !unlockKeystore(&databaseDAO) {
+        return exitError
+    }
+    config.Keystore = databaseDAO.Keystore
+    config.History = &databaseDAO
+
+    // Hash every file before sending anything
+    certificates := make([]*libs.CertificateHandler, len(paths))
+    fileDigests := make([]*libs.FileDigest, len(paths))
+    for i, path := range paths {
+        certificates[i], fileDigests[i], err = libs.NewFileCertificate(config, path, strings.ToLower(*algorithm), *signer)
+        if err != nil {
+            return fail(err)
+        }
+    }
+
+    results := make([]cliFileCertificate, len(paths))
+    transactionStatuses := make([]*entityApi.TransactionStatus, len(paths))
+    for i, certificateData := range certificates {
+        var historyErr error
+        transactionStatuses[i], historyErr = certificateData.SendCertificate()
+        if transactionStatuses[i] == nil {
+            return fail(historyErr)
+        }
+        // The API answered : the certificate is recorded even if the history could not keep the transaction
+        err = databaseDAO.AddCertificateEntry(certificateData.UuidText, certificateData.SignatureText, certificateData.SignerText,
+            certificateData.Signed.Digest(), config.Profile)
+        if err == nil {
+            err = databaseDAO.AddCertificateFile(certificateData.UuidText, fileDigests[i])
+        }
+        if err != nil {
+            return fail(err)
+        }
+        // The transaction went out : a history error is only reported so that it is not sent again
+        if historyErr != nil {
+            warn(historyErr)
+        }
+    }
+
+    code := exitOk
+    for i, certificateData := range certificates {
+        status := waitFlags.track(&databaseDAO, config, libs.EntryCertificate, certificateData.UuidText, certificateData.Signed, transactionStatuses[i])
+        if transactionStatuses[i].Code != 0 || status == libs.StatusFailed {
+            code = exitRejected
+        } else if status == libs.StatusTimedOut && code == exitOk {
+            code = exitError
+        }
+        results[i] = cliFileCertificate{
+            File:      fileDigests[i].Path,
+            Algorithm: fileDigests[i].Algorithm,
+            Digest:    fileDigests[i].Digest,
+            cliTransactionStatus: cliTransactionStatus{
+                Uuid:          certificateData.UuidText,
+                RequestDigest: certificateData.Signed.Digest(),
+                Code:          transactionStatuses[i].Code,
+                Message:       transactionStatuses[i].Message,
+                Status:        status,
+            },
+        }
+    }
+
+    var printCode int
+    if *flags.output == "json" {
+        printCode = printJSON(results)
+    } else {
+        rows := make([][]string, len(results))
+        for i, result := range results {
+            rows[i] = []string{result.File, result.Uuid, result.RequestDigest, strconv.FormatUint(uint64(result.Code), 10), result.Message, result.Status}
+        }
+        printCode = printTable([]string{"FILE", "UUID", "REQUEST DIGEST", "CODE", "MESSAGE", "STATUS"}, rows)
+    }
+    if printCode != exitOk {
+        return printCode
+    }
+    return code
+}
+
+func runCertVerifyFile(args []string) int {
+    // Re-hashes a file and compares it with the digest of its on-chain certificate
+
+    flags := newCliFlags("cert verify-file")
+    uuidFlag := flags.String("uuid", "", "certificate UUID")
+    file := flags.String("file", "", "file to check, the one recorded when it was certified by default")
+    if !flags.parse(args) || !requireFlags(flags, "uuid") {
+        return exitUsage
+    }
+    config, ok := flags.config(false)
+    if !ok {
+        return exitUsage
+    }
+
+    path := *file
+    if path == "" {
+        databaseDAO, err := libs.InitDb()
+        if err != nil {
+            return fail(err)
+        }
+        fileDigest, err := databaseDAO.GetCertificateFile(*uuidFlag)
+        if err != nil {
+            return fail(fmt.Errorf("no file recorded for %s, use -file: %s", *uuidFlag, err))
+        }
+        path = fileDigest.Path
+    }
+
+    certificateData := libs.CertificateHandler{
+        Config:   config,
+        UuidText: *uuidFlag,
+    }
+    verification, err := certificateData.VerifyFile(path)
+    if err != nil {
+        return fail(err)
+    }
+
+    var code int
+    if *flags.output == "json" {
+        code = printJSON(verification)
+    } else {
+        result := "match"
+        if !verification.Match {
+            result = "mismatch"
+        }
+        code = printTable([]string{"FILE", "ALGORITHM", "RESULT", "EXPECTED", "ACTUAL"}, [][]string{
+            {verification.Path, verification.Algorithm, result, verification.Expected, verification.Actual},
+        })
+    }
+    if code == exitOk && !verification.Match {
+        return exitMismatch
+    }
+    return code
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/google/uuid"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+func groupContacts(databaseDAO *libs.DatabaseDAO, names string) ([]libs.Contact, error) {
+    // Returns the contacts of a comma separated list of names
+
+    var contacts []libs.Contact
+    for _, name := range strings.Split(names, ",") {
+        if name = strings.TrimSpace(name); name == "" {
+            continue
+        }
+        contact, err := databaseDAO.GetContact(name)
+        if err != nil {
+            return nil, err
+        }
+        contacts = append(contacts, *contact)
+    }
+    return contacts, nil
+}
+
+func runSecretSendMany(args []string) int {
+    // Seals a content for several contacts and sends it to each of them as its own secret, under a group UUID
+
+    flags := newCliFlags("secret send-many")
+    groupFlag := flags.String("group", "", "group UUID the secret UUIDs are derived from, generated if empty")
+    contentFlags := newCliContentFlags(flags)
+    recipients := flags.String("recipients", "", "comma separated names of the recipient contacts")
+    senderPublic := flags.String("sender-public-key", "", "base64 X25519 sender public key")
+    senderPrivate := flags.String("sender-private-key", envOrDefault("KATENA_SENDER_PRIVATE_KEY", ""), "base64 X25519 sender private key (env KATENA_SENDER_PRIVATE_KEY)")
+    senderKeyName := flags.String("sender-key-name", "", "name of the X25519 sender key in the keystore, instead of the sender keys")
+    dryRun := flags.Bool("dry-run", false, "only list the secret UUIDs and what would be sent")
+    waitFlags := newCliWaitFlags(flags)
+    if !flags.parse(args) || !requireFlags(flags, "recipients") || !contentFlags.given() {
+        return exitUsage
+    }
+    if *senderKeyName == "" && !requireFlags(flags, "sender-public-key", "sender-private-key") {
+        return exitUsage
+    }
+    config, ok := flags.config(true)
+    if !ok {
+        return exitUsage
+    }
+    if *groupFlag == "" {
+        *groupFlag = uuid.New().String()
+        fmt.Fprintln(os.Stderr, "Group "+*groupFlag)
+    }
+
+    contentBytes, err := contentFlags.read()
+    if err != nil {
+        return fail(err)
+    }
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    contacts, err := groupContacts(&databaseDAO, *recipients)
+    if err != nil {
+        return fail(err)
+    }
+    plan, err := libs.PlanSecretGroup(&databaseDAO, *groupFlag, contacts)
+    if err != nil {
+        return fail(err)
+    }
+
+    if !*dryRun && plan.Count(libs.ImportSend) > 0 {
+        if (config.KeyName != "" || *senderKeyName != "") && !unlockKeystore(&databaseDAO) {
+            return exitError
+        }
+        config.Keystore = databaseDAO.Keystore
+        config.History = &databaseDAO
+        runner := libs.SecretGroupRunner{Dao: &databaseDAO, Config: config, Content: contentBytes}
+        runner.SenderPubKey, runner.SenderPrivKey, err = senderKeys(&databaseDAO, *senderPublic, *senderPrivate, *senderKeyName)
+        if err != nil {
+            return fail(err)
+        }
+
+        fmt.Fprintln(os.Stderr, "Sending "+plan.Summary())
+        if err := runner.Run(plan); err != nil {
+            return fail(err)
+        }
+        // The secrets are all sent before being followed, so that waiting for one does not delay the others
+        for _, send := range plan.Sends {
+            if send.Result == libs.ImportAccepted {
+                send.Status = waitFlags.track(&databaseDAO, config, libs.EntrySecret, send.SecretUuid, send.Signed, send.SendStatus)
+            }
+        }
+    }
+
+    var code int
+    if *flags.output == "json" {
+        code = printJSON(plan.Sends)
+    } else {
+        rows := make([][]string, len(plan.Sends))
+        for i, send := range plan.Sends {
+            rows[i] = []string{send.ContactName, send.Fingerprint, send.SecretUuid, send.Result, send.Status, send.Reason}
+        }
+        code = printTable([]string{"RECIPIENT", "FINGERPRINT", "SECRET UUID", "RESULT", "STATUS", "REASON"}, rows)
+    }
+    fmt.Fprintln(os.Stderr, plan.Summary())
+    if code == exitOk && plan.Failed() {
+        return exitRejected
+    }
+    return code
+}
+
+func runSecretGroups(args []string) int {
+    // Lists the secret groups and the delivery of the secret of each recipient
+
+    flags := newCliFlags("secret groups")
+    groupFlag := flags.String("group", "", "only list this group")
+    if !flags.parse(args) {
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    var groups []libs.SecretGroup
+    if *groupFlag != "" {
+        group, err := databaseDAO.GetSecretGroup(*groupFlag)
+        if err != nil {
+            return fail(err)
+        }
+        groups = append(groups, *group)
+    } else if groups, err = databaseDAO.ListSecretGroups(); err != nil {
+        return fail(err)
+    }
+
+    if *flags.output == "json" {
+        return printJSON(groups)
+    }
+    var rows [][]string
+    for _, group := range groups {
+        for _, member := range group.Members {
+            rows = append(rows, []string{group.Uuid, member.ContactName, member.Fingerprint, member.SecretUuid, member.Delivery(), member.Reason})
+        }
+    }
+    return printTable([]string{"GROUP", "RECIPIENT", "FINGERPRINT", "SECRET UUID", "DELIVERY", "REASON"}, rows)
+}
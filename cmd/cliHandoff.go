@@ -0,0 +1,102 @@
+package main
+
+import (
+    "fmt"
+    "os"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+type cliImportedSecret struct {
+    Uuid           string `json:"uuid"`
+    CompanyChainID string `json:"company_chain_id"`
+}
+
+func writeHandoffBundle(bundle *libs.HandoffBundle, path string) error {
+    // Writes a hand-off bundle readable by its owner only
+
+    return writeFile(path, func(file *os.File) error {
+        return bundle.Write(file)
+    })
+}
+
+func readHandoffBundle(path string) (*libs.HandoffBundle, error) {
+    // Reads a hand-off bundle
+
+    file, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer func() {
+        _ = file.Close()
+    }()
+    bundle, err := libs.ReadHandoffBundle(file)
+    if err != nil {
+        return nil, fmt.Errorf("%s: %s", path, err)
+    }
+    return bundle, nil
+}
+
+func writeNewHandoff(path string, uuidText string, companyChainID string) (string, error) {
+    // Generates the recipient key pair of a secret and writes its hand-off bundle, returning the public key the secret
+    // is sealed to
+
+    passphrase, err := readPassphrase("KATENA_HANDOFF_PASSPHRASE", "Hand-off passphrase: ", true)
+    if err != nil {
+        return "", err
+    }
+    publicKey, bundle, err := libs.GenerateHandoff(uuidText, companyChainID, passphrase)
+    if err != nil {
+        return "", err
+    }
+    if err := writeHandoffBundle(bundle, path); err != nil {
+        return "", err
+    }
+    fingerprint, _ := libs.X25519Fingerprint(publicKey)
+    fmt.Fprintln(os.Stderr, "Hand-off bundle written to "+path+", recipient key "+fingerprint)
+    return publicKey, nil
+}
+
+func runSecretImport(args []string) int {
+    // Tracks the secret of a hand-off bundle with the recipient private key it holds
+
+    flags := newCliFlags("secret import")
+    file := flags.String("file", "", "hand-off bundle received from the sender (passphrase from env KATENA_HANDOFF_PASSPHRASE)")
+    if !flags.parse(args) || !requireFlags(flags, "file") {
+        return exitUsage
+    }
+    config, ok := flags.config(false)
+    if !ok {
+        return exitUsage
+    }
+
+    bundle, err := readHandoffBundle(*file)
+    if err != nil {
+        return fail(err)
+    }
+    passphrase, err := readPassphrase("KATENA_HANDOFF_PASSPHRASE", "Hand-off passphrase: ", false)
+    if err != nil {
+        return fail(err)
+    }
+    key, err := bundle.Open(passphrase)
+    if err != nil {
+        return fail(err)
+    }
+
+    // The keystore encrypts the recipient private key recorded in the database
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    if !unlockKeystore(&databaseDAO) {
+        return exitError
+    }
+    if err := databaseDAO.ImportHandoff(key, config.CompanyChainID, config.Profile); err != nil {
+        return fail(err)
+    }
+
+    if *flags.output == "json" {
+        return printJSON(cliImportedSecret{key.Uuid, key.CompanyChainID})
+    }
+    return printTable([]string{"UUID", "COMPANY CHAIN ID"}, [][]string{{key.Uuid, key.CompanyChainID}})
+}
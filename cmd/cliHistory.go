@@ -0,0 +1,149 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+func parseSince(value string) (time.Time, error) {
+    // Returns the start of a history period given as a duration back from now or a local date
+
+    if value == "" {
+        return time.Time{}, nil
+    }
+    if duration, err := time.ParseDuration(value); err == nil {
+        return time.Now().Add(-duration), nil
+    }
+    for _, layout := range []string{libs.DisplayTimeFormat, "2006-01-02"} {
+        if since, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+            return since, nil
+        }
+    }
+    return time.Time{}, fmt.Errorf("invalid -since %s, expected a duration like 24h or a date like 2006-01-02", value)
+}
+
+func runHistory(args []string) int {
+    // Lists the transactions broadcast from this database, filtered and sorted
+
+    flags := newCliFlags("history")
+    search := flags.String("search", "", "part of the UUID, local request digest (not an on-chain hash), company chain id or message")
+    kind := flags.String("kind", "", "only the certificate or secret transactions")
+    status := flags.String("status", "", "only the pending, committed, failed or timed-out transactions")
+    since := flags.String("since", "", "only the transactions sent during a duration like 24h or since a date like 2006-01-02")
+    sortBy := flags.String("sort", libs.HistorySortColumns[0], "sort column : "+strings.Join(libs.HistorySortColumns, ", "))
+    descending := flags.Bool("desc", false, "sort in descending order")
+    limit := flags.Int("limit", 0, "maximum number of transactions listed")
+    if !flags.parse(args) {
+        return exitUsage
+    }
+    sinceTime, err := parseSince(*since)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    // Only an explicit -profile filters the history, the active profile does not
+    entries, err := databaseDAO.ListTransactions(libs.HistoryFilter{
+        Search:     *search,
+        Kind:       *kind,
+        Status:     *status,
+        Profile:    *flags.profile,
+        Since:      sinceTime,
+        SortBy:     *sortBy,
+        Descending: *descending,
+        Limit:      *limit,
+    })
+    if err != nil {
+        return fail(err)
+    }
+
+    if *flags.output == "json" {
+        if entries == nil {
+            entries = []libs.HistoryEntry{}
+        }
+        return printJSON(entries)
+    }
+    rows := make([][]string, len(entries))
+    for i, entry := range entries {
+        rows[i] = []string{strconv.FormatInt(entry.Id, 10), libs.LocalTime(entry.CreatedAt), entry.Profile, entry.Kind, entry.Uuid,
+            entry.Status, strconv.FormatUint(uint64(entry.Code), 10), entry.Message, entry.RequestDigest}
+    }
+    return printTable([]string{"ID", "SENT AT", "PROFILE", "KIND", "UUID", "STATUS", "CODE", "MESSAGE", "REQUEST DIGEST"}, rows)
+}
+
+func runHistoryShow(args []string) int {
+    // Prints a transaction of the history, or only the bytes that were broadcast
+
+    flags := newCliFlags("history show")
+    id := flags.Int64("id", 0, "transaction id, as listed by history")
+    raw := flags.Bool("raw", false, "only print the signed transaction, to broadcast it again with tx broadcast -file -")
+    if !flags.parse(args) {
+        return exitUsage
+    }
+    if *id <= 0 {
+        fmt.Fprintln(os.Stderr, "missing required flag: -id")
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    entry, err := databaseDAO.GetTransaction(*id)
+    if err != nil {
+        return fail(err)
+    }
+    if *raw {
+        fmt.Println(entry.SignedBytes)
+        return exitOk
+    }
+    if *flags.output == "json" {
+        return printJSON(entry)
+    }
+    fields := append(entry.Fields(), libs.SummaryField{Label: "Signed transaction", Value: entry.SignedBytes})
+    rows := make([][]string, len(fields))
+    for i, field := range fields {
+        rows[i] = []string{field.Label, field.Value}
+    }
+    return printTable([]string{"FIELD", "VALUE"}, rows)
+}
+
+func runHistoryRefresh(args []string) int {
+    // Polls once the pending and timed out transactions and lists the ones whose status changed
+
+    flags := newCliFlags("history refresh")
+    if !flags.parse(args) {
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    // Every transaction is polled with the API and company it was sent with
+    changed, err := libs.NewStatusTracker(&databaseDAO, libs.Config{}).Refresh()
+    if err != nil {
+        return fail(err)
+    }
+
+    if *flags.output == "json" {
+        if changed == nil {
+            changed = []libs.HistoryEntry{}
+        }
+        return printJSON(changed)
+    }
+    rows := make([][]string, len(changed))
+    for i, entry := range changed {
+        rows[i] = []string{strconv.FormatInt(entry.Id, 10), entry.Kind, entry.Uuid, entry.Status, strconv.FormatUint(uint64(entry.Code), 10), entry.Message}
+    }
+    return printTable([]string{"ID", "KIND", "UUID", "STATUS", "CODE", "MESSAGE"}, rows)
+}
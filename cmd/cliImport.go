@@ -0,0 +1,121 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "strconv"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+func loadImportPlan(databaseDAO *libs.DatabaseDAO, path string, format string, companyChainID string) (*libs.ImportPlan, error) {
+    // Reads and validates an import file
+
+    if format == "" {
+        var err error
+        if format, err = libs.ImportFormatFromPath(path); err != nil {
+            return nil, err
+        }
+    }
+    file, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer func() {
+        _ = file.Close()
+    }()
+    rows, err := libs.ReadImportRows(file, format)
+    if err != nil {
+        return nil, fmt.Errorf("%s: %s", path, err)
+    }
+    return libs.PlanImport(rows, databaseDAO, companyChainID)
+}
+
+func writeImportReport(plan *libs.ImportPlan, path string) error {
+    // Writes the report file of an import
+
+    return writeFile(path, func(file *os.File) error {
+        return plan.WriteReport(file)
+    })
+}
+
+func runCertImport(args []string) int {
+    // Sends the certificates of a CSV or JSON lines file, skipping the rows already sent
+
+    flags := newCliFlags("cert import")
+    file := flags.String("file", "", "CSV file with a uuid, signature and signer header, or JSON lines file")
+    format := flags.String("format", "", "csv or jsonl, guessed from the file extension by default")
+    dryRun := flags.Bool("dry-run", false, "only validate the rows and print what would be sent")
+    workers := flags.Int("workers", libs.DefaultImportWorkers, "number of concurrent sends")
+    rate := flags.Float64("rate", libs.DefaultImportRate, "maximum transactions sent per second, 0 for no limit")
+    report := flags.String("report", "", "CSV report of the rows, next to the file by default")
+    waitFlags := newCliWaitFlags(flags)
+    if !flags.parse(args) || !requireFlags(flags, "file") {
+        return exitUsage
+    }
+    config, ok := flags.config(!*dryRun)
+    if !ok {
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    plan, err := loadImportPlan(&databaseDAO, *file, *format, config.CompanyChainID)
+    if err != nil {
+        return fail(err)
+    }
+
+    if !*dryRun && plan.Count(libs.ImportSend) > 0 {
+        if config.KeyName != "" && !unlockKeystore(&databaseDAO) {
+            return exitError
+        }
+        config.Keystore = databaseDAO.Keystore
+        config.History = &databaseDAO
+
+        fmt.Fprintln(os.Stderr, "Importing "+plan.Summary())
+        runner := libs.NewImportRunner(&databaseDAO, config)
+        runner.Workers = *workers
+        runner.Rate = *rate
+        sent, total := 0, plan.Count(libs.ImportSend)
+        runner.OnResult = func(result *libs.ImportResult) {
+            sent++
+            fmt.Fprintf(os.Stderr, "\r%d/%d", sent, total)
+        }
+        runner.Run(plan)
+        fmt.Fprintln(os.Stderr)
+        if *waitFlags.wait {
+            fmt.Fprintln(os.Stderr, "Waiting for "+strconv.Itoa(plan.Count(libs.ImportAccepted))+" accepted certificates")
+            runner.Track(plan, *waitFlags.timeout)
+        }
+
+        if err := databaseDAO.RecordImport(*file, plan); err != nil {
+            return fail(err)
+        }
+        reportPath := firstNonEmpty(*report, libs.ReportPath(*file))
+        if err := writeImportReport(plan, reportPath); err != nil {
+            return fail(err)
+        }
+        fmt.Fprintln(os.Stderr, "Report written to "+reportPath)
+    }
+
+    var code int
+    if *flags.output == "json" {
+        code = printJSON(plan.Rows)
+    } else {
+        rows := make([][]string, 0, len(plan.Rows))
+        for _, row := range plan.Rows {
+            rows = append(rows, []string{strconv.Itoa(row.Line), row.Uuid, row.Result, row.Reason, row.Message, row.Status, row.RequestDigest})
+        }
+        code = printTable([]string{"LINE", "UUID", "RESULT", "REASON", "MESSAGE", "STATUS", "REQUEST DIGEST"}, rows)
+    }
+    fmt.Fprintln(os.Stderr, plan.Summary())
+    if code == exitOk && plan.Failed() {
+        return exitRejected
+    }
+    if code == exitOk && plan.TimedOut() > 0 {
+        return exitError
+    }
+    return code
+}
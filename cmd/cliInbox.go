@@ -0,0 +1,134 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+func inboxSources(list string) ([]libs.InboxSource, error) {
+    // Returns the sources of a comma separated list
+
+    var sources []libs.InboxSource
+    for _, text := range strings.Split(list, ",") {
+        if strings.TrimSpace(text) == "" {
+            continue
+        }
+        source, err := libs.ParseInboxSource(text)
+        if err != nil {
+            return nil, err
+        }
+        sources = append(sources, source)
+    }
+    return sources, nil
+}
+
+func runSecretInbox(args []string) int {
+    // Retrieves the secrets of the configured UUIDs and companies and lists those the keystore X25519 keys open, or
+    // changes the configured sources
+
+    flags := newCliFlags("secret inbox")
+    add := flags.String("add", "", "comma separated sources to look at from now on : UUID, COMPANY/UUID or COMPANY")
+    remove := flags.String("remove", "", "comma separated sources to stop looking at")
+    list := flags.Bool("list", false, "only list the configured sources")
+    only := flags.String("sources", "", "comma separated sources to look at this time, instead of the configured ones")
+    previewLength := flags.Int("preview", libs.InboxPreviewLength, "length of the content previews")
+    if !flags.parse(args) {
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    if *add != "" || *remove != "" || *list {
+        return changeInboxSources(&databaseDAO, *add, *remove, *flags.output)
+    }
+
+    config, ok := flags.config(false)
+    if !ok {
+        return exitUsage
+    }
+    sources, err := inboxSources(*only)
+    if err != nil {
+        return fail(err)
+    }
+    if len(sources) == 0 {
+        if sources, err = databaseDAO.ListInboxSources(); err != nil {
+            return fail(err)
+        }
+    }
+    if len(sources) == 0 {
+        fmt.Fprintln(os.Stderr, "No inbox source, add some with -add or give them with -sources")
+        return exitUsage
+    }
+    if !unlockKeystore(&databaseDAO) {
+        return exitError
+    }
+
+    inbox, err := databaseDAO.ReadInbox(config, sources)
+    if err != nil {
+        return fail(err)
+    }
+    for _, message := range inbox.Errors {
+        fmt.Fprintln(os.Stderr, "Not retrieved:", message)
+    }
+
+    var code int
+    if *flags.output == "json" {
+        code = printJSON(inbox)
+    } else {
+        rows := make([][]string, len(inbox.Secrets))
+        for i, secret := range inbox.Secrets {
+            preview := secret.Preview(*previewLength)
+            if secret.Error != "" {
+                preview = "error: " + secret.Error
+            }
+            rows[i] = []string{secret.NonceTime.Local().Format(libs.DisplayTimeFormat), secret.CompanyChainID, secret.Uuid, secret.Sender(), secret.KeyName, preview}
+        }
+        code = printTable([]string{"NONCE TIME", "COMPANY", "UUID", "SENDER", "KEY", "PREVIEW"}, rows)
+    }
+    fmt.Fprintln(os.Stderr, inbox.Summary())
+    if code == exitOk && len(inbox.Errors) > 0 {
+        return exitError
+    }
+    return code
+}
+
+func changeInboxSources(databaseDAO *libs.DatabaseDAO, add string, remove string, output string) int {
+    // Adds and removes inbox sources, then lists them
+
+    added, err := inboxSources(add)
+    if err != nil {
+        return fail(err)
+    }
+    removed, err := inboxSources(remove)
+    if err != nil {
+        return fail(err)
+    }
+    for _, source := range added {
+        if _, err := databaseDAO.AddInboxSource(source.Source); err != nil {
+            return fail(err)
+        }
+    }
+    for _, source := range removed {
+        if err := databaseDAO.RemoveInboxSource(source.Source); err != nil {
+            return fail(err)
+        }
+    }
+
+    sources, err := databaseDAO.ListInboxSources()
+    if err != nil {
+        return fail(err)
+    }
+    if output == "json" {
+        return printJSON(sources)
+    }
+    rows := make([][]string, len(sources))
+    for i, source := range sources {
+        rows[i] = []string{source.Source, source.Describe(), source.CreatedAt}
+    }
+    return printTable([]string{"SOURCE", "LOOKS AT", "ADDED"}, rows)
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "strings"
+
+    "golang.org/x/crypto/ssh/terminal"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+// Shared so successive reads do not lose buffered input
+var stdinReader = bufio.NewReader(os.Stdin)
+
+func readSecretLine(prompt string) (string, error) {
+    // Reads a line from the terminal without echoing it, or from stdin when it is not a terminal
+
+    fmt.Fprint(os.Stderr, prompt)
+    if terminal.IsTerminal(int(os.Stdin.Fd())) {
+        value, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+        fmt.Fprintln(os.Stderr)
+        return string(value), err
+    }
+    line, err := stdinReader.ReadString('\n')
+    if err != nil && line == "" {
+        return "", err
+    }
+    return strings.TrimRight(line, "\r\n"), nil
+}
+
+func unlockKeystore(databaseDAO *libs.DatabaseDAO) bool {
+    // Unlocks the keystore with KATENA_PASSPHRASE or a prompted passphrase, printing the errors
+
+    initialized, err := databaseDAO.Keystore.Initialized()
+    if err != nil {
+        fail(err)
+        return false
+    }
+
+    passphrase, ok := os.LookupEnv("KATENA_PASSPHRASE")
+    if !ok {
+        if !initialized {
+            fmt.Fprintln(os.Stderr, "No keystore yet, choose its master passphrase.")
+        }
+        passphrase, err = readSecretLine("Keystore passphrase: ")
+        if err != nil {
+            fail(err)
+            return false
+        }
+        if !initialized {
+            confirmation, err := readSecretLine("Confirm passphrase: ")
+            if err != nil {
+                fail(err)
+                return false
+            }
+            if confirmation != passphrase {
+                fail(fmt.Errorf("passphrases do not match"))
+                return false
+            }
+        }
+    }
+
+    migrated, err := databaseDAO.Unlock(passphrase)
+    if err != nil {
+        fail(err)
+        return false
+    }
+    if migrated > 0 {
+        fmt.Fprintf(os.Stderr, "Encrypted %d recipient keys previously stored in clear.\n", migrated)
+    }
+    return true
+}
+
+func parseKeyType(keyType string) (string, error) {
+    // Returns the keystore type matching a command line key type
+
+    switch strings.ToUpper(keyType) {
+    case libs.KeyTypeED25519:
+        return libs.KeyTypeED25519, nil
+    case libs.KeyTypeX25519:
+        return libs.KeyTypeX25519, nil
+    case "":
+        return "", nil
+    default:
+        return "", fmt.Errorf("unknown key type: %s", keyType)
+    }
+}
+
+func runKeysImport(args []string) int {
+    // Encrypts a base64 private key into the keystore
+
+    flags := newCliFlags("keys import")
+    name := flags.String("name", "", "key name")
+    keyTypeFlag := flags.String("type", "", "key type : ed25519 or x25519")
+    key := flags.String("key", "-", "base64 private key, - to type it")
+    if !flags.parse(args) || !requireFlags(flags, "name", "type") {
+        return exitUsage
+    }
+    keyType, err := parseKeyType(*keyTypeFlag)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    if !unlockKeystore(&databaseDAO) {
+        return exitError
+    }
+    privateKey := *key
+    if privateKey == "-" {
+        privateKey, err = readSecretLine("Private key: ")
+        if err != nil {
+            return fail(err)
+        }
+    }
+    if err := databaseDAO.Keystore.AddKey(*name, keyType, strings.TrimSpace(privateKey)); err != nil {
+        return fail(err)
+    }
+    return exitOk
+}
+
+func runKeysList(args []string) int {
+    // Lists the keystore entries with their public keys
+
+    flags := newCliFlags("keys list")
+    keyTypeFlag := flags.String("type", "", "only list keys of a type : ed25519 or x25519")
+    if !flags.parse(args) {
+        return exitUsage
+    }
+    keyType, err := parseKeyType(*keyTypeFlag)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    entries, err := databaseDAO.Keystore.ListKeys(keyType)
+    if err != nil {
+        return fail(err)
+    }
+    if *flags.output == "json" {
+        return printJSON(entries)
+    }
+    rows := make([][]string, 0, len(entries))
+    for _, entry := range entries {
+        rows = append(rows, []string{entry.Name, entry.Type, entry.PublicKey})
+    }
+    return printTable([]string{"NAME", "TYPE", "PUBLIC KEY"}, rows)
+}
+
+func runKeysDelete(args []string) int {
+    // Removes a key from the keystore
+
+    flags := newCliFlags("keys delete")
+    name := flags.String("name", "", "key name")
+    if !flags.parse(args) || !requireFlags(flags, "name") {
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    if err := databaseDAO.Keystore.RemoveKey(*name); err != nil {
+        return fail(err)
+    }
+    return exitOk
+}
+
+type cliKeyPair struct {
+    Name       string `json:"name,omitempty"`
+    Type       string `json:"type"`
+    PublicKey  string `json:"public_key"`
+    PrivateKey string `json:"private_key,omitempty"`
+}
+
+func runKeysGenerate(args []string) int {
+    // Generates a key pair, printing it or saving its private key straight into the keystore
+
+    flags := newCliFlags("keys generate")
+    keyTypeFlag := flags.String("type", "", "key type : ed25519 or x25519")
+    save := flags.String("save", "", "save the key in the keystore under this name instead of printing the private key")
+    if !flags.parse(args) || !requireFlags(flags, "type") {
+        return exitUsage
+    }
+    keyType, err := parseKeyType(*keyTypeFlag)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        return exitUsage
+    }
+
+    publicKey, privateKey, err := libs.GenerateKeyPair(keyType)
+    if err != nil {
+        return fail(err)
+    }
+    keyPair := cliKeyPair{
+        Type:       keyType,
+        PublicKey:  publicKey,
+        PrivateKey: privateKey,
+    }
+
+    if *save != "" {
+        databaseDAO, err := libs.InitDb()
+        if err != nil {
+            return fail(err)
+        }
+        if !unlockKeystore(&databaseDAO) {
+            return exitError
+        }
+        if err := databaseDAO.Keystore.AddKey(*save, keyType, privateKey); err != nil {
+            return fail(err)
+        }
+        keyPair.Name = *save
+        keyPair.PrivateKey = ""
+    }
+
+    if *flags.output == "json" {
+        return printJSON(keyPair)
+    }
+    return printTable([]string{"NAME", "TYPE", "PUBLIC KEY", "PRIVATE KEY"}, [][]string{
+        {keyPair.Name, keyPair.Type, keyPair.PublicKey, keyPair.PrivateKey},
+    })
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+    "fmt"
+    "os"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+type cliLookup struct {
+    *libs.LookupResult
+    Tracked []string `json:"tracked"`
+}
+
+func runLookup(args []string) int {
+    // Retrieves the certificate and secrets of any UUID, from another company if asked, and optionally records them
+    // in the database so that they are listed like the sent ones
+
+    flags := newCliFlags("lookup")
+    uuidFlag := flags.String("uuid", "", "certificate UUID")
+    fromCompany := flags.String("from-company-chain-id", "", "company chain id the UUID belongs to, the configured one by default")
+    recipientPrivate := flags.String("recipient-private-key", "", "base64 X25519 recipient private key opening the secrets")
+    track := flags.Bool("track", false, "record the retrieved certificate and secrets in the database")
+    if !flags.parse(args) || !requireFlags(flags, "uuid") {
+        return exitUsage
+    }
+    config, ok := flags.config(false)
+    if !ok {
+        return exitUsage
+    }
+
+    result, err := libs.Lookup(config, *uuidFlag, *fromCompany)
+    if err != nil {
+        return fail(err)
+    }
+    if result.CertificateError != "" {
+        fmt.Fprintln(os.Stderr, "No certificate:", result.CertificateError)
+    }
+    if result.SecretsError != "" {
+        fmt.Fprintln(os.Stderr, "No secrets:", result.SecretsError)
+    }
+
+    var decryptedSecrets []libs.DecryptedSecret
+    if *recipientPrivate != "" && result.SecretCount() > 0 {
+        if decryptedSecrets, err = libs.OpenSecrets(result.Secrets, *recipientPrivate); err != nil {
+            return fail(err)
+        }
+    }
+
+    output := cliLookup{LookupResult: result, Tracked: []string{}}
+    trackFailed := false
+    if *track {
+        databaseDAO, err := libs.InitDb()
+        if err != nil {
+            return fail(err)
+        }
+        // The keystore encrypts the recipient private key recorded with the secrets
+        if *recipientPrivate != "" && result.SecretCount() > 0 && !unlockKeystore(&databaseDAO) {
+            return exitError
+        }
+        for _, kind := range []string{libs.EntryCertificate, libs.EntrySecret} {
+            if (kind == libs.EntryCertificate && result.Certificate == nil) || (kind == libs.EntrySecret && result.SecretCount() == 0) {
+                continue
+            }
+            if err := databaseDAO.TrackLookup(result, kind, *recipientPrivate, config.CompanyChainID, config.Profile); err != nil {
+                fmt.Fprintln(os.Stderr, "Not tracked:", err)
+                trackFailed = true
+                continue
+            }
+            output.Tracked = append(output.Tracked, kind)
+        }
+    }
+
+    var code int
+    if *flags.output == "json" {
+        code = printJSON(output)
+    } else {
+        var rows [][]string
+        if result.Certificate != nil {
+            certificate, err := libs.CertificateOf(result.Certificate.Transaction)
+            if err != nil {
+                return fail(err)
+            }
+            details := ""
+            if certificate.Seal != nil {
+                details = "signature " + libs.DisplayBytes(certificate.Seal.Signature) + ", signer " + libs.DisplayBytes(certificate.Seal.Signer)
+            }
+            statusCode, statusMessage := formatStatus(result.Certificate.Status)
+            rows = append(rows, []string{libs.EntryCertificate, formatNonceTime(result.Certificate.Transaction.NonceTime), statusCode, statusMessage, details})
+        }
+        for i := 0; i < result.SecretCount(); i++ {
+            transactionWrapper := result.Secrets.Transactions[i]
+            details := "not opened"
+            if decryptedSecrets != nil {
+                details = string(decryptedSecrets[i].Content)
+                if decryptedSecrets[i].Err != nil {
+                    details = "error: " + decryptedSecrets[i].Err.Error()
+                }
+            }
+            statusCode, statusMessage := formatStatus(transactionWrapper.Status)
+            rows = append(rows, []string{libs.EntrySecret, formatNonceTime(transactionWrapper.Transaction.NonceTime), statusCode, statusMessage, details})
+        }
+        code = printTable([]string{"KIND", "NONCE TIME", "CODE", "MESSAGE", "DETAILS"}, rows)
+        for _, kind := range output.Tracked {
+            fmt.Fprintln(os.Stderr, "Tracked the "+kind+" of "+result.Uuid+" from company "+result.CompanyChainID)
+        }
+    }
+    if code == exitOk && result.Certificate == nil && result.SecretCount() == 0 {
+        fmt.Fprintln(os.Stderr, "Nothing found for", result.Uuid, "on company", result.CompanyChainID)
+        return exitError
+    }
+    if code == exitOk && trackFailed {
+        return exitError
+    }
+    return code
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "net/http"
+    "os"
+    "strings"
+
+    "github.com/katena-chain/transactor-ui/libs"
+    "github.com/katena-chain/transactor-ui/mockapi"
+)
+
+func runMockServer(args []string) int {
+    // Serves a local stand-in of the Katena API until interrupted
+
+    flags := flag.NewFlagSet("mock-server", flag.ContinueOnError)
+    listen := flags.String("listen", "127.0.0.1:8080", "address to listen on")
+    chainID := flags.String("chain-id", libs.DefaultChainID, "chain id the seals are checked against")
+    dbPath := flags.String("db", "", "SQLite file keeping the transactions across restarts, in memory if empty")
+    latency := flags.Duration("latency", 0, "delay before a sent transaction is committed and can be retrieved")
+    failureRate := flags.Float64("failure-rate", 0, "fraction of the accepted transactions committed with a failure code")
+    errorRate := flags.Float64("error-rate", 0, "fraction of the requests answered by an HTTP error")
+    if err := flags.Parse(args); err != nil {
+        return exitUsage
+    }
+    if *failureRate < 0 || *failureRate > 1 || *errorRate < 0 || *errorRate > 1 {
+        fmt.Fprintln(os.Stderr, "rates must be between 0 and 1")
+        return exitUsage
+    }
+
+    var store mockapi.Store = mockapi.NewMemoryStore()
+    if *dbPath != "" {
+        sqliteStore, err := mockapi.NewSqliteStore(*dbPath)
+        if err != nil {
+            return fail(err)
+        }
+        store = sqliteStore
+    }
+
+    server := mockapi.NewServer(*chainID, store)
+    server.Latency = *latency
+    server.FailureRate = *failureRate
+    server.ErrorRate = *errorRate
+
+    fmt.Fprintln(os.Stderr, "Mock Katena API for chain "+*chainID+" listening, use -api-url http://"+*listen+strings.TrimSuffix(mockapi.ApiPrefix, "/"))
+    if err := http.ListenAndServe(*listen, server); err != nil {
+        return fail(err)
+    }
+    return exitOk
+}
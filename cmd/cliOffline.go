@@ -0,0 +1,294 @@
+package main
+
+import (
+    "fmt"
+    "os"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+type cliSignedTransaction struct {
+    Kind          string `json:"kind"`
+    Uuid          string `json:"uuid"`
+    File          string `json:"file"`
+    RequestDigest string `json:"request_digest,omitempty"`
+}
+
+func writeUnsignedTransaction(output string, unsigned *libs.UnsignedTransaction, path string) int {
+    // Writes an unsigned transaction request and prints what it creates
+
+    entry, err := libs.DescribeMessage(unsigned.Message)
+    if err != nil {
+        return fail(err)
+    }
+    err = writeFile(path, func(file *os.File) error {
+        return unsigned.Write(file)
+    })
+    if err != nil {
+        return fail(err)
+    }
+    return printSignedTransaction(output, cliSignedTransaction{Kind: entry.Kind, Uuid: entry.Uuid, File: path})
+}
+
+func printSignedTransaction(output string, result cliSignedTransaction) int {
+    // Prints the entry created by a request or signed transaction file
+
+    if output == "json" {
+        return printJSON(result)
+    }
+    return printTable([]string{"KIND", "UUID", "FILE", "REQUEST DIGEST"}, [][]string{{result.Kind, result.Uuid, result.File, result.RequestDigest}})
+}
+
+func runCertPrepare(args []string) int {
+    // Writes an unsigned certificate transaction request, to be signed offline with tx sign
+
+    flags := newCliFlags("cert prepare")
+    uuidFlag := flags.String("uuid", "", "certificate UUID")
+    signature := flags.String("signature", "", "data signature")
+    signer := flags.String("signer", "", "data signer")
+    out := flags.String("out", "", "unsigned transaction file to write")
+    if !flags.parse(args) || !requireFlags(flags, "uuid", "signature", "signer", "out") {
+        return exitUsage
+    }
+    config, ok := flags.config(false)
+    if !ok {
+        return exitUsage
+    }
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    if err := databaseDAO.CheckNewEntry(libs.EntryCertificate, *uuidFlag); err != nil {
+        return fail(err)
+    }
+    certificateData := libs.CertificateHandler{
+        Config:        config,
+        UuidText:      *uuidFlag,
+        SignatureText: *signature,
+        SignerText:    *signer,
+    }
+    return writeUnsignedTransaction(*flags.output, certificateData.UnsignedTransaction(), *out)
+}
+
+func runSecretPrepare(args []string) int {
+    // Seals a secret and writes its unsigned transaction request, to be signed offline with tx sign
+
+    flags := newCliFlags("secret prepare")
+    uuidFlag := flags.String("uuid", "", "certificate UUID the secret is attached to")
+    contentFlags := newCliContentFlags(flags)
+    recipientPublic := flags.String("recipient-public-key", "", "base64 X25519 recipient public key")
+    recipient := flags.String("recipient", "", "name of the recipient contact, instead of the recipient public key")
+    senderPublic := flags.String("sender-public-key", "", "base64 X25519 sender public key")
+    senderPrivate := flags.String("sender-private-key", envOrDefault("KATENA_SENDER_PRIVATE_KEY", ""), "base64 X25519 sender private key (env KATENA_SENDER_PRIVATE_KEY)")
+    senderKeyName := flags.String("sender-key-name", "", "name of the X25519 sender key in the keystore, instead of the sender keys")
+    out := flags.String("out", "", "unsigned transaction file to write")
+    if !flags.parse(args) || !requireFlags(flags, "uuid", "out") || !contentFlags.given() {
+        return exitUsage
+    }
+    if *recipient == "" && !requireFlags(flags, "recipient-public-key") {
+        return exitUsage
+    }
+    if *senderKeyName == "" && !requireFlags(flags, "sender-public-key", "sender-private-key") {
+        return exitUsage
+    }
+    config, ok := flags.config(false)
+    if !ok {
+        return exitUsage
+    }
+
+    contentBytes, err := contentFlags.read()
+    if err != nil {
+        return fail(err)
+    }
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    if *senderKeyName != "" && !unlockKeystore(&databaseDAO) {
+        return exitError
+    }
+    if err := databaseDAO.CheckNewEntry(libs.EntrySecret, *uuidFlag); err != nil {
+        return fail(err)
+    }
+    if *recipient != "" {
+        contact, err := databaseDAO.GetContact(*recipient)
+        if err != nil {
+            return fail(err)
+        }
+        *recipientPublic = contact.PublicKey
+    }
+
+    secretData := libs.SecretHandler{
+        Config:   config,
+        UuidText: *uuidFlag,
+        Content:  contentBytes,
+    }
+    if err := secretKeys(&databaseDAO, &secretData, *recipientPublic, *senderPublic, *senderPrivate, *senderKeyName); err != nil {
+        return fail(err)
+    }
+    unsigned, err := secretData.UnsignedTransaction()
+    if err != nil {
+        return fail(err)
+    }
+    return writeUnsignedTransaction(*flags.output, unsigned, *out)
+}
+
+func runTxSign(args []string) int {
+    // Signs an unsigned transaction request with the transactor key, without any network access
+
+    flags := newCliFlags("tx sign")
+    file := flags.String("file", "", "unsigned transaction file")
+    out := flags.String("out", "", "signed transaction file to write")
+    if !flags.parse(args) || !requireFlags(flags, "file", "out") {
+        return exitUsage
+    }
+    // Only the transactor key is needed, the chain id comes from the request
+    config, err := flags.profileConfig()
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        return exitUsage
+    }
+    if config.PrivKey == "" && config.KeyName == "" {
+        fmt.Fprintln(os.Stderr, "missing private key")
+        return exitUsage
+    }
+
+    requestFile, err := os.Open(*file)
+    if err != nil {
+        return fail(err)
+    }
+    unsigned, err := libs.ReadUnsignedTransaction(requestFile)
+    _ = requestFile.Close()
+    if err != nil {
+        return fail(fmt.Errorf("%s: %s", *file, err))
+    }
+    entry, err := libs.DescribeMessage(unsigned.Message)
+    if err != nil {
+        return fail(err)
+    }
+    fmt.Fprintf(os.Stderr, "Signing a %s for %s of company %s on chain %s\n", entry.Kind, entry.Uuid, entry.CompanyChainID, unsigned.ChainID)
+
+    if config.KeyName != "" {
+        databaseDAO, err := libs.InitDb()
+        if err != nil {
+            return fail(err)
+        }
+        if !unlockKeystore(&databaseDAO) {
+            return exitError
+        }
+        config.Keystore = databaseDAO.Keystore
+    }
+    privateKey, err := config.TransactorKey()
+    if err != nil {
+        return fail(err)
+    }
+    signed, err := unsigned.Sign(privateKey)
+    if err != nil {
+        return fail(err)
+    }
+    err = writeFile(*out, func(file *os.File) error {
+        return signed.Write(file)
+    })
+    if err != nil {
+        return fail(err)
+    }
+    return printSignedTransaction(*flags.output, cliSignedTransaction{Kind: entry.Kind, Uuid: entry.Uuid, File: *out, RequestDigest: signed.Digest()})
+}
+
+func readSignedTransaction(path string) (*libs.SignedTransaction, error) {
+    // Reads a signed transaction file, or stdin for -
+
+    if path == "-" {
+        return libs.ReadSignedTransaction(os.Stdin)
+    }
+    file, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer func() {
+        _ = file.Close()
+    }()
+    signed, err := libs.ReadSignedTransaction(file)
+    if err != nil {
+        return nil, fmt.Errorf("%s: %s", path, err)
+    }
+    return signed, nil
+}
+
+func runTxBroadcast(args []string) int {
+    // Broadcasts a signed transaction file unchanged, signed here or handed over by a partner, and records it in
+    // the database
+
+    flags := newCliFlags("tx broadcast")
+    file := flags.String("file", "", "signed transaction JSON file, - to read it from stdin")
+    check := flags.Bool("check", false, "only decode the transaction and check its seal, without sending it")
+    recipientPrivate := flags.String("recipient-private-key", "", "base64 X25519 recipient private key of a secret, stored in the database to decrypt it later")
+    waitFlags := newCliWaitFlags(flags)
+    if !flags.parse(args) || !requireFlags(flags, "file") {
+        return exitUsage
+    }
+    config, ok := flags.config(false)
+    if !ok {
+        return exitUsage
+    }
+
+    signed, err := readSignedTransaction(*file)
+    if err != nil {
+        return fail(err)
+    }
+    summary, err := signed.Summary(config.ChainID)
+    if err != nil {
+        return fail(err)
+    }
+    if *check {
+        var code int
+        if *flags.output == "json" {
+            code = printJSON(summary.Fields)
+        } else {
+            rows := make([][]string, len(summary.Fields))
+            for i, field := range summary.Fields {
+                rows[i] = []string{field.Label, field.Value}
+            }
+            code = printTable([]string{"FIELD", "VALUE"}, rows)
+        }
+        if code == exitOk && !summary.SealValid {
+            return exitMismatch
+        }
+        return code
+    }
+    fmt.Fprintln(os.Stderr, summary.String())
+    if !summary.SealValid {
+        return fail(fmt.Errorf("the transaction seal is not valid for chain %s", config.ChainID))
+    }
+    entry := summary.Entry
+
+    databaseDAO, err := libs.InitDb()
+    if err != nil {
+        return fail(err)
+    }
+    config.History = &databaseDAO
+    // The keystore encrypts the recipient private key recorded in the database
+    if entry.Kind == libs.EntrySecret && !unlockKeystore(&databaseDAO) {
+        return exitError
+    }
+    if err := databaseDAO.CheckNewEntry(entry.Kind, entry.Uuid); err != nil {
+        return fail(err)
+    }
+
+    transactionStatus, err := config.Broadcast(signed)
+    if transactionStatus == nil {
+        return fail(err)
+    }
+    // The API answered : the entry is recorded even if the history could not keep the transaction
+    if err := databaseDAO.RecordBroadcast(signed, config.Profile, *recipientPrivate); err != nil {
+        return fail(err)
+    }
+    // The transaction went out : a history error is only reported so that it is not sent again
+    if err != nil {
+        warn(err)
+    }
+    status := waitFlags.track(&databaseDAO, config, entry.Kind, entry.Uuid, signed, transactionStatus)
+
+    return printTransactionStatus(*flags.output, entry.Uuid, signed, transactionStatus, status)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+    "fmt"
+    "os"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+func runProfileList(args []string) int {
+    // Lists the saved profiles, marking the active one
+
+    flags := newCliFlags("profile list")
+    if !flags.parse(args) {
+        return exitUsage
+    }
+    store, err := libs.LoadProfiles()
+    if err != nil {
+        return fail(err)
+    }
+
+    if *flags.output == "json" {
+        return printJSON(store)
+    }
+    rows := make([][]string, 0, len(store.Profiles))
+    for _, name := range store.Names() {
+        profile := store.Profiles[name]
+        active := ""
+        if name == store.Active {
+            active = "*"
+        }
+        rows = append(rows, []string{active, profile.Name, profile.CompanyChainID, profile.ChainID, profile.ApiUrl})
+    }
+    return printTable([]string{"ACTIVE", "NAME", "COMPANY CHAIN ID", "CHAIN ID", "API URL"}, rows)
+}
+
+func runProfileSave(args []string) int {
+    // Saves the resolved configuration, without its private key, under a profile name and makes it active
+
+    flags := newCliFlags("profile save")
+    name := flags.String("name", "", "profile name")
+    if !flags.parse(args) || !requireFlags(flags, "name") {
+        return exitUsage
+    }
+    config, ok := flags.config(false)
+    if !ok {
+        return exitUsage
+    }
+
+    store, err := libs.LoadProfiles()
+    if err != nil {
+        return fail(err)
+    }
+    if err := store.Set(libs.NewProfile(*name, config)); err != nil {
+        return fail(err)
+    }
+    if err := store.Use(*name); err != nil {
+        return fail(err)
+    }
+    if err := store.Save(); err != nil {
+        return fail(err)
+    }
+    fmt.Fprintln(os.Stderr, "Profile saved:", *name)
+    return exitOk
+}
+
+func runProfileUse(args []string) int {
+    // Makes a profile the active one for the next launches
+
+    flags := newCliFlags("profile use")
+    name := flags.String("name", "", "profile name")
+    if !flags.parse(args) || !requireFlags(flags, "name") {
+        return exitUsage
+    }
+
+    store, err := libs.LoadProfiles()
+    if err != nil {
+        return fail(err)
+    }
+    if err := store.Use(*name); err != nil {
+        return fail(err)
+    }
+    if err := store.Save(); err != nil {
+        return fail(err)
+    }
+    return exitOk
+}
+
+func runProfileDelete(args []string) int {
+    // Removes a profile
+
+    flags := newCliFlags("profile delete")
+    name := flags.String("name", "", "profile name")
+    if !flags.parse(args) || !requireFlags(flags, "name") {
+        return exitUsage
+    }
+
+    store, err := libs.LoadProfiles()
+    if err != nil {
+        return fail(err)
+    }
+    if err := store.Remove(*name); err != nil {
+        return fail(err)
+    }
+    if err := store.Save(); err != nil {
+        return fail(err)
+    }
+    return exitOk
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+
+    "fyne.io/fyne"
+    "fyne.io/fyne/dialog"
+    "fyne.io/fyne/layout"
+    "fyne.io/fyne/widget"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+func auditStatus(databaseDAO *libs.DatabaseDAO) string {
+    // Returns the text of the audit log label
+
+    head, err := databaseDAO.AuditHead()
+    if err != nil {
+        return "Audit log : " + err.Error()
+    }
+    if head == nil {
+        return "Audit log : empty"
+    }
+    status := "Audit log : " + strconv.FormatInt(head.Seq, 10) + " entries"
+    anchors, err := databaseDAO.ListAuditAnchors()
+    if err != nil || len(anchors) == 0 {
+        return status + ", never anchored"
+    }
+    last := anchors[len(anchors)-1]
+    return status + ", last anchored at entry #" + strconv.FormatInt(last.Seq, 10) + " on " + libs.LocalTime(last.CreatedAt)
+}
+
+func anchorAuditLog(databaseDAO *libs.DatabaseDAO, config libs.Config) (*libs.AuditAnchor, error) {
+    // Anchors the audit log head with a configuration recording the anchor in the history
+
+    if err := config.Check(); err != nil {
+        return nil, err
+    }
+    config.History = databaseDAO
+    return databaseDAO.AnchorAuditLog(config)
+}
+
+func startAuditAnchoring(databaseDAO *libs.DatabaseDAO, readConfig func() libs.Config, enabled *widget.Check, statusLabel *widget.Label) {
+    // Anchors the audit log in the background at every interval while enabled, if entries were added since the
+    // last anchor
+
+    go func() {
+        for range time.Tick(libs.DefaultAnchorInterval) {
+            if !enabled.Checked {
+                continue
+            }
+            due, err := databaseDAO.AnchorDue()
+            if err == nil && due {
+                _, err = anchorAuditLog(databaseDAO, readConfig())
+            }
+            if err != nil {
+                statusLabel.SetText(auditStatus(databaseDAO) + " (automatic anchor failed : " + err.Error() + ")")
+                continue
+            }
+            statusLabel.SetText(auditStatus(databaseDAO))
+        }
+    }()
+}
+
+func showAnchorAuditDialog(window fyne.Window, databaseDAO *libs.DatabaseDAO, config libs.Config, statusLabel *widget.Label) {
+    // Certifies the audit log head on chain after a confirmation
+
+    head, err := databaseDAO.AuditHead()
+    if err != nil {
+        dialog.ShowError(err, window)
+        return
+    }
+    if head == nil {
+        dialog.ShowError(fmt.Errorf("the audit log is empty"), window)
+        return
+    }
+    message := "Certify the audit log head, entry #" + strconv.FormatInt(head.Seq, 10) + ",\non company " + config.CompanyChainID + " ?"
+    dialog.ShowConfirm("Anchor audit log...", message, func(confirm bool) {
+        if !confirm {
+            return
+        }
+        anchor, err := anchorAuditLog(databaseDAO, config)
+        statusLabel.SetText(auditStatus(databaseDAO))
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+        dialog.ShowInformation("Audit log anchored", "Entry #"+strconv.FormatInt(anchor.Seq, 10)+" certified as "+anchor.Uuid, window)
+    }, window)
+}
+
+func showVerifyDatabaseDialog(window fyne.Window, databaseDAO *libs.DatabaseDAO, config libs.Config) {
+    // Recomputes the audit log chain, optionally checking the anchors on chain, and lists the problems found
+
+    anchorsCheck := widget.NewCheck("Also compare the anchors with the chain", nil)
+    dialogContent := widget.NewVBox(
+        widget.NewLabel("Recomputes the audit log and compares it with the certificates, secrets and history rows."),
+        anchorsCheck,
+    )
+
+    dialog.ShowCustomConfirm("Verify database...", "Verify", "Cancel", dialogContent, func(confirm bool) {
+        if !confirm {
+            return
+        }
+        report, err := databaseDAO.VerifyAuditLog()
+        if err == nil && anchorsCheck.Checked {
+            var problems []libs.AuditProblem
+            problems, err = databaseDAO.VerifyAuditAnchors(config)
+            report.Problems = append(report.Problems, problems...)
+        }
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+        if len(report.Problems) == 0 {
+            dialog.ShowInformation("Database verified", report.Summary(), window)
+            return
+        }
+
+        lines := make([]string, len(report.Problems))
+        for i, problem := range report.Problems {
+            lines[i] = problem.String()
+            if problem.Seq > 0 {
+                lines[i] = "#" + strconv.FormatInt(problem.Seq, 10) + " " + lines[i]
+            }
+        }
+        problemsZone := widget.NewMultiLineEntry()
+        problemsZone.SetText(strings.Join(lines, "\n"))
+        problemsWrap := fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.Size{Width: 700, Height: 300}), widget.NewScrollContainer(problemsZone))
+        dialog.ShowCustom("Database tampered with", "Close", widget.NewVBox(
+            widget.NewLabel(report.Summary()),
+            problemsWrap,
+        ), window)
+    }, window)
+}
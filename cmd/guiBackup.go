@@ -0,0 +1,119 @@
+package main
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+
+    "fyne.io/fyne"
+    "fyne.io/fyne/dialog"
+    "fyne.io/fyne/widget"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+func showExportDatabaseDialog(window fyne.Window, databaseDAO *libs.DatabaseDAO) {
+    // Asks for a backup file and an optional passphrase, and writes the database to it
+
+    pathEntry := widget.NewEntry()
+    pathEntry.SetText("transactor-backup.json")
+    keysCheck := widget.NewCheck("Include the keystore keys", nil)
+    keysCheck.SetChecked(true)
+    passphraseEntry := widget.NewPasswordEntry()
+    confirmationEntry := widget.NewPasswordEntry()
+    dialogContent := widget.NewVBox(
+        widget.NewLabel("Backup file :"),
+        pathEntry,
+        keysCheck,
+        widget.NewLabel("Passphrase protecting the private keys, empty to write them in clear :"),
+        passphraseEntry,
+        widget.NewLabel("Confirm passphrase :"),
+        confirmationEntry,
+    )
+
+    dialog.ShowCustomConfirm("Export database...", "Export", "Cancel", dialogContent, func(confirm bool) {
+        if !confirm {
+            return
+        }
+        path := strings.TrimSpace(pathEntry.Text)
+        if path == "" {
+            dialog.ShowError(fmt.Errorf("missing backup file"), window)
+            return
+        }
+        if passphraseEntry.Text != confirmationEntry.Text {
+            dialog.ShowError(fmt.Errorf("passphrases do not match"), window)
+            return
+        }
+        backup, err := databaseDAO.ExportBackup(passphraseEntry.Text, keysCheck.Checked)
+        if err == nil {
+            err = writeBackup(backup, path)
+        }
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+
+        message := "Exported " + strconv.Itoa(len(backup.Certificates)) + " certificates, " + strconv.Itoa(len(backup.Secrets)) +
+            " secrets, " + strconv.Itoa(len(backup.Keys)) + " keys and " + strconv.Itoa(len(backup.Contacts)) + " contacts to " + path
+        if backup.Encryption == nil && backup.HasKeyMaterial() {
+            message += "\nThe private keys are written in clear."
+        }
+        dialog.ShowInformation("Database exported", message, window)
+    }, window)
+}
+
+func showImportDatabaseDialog(window fyne.Window, databaseDAO *libs.DatabaseDAO, onImported func()) {
+    // Asks for a backup file, shows what merging it would do and merges it
+
+    pathEntry := widget.NewEntry()
+    passphraseEntry := widget.NewPasswordEntry()
+    dialogContent := widget.NewVBox(
+        widget.NewLabel("Backup file :"),
+        pathEntry,
+        widget.NewLabel("Backup passphrase, if it is encrypted :"),
+        passphraseEntry,
+    )
+
+    // Build child dialog canvas
+    summaryLabel := widget.NewLabel("")
+    entriesZone := widget.NewMultiLineEntry()
+    childDialogContent := widget.NewVBox(
+        summaryLabel,
+        widget.NewLabel("Entries not added :"),
+        entriesZone,
+    )
+
+    dialog.ShowCustomConfirm("Import database...", "Check", "Cancel", dialogContent, func(confirm bool) {
+        if !confirm {
+            return
+        }
+        backup, err := readBackup(strings.TrimSpace(pathEntry.Text))
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+        report, err := databaseDAO.ImportBackup(backup, passphraseEntry.Text, true)
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+
+        // Dry run : list the entries left aside before merging
+        summaryLabel.SetText(report.Summary())
+        entriesZone.SetText(report.Lines(libs.BackupSkipped, libs.BackupConflict))
+
+        dialog.ShowCustomConfirm("Confirm import...", "Add "+strconv.Itoa(report.Count(libs.BackupAdded))+" entries", "Cancel",
+            childDialogContent, func(confirm bool) {
+                if !confirm {
+                    return
+                }
+                report, err := databaseDAO.ImportBackup(backup, passphraseEntry.Text, false)
+                if err != nil {
+                    dialog.ShowError(err, window)
+                    return
+                }
+                onImported()
+                dialog.ShowInformation("Database imported", report.Summary(), window)
+            }, window)
+    }, window)
+}
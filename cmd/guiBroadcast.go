@@ -0,0 +1,95 @@
+package main
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+
+    "fyne.io/fyne"
+    "fyne.io/fyne/dialog"
+    "fyne.io/fyne/widget"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+func showBroadcastFileDialog(window fyne.Window, databaseDAO *libs.DatabaseDAO, config libs.Config,
+    selectWidgetCertificates *widget.Select, selectWidgetSecrets *widget.Select) {
+    // Reads a signed certificate or secret transaction, shows what it contains and broadcasts it unchanged
+
+    pathEntry := widget.NewEntry()
+    recipientPrivateEntry := widget.NewPasswordEntry()
+    dialogContent := widget.NewVBox(
+        widget.NewLabel("Signed transaction JSON file :"),
+        pathEntry,
+        widget.NewLabel("Recipient private key of a secret, to decrypt it later (optional) :"),
+        recipientPrivateEntry,
+    )
+
+    // Build child dialog canvas
+    summaryZone := widget.NewMultiLineEntry()
+    childDialogContent := widget.NewVBox(
+        widget.NewLabel("Transaction :"),
+        summaryZone,
+    )
+
+    dialog.ShowCustomConfirm("Broadcast transaction file...", "Check", "Cancel", dialogContent, func(confirm bool) {
+        if !confirm {
+            return
+        }
+        signed, err := readSignedTransaction(strings.TrimSpace(pathEntry.Text))
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+        summary, err := signed.Summary(config.ChainID)
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+        if !summary.SealValid {
+            dialog.ShowError(fmt.Errorf("the transaction seal is not valid for chain %s :\n%s", config.ChainID, summary.String()), window)
+            return
+        }
+        entry := summary.Entry
+        // The recipient private key can only be recorded encrypted
+        if entry.Kind == libs.EntrySecret && !databaseDAO.Keystore.Unlocked() {
+            dialog.ShowError(fmt.Errorf("unlock the keystore first, the secret is recorded in the database"), window)
+            return
+        }
+        if err := databaseDAO.CheckNewEntry(entry.Kind, entry.Uuid); err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+        summaryZone.SetText(summary.String())
+
+        dialog.ShowCustomConfirm("Confirm broadcast...", "Broadcast "+entry.Kind, "Cancel", childDialogContent, func(confirm bool) {
+            if !confirm {
+                return
+            }
+            transactionStatus, historyErr := config.Broadcast(signed)
+            if transactionStatus == nil {
+                dialog.ShowError(historyErr, window)
+                return
+            }
+            // The API answered : the entry is recorded even if the history could not keep the transaction
+            if err := databaseDAO.RecordBroadcast(signed, config.Profile, strings.TrimSpace(recipientPrivateEntry.Text)); err != nil {
+                dialog.ShowError(err, window)
+                return
+            }
+
+            selectWidget := selectWidgetCertificates
+            if entry.Kind == libs.EntrySecret {
+                selectWidget = selectWidgetSecrets
+            }
+            showEntry(window, databaseDAO, selectWidget, entry.Kind, entry.Uuid)
+            trackTransaction(window, databaseDAO, config, selectWidget, entry.Kind, entry.Uuid, signed, transactionStatus)
+            if historyErr != nil {
+                dialog.ShowError(historyErr, window)
+                return
+            }
+
+            dialog.ShowInformation("Transaction status :", "Transaction code : "+strconv.FormatUint(uint64(transactionStatus.Code), 10)+
+                "\nTransaction message : "+transactionStatus.Message, window)
+        }, window)
+    }, window)
+}
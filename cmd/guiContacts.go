@@ -0,0 +1,260 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+    "text/tabwriter"
+
+    "fyne.io/fyne"
+    "fyne.io/fyne/dialog"
+    "fyne.io/fyne/layout"
+    "fyne.io/fyne/widget"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+func contactRows(contacts []libs.Contact) string {
+    // Returns the contacts as aligned columns, for a monospace label
+
+    var builder strings.Builder
+    writer := tabwriter.NewWriter(&builder, 0, 4, 2, ' ', 0)
+    fmt.Fprintln(writer, "NAME\tFINGERPRINT\tCOMPANY CHAIN ID\tNOTES")
+    for _, contact := range contacts {
+        fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", contact.Name, contact.Fingerprint, contact.CompanyChainID,
+            strings.Replace(contact.Notes, "\n", " ", -1))
+    }
+    _ = writer.Flush()
+    return builder.String()
+}
+
+func newContactPicker(window fyne.Window, databaseDAO *libs.DatabaseDAO, onPicked func(contact libs.Contact)) fyne.CanvasObject {
+    // Builds a search entry and a select listing the matching contacts, calling back with the chosen one
+
+    searchEntry := widget.NewEntry()
+    searchEntry.SetPlaceHolder("Search the contacts by name, fingerprint, company or notes")
+    contacts := []libs.Contact{}
+    contactSelect := widget.NewSelect([]string{}, func(option string) {
+        for _, contact := range contacts {
+            if option == contact.Label() {
+                onPicked(contact)
+                return
+            }
+        }
+    })
+    reload := func() {
+        var err error
+        if contacts, err = databaseDAO.ListContacts(searchEntry.Text); err != nil {
+            contacts = []libs.Contact{}
+        }
+        options := make([]string, len(contacts))
+        for i, contact := range contacts {
+            options[i] = contact.Label()
+        }
+        contactSelect.Options = options
+        contactSelect.Selected = ""
+        window.Canvas().Refresh(contactSelect)
+    }
+    searchEntry.OnChanged = func(string) { reload() }
+    reload()
+    return widget.NewVBox(searchEntry, contactSelect)
+}
+
+func newContactsTab(window fyne.Window, databaseDAO *libs.DatabaseDAO) fyne.CanvasObject {
+    // Builds the Contacts tab listing the address book, with a form adding, updating and removing a contact
+
+    searchEntry := widget.NewEntry()
+    searchEntry.SetPlaceHolder("Name, fingerprint, public key, company chain id or notes")
+    rowsLabel := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+
+    nameEntry := widget.NewEntry()
+    publicKeyEntry := widget.NewEntry()
+    fingerprintLabel := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+    companyEntry := widget.NewEntry()
+    notesEntry := widget.NewMultiLineEntry()
+    publicKeyEntry.OnChanged = func(publicKey string) {
+        // The fingerprint is shown as the key is typed, to be compared with the one given by the contact
+        fingerprint, err := libs.X25519Fingerprint(publicKey)
+        if err != nil && publicKey != "" {
+            fingerprint = err.Error()
+        }
+        fingerprintLabel.SetText(fingerprint)
+    }
+    fillForm := func(contact libs.Contact) {
+        nameEntry.SetText(contact.Name)
+        publicKeyEntry.SetText(contact.PublicKey)
+        companyEntry.SetText(contact.CompanyChainID)
+        notesEntry.SetText(contact.Notes)
+    }
+
+    contacts := []libs.Contact{}
+    rowSelect := widget.NewSelect([]string{}, func(option string) {
+        for _, contact := range contacts {
+            if option == contact.Label() {
+                fillForm(contact)
+                return
+            }
+        }
+    })
+    reload := func() {
+        var err error
+        contacts, err = databaseDAO.ListContacts(searchEntry.Text)
+        if err != nil {
+            rowsLabel.SetText("Cannot read the contacts : " + err.Error())
+            return
+        }
+        rowsLabel.SetText(contactRows(contacts))
+        options := make([]string, len(contacts))
+        for i, contact := range contacts {
+            options[i] = contact.Label()
+        }
+        rowSelect.Options = options
+        rowSelect.Selected = ""
+        window.Canvas().Refresh(rowSelect)
+    }
+    searchEntry.OnChanged = func(string) { reload() }
+
+    formContact := func() libs.Contact {
+        return libs.Contact{Name: nameEntry.Text, PublicKey: publicKeyEntry.Text, CompanyChainID: companyEntry.Text, Notes: notesEntry.Text}
+    }
+    saveButton := widget.NewButton("Save", func() {
+        contact := formContact()
+        existing, err := databaseDAO.GetContact(strings.TrimSpace(contact.Name))
+        if err != nil {
+            // A new name adds a contact
+            if err := databaseDAO.AddContact(contact); err != nil {
+                dialog.ShowError(err, window)
+                return
+            }
+            reload()
+            return
+        }
+        message := "Replace the contact " + existing.Name + " ?"
+        if fingerprint, err := libs.X25519Fingerprint(contact.PublicKey); err == nil && fingerprint != existing.Fingerprint {
+            message += "\nIts public key changes from\n" + existing.Fingerprint + " to\n" + fingerprint
+        }
+        dialog.ShowConfirm("Update contact", message, func(confirm bool) {
+            if !confirm {
+                return
+            }
+            if err := databaseDAO.UpdateContact(contact); err != nil {
+                dialog.ShowError(err, window)
+                return
+            }
+            reload()
+        }, window)
+    })
+    deleteButton := widget.NewButton("Delete", func() {
+        name := strings.TrimSpace(nameEntry.Text)
+        dialog.ShowConfirm("Delete contact", "Remove "+name+" from the address book ?", func(confirm bool) {
+            if !confirm {
+                return
+            }
+            if err := databaseDAO.RemoveContact(name); err != nil {
+                dialog.ShowError(err, window)
+                return
+            }
+            fillForm(libs.Contact{})
+            reload()
+        }, window)
+    })
+
+    // The scrollcontainer has to be wrapped in a fixed grid layout in order to be displayed in the proper size
+    rowsWrap := fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.Size{Width: 1000, Height: 250}), widget.NewScrollContainer(rowsLabel))
+    notesWrap := fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.Size{Width: 800, Height: 80}), widget.NewScrollContainer(notesEntry))
+
+    tabContacts := widget.NewVBox(
+        widget.NewForm(&widget.FormItem{Text: "Search", Widget: searchEntry}),
+        rowsWrap,
+        rowSelect,
+        widget.NewForm(
+            &widget.FormItem{Text: "Name", Widget: nameEntry},
+            &widget.FormItem{Text: "X25519 public key", Widget: publicKeyEntry},
+            &widget.FormItem{Text: "Fingerprint", Widget: fingerprintLabel},
+            &widget.FormItem{Text: "Company chain id", Widget: companyEntry},
+            &widget.FormItem{Text: "Notes", Widget: notesWrap},
+        ),
+        widget.NewHBox(
+            widget.NewButton("New", func() { fillForm(libs.Contact{}) }),
+            saveButton,
+            deleteButton,
+            widget.NewButton("Import contacts...", func() { showImportContactsDialog(window, databaseDAO, reload) }),
+            widget.NewButton("Export contacts...", func() { showExportContactsDialog(window, databaseDAO) }),
+        ),
+    )
+    reload()
+    return tabContacts
+}
+
+func showExportContactsDialog(window fyne.Window, databaseDAO *libs.DatabaseDAO) {
+    // Asks for a file and writes the address book to it as JSON
+
+    pathEntry := widget.NewEntry()
+    pathEntry.SetText("transactor-contacts.json")
+    dialog.ShowCustomConfirm("Export contacts...", "Export", "Cancel", widget.NewVBox(widget.NewLabel("Contacts file :"), pathEntry), func(confirm bool) {
+        if !confirm {
+            return
+        }
+        path := strings.TrimSpace(pathEntry.Text)
+        if path == "" {
+            dialog.ShowError(fmt.Errorf("missing contacts file"), window)
+            return
+        }
+        err := writeFile(path, func(file *os.File) error {
+            return databaseDAO.ExportContacts(file)
+        })
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+        dialog.ShowInformation("Contacts exported", "Address book written to "+path, window)
+    }, window)
+}
+
+func showImportContactsDialog(window fyne.Window, databaseDAO *libs.DatabaseDAO, onImported func()) {
+    // Asks for a contacts file, shows what merging it would do and merges it
+
+    pathEntry := widget.NewEntry()
+    summaryLabel := widget.NewLabel("")
+    entriesZone := widget.NewMultiLineEntry()
+    childDialogContent := widget.NewVBox(
+        summaryLabel,
+        widget.NewLabel("Contacts not added :"),
+        entriesZone,
+    )
+
+    dialog.ShowCustomConfirm("Import contacts...", "Check", "Cancel", widget.NewVBox(widget.NewLabel("Contacts file :"), pathEntry), func(confirm bool) {
+        if !confirm {
+            return
+        }
+        contacts, err := readContacts(strings.TrimSpace(pathEntry.Text))
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+        report, err := databaseDAO.ImportContacts(contacts, true)
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+
+        // Dry run : list the contacts left aside before merging
+        summaryLabel.SetText(report.Summary())
+        entriesZone.SetText(report.Lines(libs.BackupSkipped, libs.BackupConflict))
+
+        dialog.ShowCustomConfirm("Confirm import...", "Add "+strconv.Itoa(report.Count(libs.BackupAdded))+" contacts", "Cancel",
+            childDialogContent, func(confirm bool) {
+                if !confirm {
+                    return
+                }
+                report, err := databaseDAO.ImportContacts(contacts, false)
+                if err != nil {
+                    dialog.ShowError(err, window)
+                    return
+                }
+                onImported()
+                dialog.ShowInformation("Contacts imported", report.Summary(), window)
+            }, window)
+    }, window)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+    "strconv"
+
+    "fyne.io/fyne"
+    "fyne.io/fyne/layout"
+    "fyne.io/fyne/theme"
+    "fyne.io/fyne/widget"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+// Decoded fields of retrieved transactions, each one with a copy button, or the tree of the raw JSON returned by the
+// API
+type transactionView struct {
+    window     fyne.Window
+    fields     *widget.Box
+    fieldsWrap fyne.CanvasObject
+    raw        *jsonTreeView
+    rawWrap    fyne.CanvasObject
+    rawCheck   *widget.Check
+    scroll     *widget.ScrollContainer
+    Content    fyne.CanvasObject
+}
+
+func newTransactionView(window fyne.Window, size fyne.Size) *transactionView {
+    // Builds an empty view, its fields scrollcontainer and JSON tree wrapped in fixed grid layouts of the given size
+
+    view := &transactionView{
+        window: window,
+        fields: widget.NewVBox(),
+        raw:    newJSONTreeView(window),
+    }
+    content := widget.NewVBox()
+    view.scroll = widget.NewScrollContainer(view.fields)
+    view.fieldsWrap = fyne.NewContainerWithLayout(layout.NewFixedGridLayout(size), view.scroll)
+    view.rawWrap = fyne.NewContainerWithLayout(layout.NewFixedGridLayout(size), view.raw.Content)
+    view.rawWrap.Hide()
+    view.rawCheck = widget.NewCheck("Show raw JSON", func(raw bool) {
+        if raw {
+            view.fieldsWrap.Hide()
+            view.rawWrap.Show()
+        } else {
+            view.rawWrap.Hide()
+            view.fieldsWrap.Show()
+        }
+        widget.Refresh(content)
+    })
+    content.Children = []fyne.CanvasObject{view.rawCheck, view.fieldsWrap, view.rawWrap}
+    view.Content = content
+    return view
+}
+
+func (view *transactionView) fieldRow(field libs.SummaryField) fyne.CanvasObject {
+    // Returns the line of a field, its value being copied to the clipboard by the button
+
+    value := field.Value
+    return widget.NewHBox(
+        widget.NewButtonWithIcon("", theme.ContentCopyIcon(), func() {
+            view.window.Clipboard().SetContent(value)
+        }),
+        widget.NewLabelWithStyle(field.Label+" :", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+        widget.NewLabel(value),
+    )
+}
+
+func (view *transactionView) setChildren(children []fyne.CanvasObject, raw string) {
+    view.fields.Children = children
+    widget.Refresh(view.fields)
+    view.raw.SetJSON(raw)
+    widget.Refresh(view.scroll)
+}
+
+func (view *transactionView) SetTransactions(summaries []*libs.TransactionSummary, raw string) {
+    // Shows the decoded fields of transactions, under a numbered title when there are several
+
+    var children []fyne.CanvasObject
+    for i, summary := range summaries {
+        if len(summaries) > 1 {
+            title := "Transaction " + strconv.Itoa(i+1) + "/" + strconv.Itoa(len(summaries))
+            children = append(children, widget.NewLabelWithStyle(title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+        }
+        for _, field := range summary.Fields {
+            children = append(children, view.fieldRow(field))
+        }
+    }
+    view.setChildren(children, raw)
+}
+
+func (view *transactionView) SetMessage(message string) {
+    // Replaces the fields by a message, like when nothing was retrieved
+
+    view.setChildren([]fyne.CanvasObject{widget.NewLabel(message)}, "")
+}
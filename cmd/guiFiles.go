@@ -0,0 +1,143 @@
+package main
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+
+    "fyne.io/fyne"
+    "fyne.io/fyne/dialog"
+    "fyne.io/fyne/widget"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+func showCertifyFilesDialog(window fyne.Window, databaseDAO *libs.DatabaseDAO, config libs.Config, selectWidgetCertificates *widget.Select) {
+    // Asks for files to certify by their digest, previews the certificates and sends them, one per file
+
+    pathsEntry := widget.NewMultiLineEntry()
+    algorithmRadio := widget.NewRadio(libs.HashAlgorithms, nil)
+    algorithmRadio.SetSelected(libs.HashSHA256)
+    signerEntry := widget.NewEntry()
+    dialogContent := widget.NewVBox(
+        widget.NewLabel("Files, one path per line :"),
+        pathsEntry,
+        widget.NewLabel("Hash algorithm :"),
+        algorithmRadio,
+        widget.NewLabel("Signer :"),
+        signerEntry,
+    )
+
+    // Build child dialog canvas
+    previewZone := widget.NewMultiLineEntry()
+    childDialogContent := widget.NewVBox(
+        widget.NewLabel("Expected certificates :"),
+        previewZone,
+    )
+
+    dialog.ShowCustomConfirm("Certify files...", "Confirm", "Cancel", dialogContent, func(confirm bool) {
+        if !confirm {
+            return
+        }
+        var paths []string
+        for _, line := range strings.Split(pathsEntry.Text, "\n") {
+            if path := strings.TrimSpace(line); path != "" {
+                paths = append(paths, path)
+            }
+        }
+        if len(paths) == 0 || signerEntry.Text == "" {
+            dialog.ShowError(fmt.Errorf("at least a file and the signer are needed"), window)
+            return
+        }
+
+        // Hash every file and build its transaction before sending anything
+        certificates := make([]*libs.CertificateHandler, len(paths))
+        fileDigests := make([]*libs.FileDigest, len(paths))
+        preview := ""
+        for i, path := range paths {
+            var err error
+            certificates[i], fileDigests[i], err = libs.NewFileCertificate(config, path, algorithmRadio.Selected, signerEntry.Text)
+            if err != nil {
+                dialog.ShowError(err, window)
+                return
+            }
+            preview += fileDigests[i].Name + " (" + strconv.FormatInt(fileDigests[i].Size, 10) + " bytes)" +
+                "\n  UUID : " + certificates[i].UuidText +
+                "\n  Signature : " + fileDigests[i].Signature() +
+                "\n  Request digest : " + certificates[i].Signed.Digest() + "\n"
+        }
+        previewZone.SetText(preview)
+
+        dialog.ShowCustomConfirm("Confirm certificates...", "Send certificates", "Cancel", childDialogContent, func(confirm bool) {
+            if !confirm {
+                return
+            }
+
+            report := ""
+            for i, certificateData := range certificates {
+                // The API answered when a status comes back : the certificate is recorded even if the history could not
+                // keep the transaction
+                transactionStatus, historyErr := certificateData.SendCertificate()
+                err := historyErr
+                if transactionStatus != nil {
+                    err = databaseDAO.AddCertificateEntry(certificateData.UuidText, certificateData.SignatureText, certificateData.SignerText,
+                        certificateData.Signed.Digest(), config.Profile)
+                    if err == nil {
+                        err = databaseDAO.AddCertificateFile(certificateData.UuidText, fileDigests[i])
+                    }
+                }
+                if err != nil {
+                    report += fileDigests[i].Name + " : " + err.Error() + "\n"
+                    continue
+                }
+
+                showEntry(window, databaseDAO, selectWidgetCertificates, libs.EntryCertificate, certificateData.UuidText)
+                trackTransaction(window, databaseDAO, config, selectWidgetCertificates, libs.EntryCertificate,
+                    certificateData.UuidText, certificateData.Signed, transactionStatus)
+                report += fileDigests[i].Name + " : code " + strconv.FormatUint(uint64(transactionStatus.Code), 10) + ", " + transactionStatus.Message + "\n"
+                if historyErr != nil {
+                    report += fileDigests[i].Name + " : " + historyErr.Error() + "\n"
+                }
+            }
+            dialog.ShowInformation("Transaction status :", report, window)
+        }, window)
+    }, window)
+}
+
+func showVerifyFileDialog(window fyne.Window, databaseDAO *libs.DatabaseDAO, config libs.Config, certificateUuid string) {
+    // Re-hashes a file and compares it with the digest of the selected on-chain certificate
+
+    pathEntry := widget.NewEntry()
+    if fileDigest, err := databaseDAO.GetCertificateFile(certificateUuid); err == nil {
+        pathEntry.SetText(fileDigest.Path)
+    }
+    dialogContent := widget.NewVBox(
+        widget.NewLabel("Certificate : "+certificateUuid),
+        widget.NewLabel("File :"),
+        pathEntry,
+    )
+
+    dialog.ShowCustomConfirm("Verify file...", "Verify", "Cancel", dialogContent, func(confirm bool) {
+        if !confirm || pathEntry.Text == "" {
+            return
+        }
+        certificateData := libs.CertificateHandler{
+            Config:   config,
+            UuidText: certificateUuid,
+        }
+        verification, err := certificateData.VerifyFile(strings.TrimSpace(pathEntry.Text))
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+
+        result := "The file matches the certificate."
+        if !verification.Match {
+            result = "The file does NOT match the certificate."
+        }
+        dialog.ShowInformation("File verification", result+
+            "\nAlgorithm : "+verification.Algorithm+
+            "\nCertified digest : "+verification.Expected+
+            "\nFile digest : "+verification.Actual, window)
+    }, window)
+}
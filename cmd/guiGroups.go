@@ -0,0 +1,295 @@
+package main
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+    "sync"
+    "text/tabwriter"
+
+    "fyne.io/fyne"
+    "fyne.io/fyne/dialog"
+    "fyne.io/fyne/layout"
+    "fyne.io/fyne/widget"
+    "github.com/google/uuid"
+    "github.com/katena-chain/sdk-go-client/utils"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+type secretGroupsView struct {
+    window      fyne.Window
+    databaseDAO *libs.DatabaseDAO
+    // The trackers of the sent secrets reload the view from their goroutines
+    mutex        sync.Mutex
+    groups       []libs.SecretGroup
+    groupSelect  *widget.Select
+    summaryLabel *widget.Label
+    membersLabel *widget.Label
+    Content      fyne.CanvasObject
+}
+
+func groupOption(group *libs.SecretGroup) string {
+    // Returns the select option of a group, its UUID followed by the count of its recipients
+
+    return group.Uuid + " - " + group.Summary()
+}
+
+func groupFromOption(option string) string {
+    // Returns the UUID of a group option built by groupOption
+
+    return strings.SplitN(option, " - ", 2)[0]
+}
+
+func groupMemberRows(group *libs.SecretGroup) string {
+    // Returns the recipients of a group as aligned columns, for a monospace label
+
+    var builder strings.Builder
+    writer := tabwriter.NewWriter(&builder, 0, 4, 2, ' ', 0)
+    fmt.Fprintln(writer, "RECIPIENT\tFINGERPRINT\tSECRET UUID\tDELIVERY\tREASON")
+    for _, member := range group.Members {
+        fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", member.ContactName, member.Fingerprint, member.SecretUuid, member.Delivery(), member.Reason)
+    }
+    _ = writer.Flush()
+    return builder.String()
+}
+
+func newSecretGroupsView(window fyne.Window, databaseDAO *libs.DatabaseDAO, currentConfig func() libs.Config) *secretGroupsView {
+    // Builds the list of the secret groups, showing the delivery to each recipient of the selected one
+
+    view := &secretGroupsView{
+        window:       window,
+        databaseDAO:  databaseDAO,
+        summaryLabel: widget.NewLabel(""),
+        membersLabel: widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true}),
+    }
+    view.groupSelect = widget.NewSelect([]string{}, func(option string) {
+        view.mutex.Lock()
+        defer view.mutex.Unlock()
+        view.show(groupFromOption(option))
+    })
+
+    // The scrollcontainer has to be wrapped in a fixed grid layout in order to be displayed in the proper size
+    membersWrap := fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.Size{Width: 1000, Height: 150}), widget.NewScrollContainer(view.membersLabel))
+    view.Content = widget.NewVBox(
+        widget.NewLabel("Secret groups :"),
+        view.groupSelect,
+        view.summaryLabel,
+        membersWrap,
+        widget.NewHBox(
+            widget.NewButton("Send to several contacts...", func() {
+                showSendGroupDialog(window, databaseDAO, currentConfig(), view)
+            }),
+            widget.NewButton("Refresh groups", func() { view.Reload("") }),
+        ),
+    )
+    view.Reload("")
+    return view
+}
+
+func (view *secretGroupsView) Reload(selected string) {
+    // Reads the groups again, keeping the shown one unless another one is given
+
+    view.mutex.Lock()
+    defer view.mutex.Unlock()
+    if selected == "" && view.groupSelect.Selected != "" {
+        selected = groupFromOption(view.groupSelect.Selected)
+    }
+    groups, err := view.databaseDAO.ListSecretGroups()
+    if err != nil {
+        view.summaryLabel.SetText("Cannot read the secret groups : " + err.Error())
+        return
+    }
+    view.groups = groups
+    options := make([]string, len(groups))
+    for i := range groups {
+        options[i] = groupOption(&groups[i])
+    }
+    view.groupSelect.Options = options
+    view.groupSelect.Selected = ""
+    for i := range groups {
+        if groups[i].Uuid == selected {
+            view.groupSelect.Selected = options[i]
+        }
+    }
+    view.window.Canvas().Refresh(view.groupSelect)
+    view.show(selected)
+}
+
+func (view *secretGroupsView) show(groupUuid string) {
+    // Shows the recipients of a group, nothing if it is not listed
+
+    for i := range view.groups {
+        if view.groups[i].Uuid == groupUuid {
+            view.summaryLabel.SetText(view.groups[i].Summary() + ", sent by profile " + view.groups[i].Profile + " on " + view.groups[i].CreatedAt)
+            view.membersLabel.SetText(groupMemberRows(&view.groups[i]))
+            return
+        }
+    }
+    view.summaryLabel.SetText(fmt.Sprintf("%d secret groups", len(view.groups)))
+    view.membersLabel.SetText("")
+}
+
+func showSendGroupDialog(window fyne.Window, databaseDAO *libs.DatabaseDAO, config libs.Config, view *secretGroupsView) {
+    // Asks for a content, contacts and the sender key, and sends the content to each contact as its own secret
+
+    groupEntry := widget.NewEntry()
+    groupEntry.SetText(uuid.New().String())
+    contentEntry := widget.NewMultiLineEntry()
+    contentFileEntry := widget.NewEntry()
+    contentFileEntry.SetPlaceHolder("Path of a file to send instead of the content")
+    compressCheck := widget.NewCheck("Compress the file before sealing it", nil)
+    searchEntry := widget.NewEntry()
+    searchEntry.SetPlaceHolder("Search the contacts by name, fingerprint, company or notes")
+    chosenLabel := widget.NewLabel("")
+    senderPublicEntry := widget.NewEntry()
+    senderPrivateEntry := widget.NewPasswordEntry()
+    selectWidgetSenderKey := widget.NewSelect(keyOptions(databaseDAO, libs.KeyTypeX25519), nil)
+    selectWidgetSenderKey.SetSelected(useEntryOption)
+
+    // The chosen contacts are kept while the search changes the listed ones
+    chosen := map[string]libs.Contact{}
+    showChosen := func() {
+        var names []string
+        for name := range chosen {
+            names = append(names, name)
+        }
+        sort.Strings(names)
+        chosenLabel.SetText(fmt.Sprintf("%d recipients : %s", len(names), strings.Join(names, ", ")))
+    }
+    contactsBox := widget.NewVBox()
+    listContacts := func() {
+        contacts, err := databaseDAO.ListContacts(searchEntry.Text)
+        if err != nil {
+            contactsBox.Children = []fyne.CanvasObject{widget.NewLabel("Cannot read the contacts : " + err.Error())}
+            widget.Refresh(contactsBox)
+            return
+        }
+        children := make([]fyne.CanvasObject, len(contacts))
+        for i := range contacts {
+            contact := contacts[i]
+            check := widget.NewCheck(contact.Label(), func(checked bool) {
+                if checked {
+                    chosen[contact.Name] = contact
+                } else {
+                    delete(chosen, contact.Name)
+                }
+                showChosen()
+            })
+            _, check.Checked = chosen[contact.Name]
+            children[i] = check
+        }
+        contactsBox.Children = children
+        widget.Refresh(contactsBox)
+    }
+    searchEntry.OnChanged = func(string) { listContacts() }
+    listContacts()
+    showChosen()
+
+    contactsWrap := fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.Size{Width: 700, Height: 150}), widget.NewScrollContainer(contactsBox))
+    dialogContent := widget.NewVBox(
+        widget.NewLabel("Group UUID, the secret UUIDs of the recipients being derived from it :"),
+        groupEntry,
+        widget.NewLabel("Content :"),
+        contentEntry,
+        widget.NewLabel("Content file :"),
+        contentFileEntry,
+        compressCheck,
+        widget.NewLabel("Recipients :"),
+        searchEntry,
+        contactsWrap,
+        chosenLabel,
+        widget.NewLabel("Sender key :"),
+        selectWidgetSenderKey,
+        widget.NewLabel("Sender public key :"),
+        senderPublicEntry,
+        widget.NewLabel("Sender private key :"),
+        senderPrivateEntry,
+    )
+
+    // Build child dialog canvas
+    summaryLabel := widget.NewLabel("")
+    sendsZone := widget.NewMultiLineEntry()
+    childDialogContent := widget.NewVBox(
+        summaryLabel,
+        sendsZone,
+    )
+
+    dialog.ShowCustomConfirm("Send to several contacts...", "Check", "Cancel", dialogContent, func(confirm bool) {
+        if !confirm {
+            return
+        }
+        if contentEntry.Text == "" && contentFileEntry.Text == "" {
+            dialog.ShowError(fmt.Errorf("missing content"), window)
+            return
+        }
+        content, contentDescription, err := dialogSecretContent(contentEntry.Text, contentFileEntry.Text, compressCheck.Checked)
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+        contacts := make([]libs.Contact, 0, len(chosen))
+        for _, contact := range chosen {
+            contacts = append(contacts, contact)
+        }
+        sort.Slice(contacts, func(i, j int) bool { return contacts[i].Name < contacts[j].Name })
+        plan, err := libs.PlanSecretGroup(databaseDAO, strings.TrimSpace(groupEntry.Text), contacts)
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+
+        runner := libs.SecretGroupRunner{Dao: databaseDAO, Config: config, Content: content}
+        if selectWidgetSenderKey.Selected == useEntryOption {
+            runner.SenderPubKey, err = utils.CreatePublicKeyX25519FromBase64(senderPublicEntry.Text)
+            if err == nil {
+                runner.SenderPrivKey, err = utils.CreatePrivateKeyX25519FromBase64(senderPrivateEntry.Text)
+            }
+        } else {
+            runner.SenderPubKey, runner.SenderPrivKey, err = databaseDAO.Keystore.GetX25519Key(selectWidgetSenderKey.Selected)
+        }
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+
+        // Dry run : list the secret UUID of every recipient before sending
+        var lines []string
+        for _, send := range plan.Sends {
+            line := send.ContactName + " : " + send.SecretUuid + " " + send.Result
+            if send.Reason != "" {
+                line += " (" + send.Reason + ")"
+            }
+            lines = append(lines, line)
+        }
+        summaryLabel.SetText(plan.Summary() + "\n" + contentDescription)
+        sendsZone.SetText(strings.Join(lines, "\n"))
+
+        dialog.ShowCustomConfirm("Confirm secrets...", fmt.Sprintf("Send %d secrets", plan.Count(libs.ImportSend)), "Cancel",
+            childDialogContent, func(confirm bool) {
+                if !confirm || plan.Count(libs.ImportSend) == 0 {
+                    return
+                }
+                // Each accepted secret is then followed in the background, the group showing its delivery
+                runner.OnResult = func(send *libs.SecretGroupSend) {
+                    view.Reload(plan.GroupUuid)
+                    if send.Result != libs.ImportAccepted {
+                        return
+                    }
+                    tracker := libs.NewStatusTracker(databaseDAO, config)
+                    tracker.OnChange = func(kind string, uuid string, status string) {
+                        view.Reload("")
+                    }
+                    go tracker.Track(libs.EntrySecret, send.SecretUuid, send.Signed, send.SendStatus)
+                }
+                go func() {
+                    if err := runner.Run(plan); err != nil {
+                        dialog.ShowError(err, window)
+                        return
+                    }
+                    view.Reload(plan.GroupUuid)
+                    dialog.ShowInformation("Secrets sent", plan.Summary(), window)
+                }()
+            }, window)
+    }, window)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+    "fmt"
+    "strings"
+
+    "fyne.io/fyne"
+    "fyne.io/fyne/dialog"
+    "fyne.io/fyne/widget"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+func showImportHandoffDialog(window fyne.Window, databaseDAO *libs.DatabaseDAO, config libs.Config, onImported func(uuid string)) {
+    // Asks for a hand-off bundle and its passphrase, and tracks the secret it opens
+
+    if !databaseDAO.Keystore.Unlocked() {
+        dialog.ShowError(fmt.Errorf("unlock the keystore first, the recipient private key is stored encrypted"), window)
+        return
+    }
+
+    pathEntry := widget.NewEntry()
+    passphraseEntry := widget.NewPasswordEntry()
+    dialogContent := widget.NewVBox(
+        widget.NewLabel("Hand-off bundle file :"),
+        pathEntry,
+        widget.NewLabel("Passphrase given by the sender :"),
+        passphraseEntry,
+    )
+
+    dialog.ShowCustomConfirm("Import hand-off bundle...", "Import", "Cancel", dialogContent, func(confirm bool) {
+        if !confirm {
+            return
+        }
+        bundle, err := readHandoffBundle(strings.TrimSpace(pathEntry.Text))
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+        key, err := bundle.Open(passphraseEntry.Text)
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+        if err := databaseDAO.ImportHandoff(key, config.CompanyChainID, config.Profile); err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+        onImported(key.Uuid)
+        dialog.ShowInformation("Hand-off imported", "The secret "+key.Uuid+" sent by company "+key.CompanyChainID+
+            "\nis listed in the Secrets tab", window)
+    }, window)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "text/tabwriter"
+
+    "fyne.io/fyne"
+    "fyne.io/fyne/layout"
+    "fyne.io/fyne/widget"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+// Option of the history filter selects matching every value
+const historyAllOption = "All"
+
+func historyOptionValue(option string) string {
+    // Returns the filter value of a select option
+
+    if option == historyAllOption {
+        return ""
+    }
+    return option
+}
+
+func historyRows(entries []libs.HistoryEntry) string {
+    // Returns the history transactions as aligned columns, for a monospace label
+
+    var builder strings.Builder
+    writer := tabwriter.NewWriter(&builder, 0, 4, 2, ' ', 0)
+    fmt.Fprintln(writer, "ID\tSENT AT\tPROFILE\tKIND\tUUID\tSTATUS\tCODE\tMESSAGE")
+    for _, entry := range entries {
+        fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\t%s\t%d\t%s\n", entry.Id, libs.LocalTime(entry.CreatedAt), entry.Profile,
+            entry.Kind, entry.Uuid, entry.Status, entry.Code, entry.Message)
+    }
+    _ = writer.Flush()
+    return builder.String()
+}
+
+func historyOption(entry libs.HistoryEntry) string {
+    // Returns the select option of a history transaction, starting with its id
+
+    return "#" + strconv.FormatInt(entry.Id, 10) + " " + libs.LocalTime(entry.CreatedAt) + " " + libs.OptionLabel(entry.Uuid, entry.Status)
+}
+
+func historyDetails(entry *libs.HistoryEntry) string {
+    // Returns every recorded value of a history transaction, the signed bytes last
+
+    lines := []string{}
+    for _, field := range entry.Fields() {
+        lines = append(lines, field.Label+" : "+field.Value)
+    }
+    return strings.Join(lines, "\n") + "\n\nSigned transaction :\n" + entry.SignedBytes
+}
+
+func newHistoryTab(window fyne.Window, databaseDAO *libs.DatabaseDAO) fyne.CanvasObject {
+    // Builds the History tab listing the broadcast transactions, reloaded whenever a filter changes or on demand
+
+    searchEntry := widget.NewEntry()
+    searchEntry.SetPlaceHolder("UUID, local request digest, company chain id or message")
+    kindSelect := widget.NewSelect([]string{historyAllOption, libs.EntryCertificate, libs.EntrySecret}, nil)
+    kindSelect.Selected = historyAllOption
+    statusSelect := widget.NewSelect([]string{historyAllOption, libs.StatusPending, libs.StatusCommitted, libs.StatusFailed,
+        libs.StatusTimedOut}, nil)
+    statusSelect.Selected = historyAllOption
+    sortSelect := widget.NewSelect(libs.HistorySortColumns, nil)
+    sortSelect.Selected = libs.HistorySortColumns[0]
+    descendingCheck := widget.NewCheck("Descending", nil)
+    descendingCheck.SetChecked(true)
+
+    rowsLabel := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+    detailsZone := widget.NewMultiLineEntry()
+    entries := []libs.HistoryEntry{}
+
+    rowSelect := widget.NewSelect([]string{}, func(option string) {
+        // Shows the details of the chosen transaction
+        for _, entry := range entries {
+            if option == historyOption(entry) {
+                detailsZone.SetText(historyDetails(&entry))
+                return
+            }
+        }
+    })
+
+    reload := func() {
+        var err error
+        entries, err = databaseDAO.ListTransactions(libs.HistoryFilter{
+            Search:     strings.TrimSpace(searchEntry.Text),
+            Kind:       historyOptionValue(kindSelect.Selected),
+            Status:     historyOptionValue(statusSelect.Selected),
+            SortBy:     sortSelect.Selected,
+            Descending: descendingCheck.Checked,
+        })
+        if err != nil {
+            rowsLabel.SetText("Cannot read the history : " + err.Error())
+            return
+        }
+        rowsLabel.SetText(historyRows(entries))
+        options := make([]string, len(entries))
+        for i, entry := range entries {
+            options[i] = historyOption(entry)
+        }
+        rowSelect.Options = options
+        rowSelect.Selected = ""
+        window.Canvas().Refresh(rowSelect)
+        detailsZone.SetText("")
+    }
+    searchEntry.OnChanged = func(string) { reload() }
+    kindSelect.OnChanged = func(string) { reload() }
+    statusSelect.OnChanged = func(string) { reload() }
+    sortSelect.OnChanged = func(string) { reload() }
+    descendingCheck.OnChanged = func(bool) { reload() }
+
+    // The scrollcontainers have to be wrapped in a fixed grid layout in order to be displayed in the proper size
+    rowsWrap := fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.Size{Width: 1000, Height: 330}), widget.NewScrollContainer(rowsLabel))
+    detailsWrap := fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.Size{Width: 1000, Height: 250}), widget.NewScrollContainer(detailsZone))
+
+    tabHistory := widget.NewVBox(
+        widget.NewForm(&widget.FormItem{Text: "Search", Widget: searchEntry}),
+        widget.NewHBox(
+            widget.NewLabel("Kind :"), kindSelect,
+            widget.NewLabel("Status :"), statusSelect,
+            widget.NewLabel("Sort by :"), sortSelect,
+            descendingCheck,
+            widget.NewButton("Refresh", reload),
+        ),
+        rowsWrap,
+        widget.NewLabel("The request digest is the SHA-256 of the signed request, kept locally : it cannot be looked up on chain."),
+        rowSelect,
+        detailsWrap,
+    )
+    reload()
+    return tabHistory
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+
+    "fyne.io/fyne"
+    "fyne.io/fyne/dialog"
+    "fyne.io/fyne/widget"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+func showImportCertificatesDialog(window fyne.Window, databaseDAO *libs.DatabaseDAO, config libs.Config, selectWidgetCertificates *widget.Select) {
+    // Asks for a CSV or JSON lines file, shows what would be sent and sends it in the background
+
+    pathEntry := widget.NewEntry()
+    workersEntry := widget.NewEntry()
+    workersEntry.SetText(strconv.Itoa(libs.DefaultImportWorkers))
+    rateEntry := widget.NewEntry()
+    rateEntry.SetText(strconv.FormatFloat(libs.DefaultImportRate, 'f', -1, 64))
+    dialogContent := widget.NewVBox(
+        widget.NewLabel("CSV (uuid, signature, signer header) or JSON lines file :"),
+        pathEntry,
+        widget.NewLabel("Concurrent sends :"),
+        workersEntry,
+        widget.NewLabel("Maximum transactions per second :"),
+        rateEntry,
+    )
+
+    // Build child dialog canvas
+    summaryLabel := widget.NewLabel("")
+    rowsZone := widget.NewMultiLineEntry()
+    childDialogContent := widget.NewVBox(
+        summaryLabel,
+        widget.NewLabel("Rows not sent :"),
+        rowsZone,
+    )
+
+    dialog.ShowCustomConfirm("Import certificates...", "Check", "Cancel", dialogContent, func(confirm bool) {
+        if !confirm {
+            return
+        }
+        path := strings.TrimSpace(pathEntry.Text)
+        workers, err := strconv.Atoi(workersEntry.Text)
+        if err != nil || workers < 1 {
+            dialog.ShowError(fmt.Errorf("the number of concurrent sends must be a positive integer"), window)
+            return
+        }
+        rate, err := strconv.ParseFloat(rateEntry.Text, 64)
+        if err != nil || rate < 0 {
+            dialog.ShowError(fmt.Errorf("the rate must be a positive number, or 0 for no limit"), window)
+            return
+        }
+        plan, err := loadImportPlan(databaseDAO, path, "", config.CompanyChainID)
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+
+        // Dry run : list what will be left aside
+        summaryLabel.SetText(plan.Summary())
+        notSent := ""
+        for _, row := range plan.Rows {
+            if row.Result != libs.ImportSend {
+                notSent += "Line " + strconv.Itoa(row.Line) + " : " + row.Result + ", " + row.Reason + "\n"
+            }
+        }
+        rowsZone.SetText(notSent)
+
+        dialog.ShowCustomConfirm("Confirm import...", "Send "+strconv.Itoa(plan.Count(libs.ImportSend))+" certificates", "Cancel",
+            childDialogContent, func(confirm bool) {
+                if !confirm || plan.Count(libs.ImportSend) == 0 {
+                    return
+                }
+
+                progressBar := widget.NewProgressBar()
+                progressBar.Max = float64(plan.Count(libs.ImportSend))
+                dialog.ShowCustom("Importing...", "Hide", progressBar, window)
+
+                runner := libs.NewImportRunner(databaseDAO, config)
+                runner.Workers = workers
+                runner.Rate = rate
+                runner.OnResult = func(result *libs.ImportResult) {
+                    progressBar.SetValue(progressBar.Value + 1)
+                }
+
+                // Done in a goroutine as an import can last for minutes
+                go func() {
+                    runner.Run(plan)
+
+                    message := plan.Summary()
+                    if err := databaseDAO.RecordImport(path, plan); err != nil {
+                        message += "\nCannot record the results : " + err.Error()
+                    }
+                    reportPath := libs.ReportPath(path)
+                    if err := writeImportReport(plan, reportPath); err != nil {
+                        message += "\nCannot write the report : " + err.Error()
+                    } else {
+                        message += "\nReport : " + reportPath
+                    }
+
+                    selectWidgetCertificates.Options = entryOptions(window, databaseDAO, libs.EntryCertificate)
+                    window.Canvas().Refresh(selectWidgetCertificates)
+                    dialog.ShowInformation("Import finished", message, window)
+
+                    // The accepted certificates are then followed like a single send, their badges kept up to date
+                    runner.OnStatus = func(result *libs.ImportResult) {
+                        showEntry(window, databaseDAO, selectWidgetCertificates, libs.EntryCertificate,
+                            libs.UuidFromOption(selectWidgetCertificates.Selected))
+                    }
+                    runner.Track(plan, libs.DefaultPollTimeout)
+                }()
+            }, window)
+    }, window)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+    "fmt"
+    "strings"
+    "text/tabwriter"
+
+    "fyne.io/fyne"
+    "fyne.io/fyne/dialog"
+    "fyne.io/fyne/layout"
+    "fyne.io/fyne/widget"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+func inboxSourceRows(sources []libs.InboxSource) string {
+    // Returns the inbox sources as aligned columns, for a monospace label
+
+    var builder strings.Builder
+    writer := tabwriter.NewWriter(&builder, 0, 4, 2, ' ', 0)
+    fmt.Fprintln(writer, "SOURCE\tLOOKS AT")
+    for _, source := range sources {
+        fmt.Fprintf(writer, "%s\t%s\n", source.Source, source.Describe())
+    }
+    _ = writer.Flush()
+    return builder.String()
+}
+
+func inboxSecretRows(inbox *libs.Inbox) string {
+    // Returns the opened secrets as aligned columns, for a monospace label
+
+    var builder strings.Builder
+    writer := tabwriter.NewWriter(&builder, 0, 4, 2, ' ', 0)
+    fmt.Fprintln(writer, "#\tNONCE TIME\tUUID\tSENDER\tKEY\tPREVIEW")
+    for i, secret := range inbox.Secrets {
+        preview := secret.Preview(libs.InboxPreviewLength)
+        if secret.Error != "" {
+            preview = "error: " + secret.Error
+        }
+        fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\t%s\n", i+1, secret.NonceTime.Local().Format(libs.DisplayTimeFormat), secret.Uuid,
+            secret.Sender(), secret.KeyName, preview)
+    }
+    _ = writer.Flush()
+    return builder.String()
+}
+
+func inboxSecretOption(index int, secret *libs.InboxSecret) string {
+    // Returns the select option of an opened secret, numbered like its row
+
+    return fmt.Sprintf("%d - %s - %s", index+1, secret.Uuid, secret.Sender())
+}
+
+func newInboxTab(window fyne.Window, databaseDAO *libs.DatabaseDAO, currentConfig func() libs.Config) fyne.CanvasObject {
+    // Builds the tab retrieving the secrets of the configured UUIDs and companies and listing those the keystore
+    // X25519 keys open
+
+    sourceEntry := widget.NewEntry()
+    sourceEntry.SetPlaceHolder("UUID, COMPANY/UUID or COMPANY")
+    sourcesLabel := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+    summaryLabel := widget.NewLabel("")
+    secretsLabel := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+    contentZone := widget.NewMultiLineEntry()
+    directoryEntry := widget.NewEntry()
+    directoryEntry.SetText(".")
+
+    listSources := func() {
+        sources, err := databaseDAO.ListInboxSources()
+        if err != nil {
+            sourcesLabel.SetText("Cannot read the inbox sources : " + err.Error())
+            return
+        }
+        sourcesLabel.SetText(inboxSourceRows(sources))
+    }
+    listSources()
+
+    var inbox *libs.Inbox
+    var selected *libs.InboxSecret
+    secretSelect := widget.NewSelect([]string{}, func(option string) {
+        selected = nil
+        contentZone.SetText("")
+        if inbox == nil {
+            return
+        }
+        for i := range inbox.Secrets {
+            if inboxSecretOption(i, &inbox.Secrets[i]) == option {
+                selected = &inbox.Secrets[i]
+                text := selected.Text
+                if selected.Error != "" {
+                    text += "\n\nError : " + selected.Error
+                }
+                contentZone.SetText(text)
+            }
+        }
+    })
+
+    var checkButton *widget.Button
+    var checkInbox func()
+    checkInbox = func() {
+        if !databaseDAO.Keystore.Unlocked() {
+            showUnlockDialog(window, databaseDAO, checkInbox)
+            return
+        }
+        sources, err := databaseDAO.ListInboxSources()
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+        if len(sources) == 0 {
+            dialog.ShowError(fmt.Errorf("add a UUID or a company to look at first"), window)
+            return
+        }
+        config := currentConfig()
+        checkButton.Disable()
+        summaryLabel.SetText(fmt.Sprintf("Retrieving the secrets of %d sources...", len(sources)))
+        // Done in a goroutine as every UUID is retrieved in turn
+        go func() {
+            defer checkButton.Enable()
+            found, err := databaseDAO.ReadInbox(config, sources)
+            if err != nil {
+                summaryLabel.SetText("")
+                dialog.ShowError(err, window)
+                return
+            }
+            inbox, selected = found, nil
+            summary := found.Summary()
+            if len(found.Errors) > 0 {
+                summary += "\n" + strings.Join(found.Errors, "\n")
+            }
+            summaryLabel.SetText(summary)
+            secretsLabel.SetText(inboxSecretRows(found))
+            options := make([]string, len(found.Secrets))
+            for i := range found.Secrets {
+                options[i] = inboxSecretOption(i, &found.Secrets[i])
+            }
+            secretSelect.Options = options
+            secretSelect.Selected = ""
+            window.Canvas().Refresh(secretSelect)
+            contentZone.SetText("")
+        }()
+    }
+    checkButton = widget.NewButton("Check inbox", func() { checkInbox() })
+
+    // The scrollcontainers have to be wrapped in a fixed grid layout in order to be displayed in the proper size
+    sourcesWrap := fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.Size{Width: 1000, Height: 100}), widget.NewScrollContainer(sourcesLabel))
+    secretsWrap := fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.Size{Width: 1000, Height: 250}), widget.NewScrollContainer(secretsLabel))
+    contentWrap := fyne.NewContainerWithLayout(layout.NewFixedGridLayout(fyne.Size{Width: 1000, Height: 150}), widget.NewScrollContainer(contentZone))
+    return widget.NewVBox(
+        widget.NewLabel("Inbox sources, a company standing for the UUIDs tracked from it :"),
+        sourcesWrap,
+        sourceEntry,
+        widget.NewHBox(
+            widget.NewButton("Add source", func() {
+                if _, err := databaseDAO.AddInboxSource(sourceEntry.Text); err != nil {
+                    dialog.ShowError(err, window)
+                    return
+                }
+                sourceEntry.SetText("")
+                listSources()
+            }),
+            widget.NewButton("Remove source", func() {
+                if err := databaseDAO.RemoveInboxSource(sourceEntry.Text); err != nil {
+                    dialog.ShowError(err, window)
+                    return
+                }
+                sourceEntry.SetText("")
+                listSources()
+            }),
+            checkButton,
+        ),
+        summaryLabel,
+        secretsWrap,
+        secretSelect,
+        contentWrap,
+        widget.NewLabel("Directory the attached file of the selected secret is saved to :"),
+        directoryEntry,
+        widget.NewButton("Save attached file", func() {
+            if selected == nil || selected.File == nil {
+                dialog.ShowError(fmt.Errorf("select a secret carrying a file first"), window)
+                return
+            }
+            path, err := selected.File.Save(strings.TrimSpace(directoryEntry.Text))
+            if err != nil {
+                dialog.ShowError(err, window)
+                return
+            }
+            dialog.ShowInformation("Save attached file", selected.File.String()+" saved to "+path, window)
+        }),
+    )
+}
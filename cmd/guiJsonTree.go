@@ -0,0 +1,330 @@
+package main
+
+import (
+    "strconv"
+    "strings"
+
+    "fyne.io/fyne"
+    "fyne.io/fyne/theme"
+    "fyne.io/fyne/widget"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+// Number of rows drawn by a JSON tree, only the visible ones being bound to widgets
+const jsonTreePageSize = 12
+
+// Number of rows moved by a mouse wheel step
+const jsonTreeScrollStep = 3
+
+// Box of the JSON tree rows moving through the visible rows on mouse wheel events
+type jsonTreeRows struct {
+    *widget.Box
+    onScrolled func(delta int)
+}
+
+func (rows *jsonTreeRows) Scrolled(event *fyne.ScrollEvent) {
+    if event.DeltaY > 0 {
+        rows.onScrolled(-jsonTreeScrollStep)
+    } else if event.DeltaY < 0 {
+        rows.onScrolled(jsonTreeScrollStep)
+    }
+}
+
+// Line of a JSON tree, bound to a different node when the tree moves
+type jsonTreeRow struct {
+    box    *widget.Box
+    toggle *widget.Button
+    path   *widget.Button
+    value  *widget.Button
+    text   *widget.Label
+    node   *libs.JSONNode
+}
+
+// Collapsible view of a JSON document with a search and a JSONPath filter, drawing a fixed number of rows whatever
+// the size of the document
+type jsonTreeView struct {
+    window   fyne.Window
+    root     *libs.JSONNode
+    roots    []*libs.JSONNode
+    isRoot   map[*libs.JSONNode]bool
+    expanded map[*libs.JSONNode]bool
+    rows     []*libs.JSONNode
+    offset   int
+
+    matches    []*libs.JSONNode
+    matched    map[*libs.JSONNode]bool
+    matchIndex int
+
+    rowWidgets  []*jsonTreeRow
+    searchEntry *widget.Entry
+    filterEntry *widget.Entry
+    statusLabel *widget.Label
+    Content     fyne.CanvasObject
+}
+
+func newJSONTreeView(window fyne.Window) *jsonTreeView {
+    // Builds an empty tree with its search and filter bars and the buttons moving through the rows
+
+    view := &jsonTreeView{
+        window:      window,
+        expanded:    map[*libs.JSONNode]bool{},
+        matched:     map[*libs.JSONNode]bool{},
+        searchEntry: widget.NewEntry(),
+        filterEntry: widget.NewEntry(),
+        statusLabel: widget.NewLabel(""),
+    }
+    view.searchEntry.SetPlaceHolder("Text to search in the keys and values")
+    view.searchEntry.OnChanged = func(string) {
+        view.search()
+    }
+    view.filterEntry.SetPlaceHolder("$.transactions[*].transaction.message")
+
+    rowsBox := &jsonTreeRows{Box: widget.NewVBox(), onScrolled: view.move}
+    for i := 0; i < jsonTreePageSize; i++ {
+        row := view.newRow()
+        view.rowWidgets = append(view.rowWidgets, row)
+        rowsBox.Append(row.box)
+    }
+
+    searchForm := widget.NewForm(
+        &widget.FormItem{Text: "Search", Widget: view.searchEntry},
+        &widget.FormItem{Text: "JSONPath", Widget: view.filterEntry},
+    )
+    buttonBar := widget.NewHBox(
+        widget.NewButtonWithIcon("Previous", theme.MoveUpIcon(), func() { view.nextMatch(-1) }),
+        widget.NewButtonWithIcon("Next", theme.MoveDownIcon(), func() { view.nextMatch(1) }),
+        widget.NewButtonWithIcon("Filter", theme.SearchIcon(), view.filter),
+        widget.NewButton("Clear filter", func() {
+            view.filterEntry.SetText("")
+            view.filter()
+        }),
+        widget.NewButton("Expand all", func() { view.expandAll(true) }),
+        widget.NewButton("Collapse all", func() { view.expandAll(false) }),
+        widget.NewButton("Top", func() { view.move(-len(view.rows)) }),
+        widget.NewButton("Page up", func() { view.move(-jsonTreePageSize) }),
+        widget.NewButton("Page down", func() { view.move(jsonTreePageSize) }),
+        widget.NewButton("Bottom", func() { view.move(len(view.rows)) }),
+    )
+    view.Content = widget.NewVBox(searchForm, buttonBar, view.statusLabel, rowsBox)
+    view.SetJSON("")
+    return view
+}
+
+func (view *jsonTreeView) newRow() *jsonTreeRow {
+    // Returns a row whose buttons act on the node it is bound to
+
+    row := &jsonTreeRow{text: widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})}
+    row.toggle = widget.NewButton(" ", func() {
+        if row.node != nil && row.node.Container() {
+            view.expanded[row.node] = !view.expanded[row.node]
+            view.refreshRows()
+        }
+    })
+    row.path = widget.NewButtonWithIcon("Path", theme.ContentCopyIcon(), func() {
+        if row.node != nil {
+            view.window.Clipboard().SetContent(row.node.Path())
+            view.statusLabel.SetText("Copied " + row.node.Path())
+        }
+    })
+    row.value = widget.NewButtonWithIcon("Value", theme.ContentCopyIcon(), func() {
+        if row.node != nil {
+            view.window.Clipboard().SetContent(row.node.JSON())
+            view.statusLabel.SetText("Copied the value of " + row.node.Path())
+        }
+    })
+    row.box = widget.NewHBox(row.toggle, row.path, row.value, row.text)
+    return row
+}
+
+func (view *jsonTreeView) SetJSON(raw string) {
+    // Shows a JSON document with its first two levels expanded, or nothing when it is empty or invalid
+
+    view.root = nil
+    view.setRoots(nil)
+    view.expanded = map[*libs.JSONNode]bool{}
+    view.offset = 0
+    if strings.TrimSpace(raw) != "" {
+        root, err := libs.ParseJSONTree([]byte(raw))
+        if err != nil {
+            view.refreshRows()
+            view.statusLabel.SetText("Invalid JSON : " + err.Error())
+            return
+        }
+        view.root = root
+        view.setRoots([]*libs.JSONNode{root})
+        view.expanded[root] = true
+        for _, child := range root.Children {
+            view.expanded[child] = true
+        }
+    }
+    view.filterEntry.SetText("")
+    // Searches again, which also draws the rows
+    view.search()
+}
+
+func (view *jsonTreeView) setRoots(roots []*libs.JSONNode) {
+    view.roots = roots
+    view.isRoot = map[*libs.JSONNode]bool{}
+    for _, root := range roots {
+        view.isRoot[root] = true
+    }
+}
+
+func (view *jsonTreeView) filter() {
+    // Shows only the nodes selected by the JSONPath expression, as top level rows, or the whole document when empty
+
+    if view.root == nil {
+        return
+    }
+    view.offset = 0
+    expression := strings.TrimSpace(view.filterEntry.Text)
+    if expression == "" || expression == "$" {
+        view.setRoots([]*libs.JSONNode{view.root})
+        view.search()
+        return
+    }
+    nodes, err := libs.SelectJSONPath(view.root, expression)
+    if err != nil {
+        view.statusLabel.SetText(err.Error())
+        return
+    }
+    view.setRoots(nodes)
+    view.search()
+}
+
+func (view *jsonTreeView) search() {
+    // Marks the shown nodes matching the search text and moves to the first one
+
+    view.matches = nil
+    view.matched = map[*libs.JSONNode]bool{}
+    view.matchIndex = -1
+    for _, root := range view.roots {
+        view.matches = append(view.matches, libs.SearchJSON(root, view.searchEntry.Text)...)
+    }
+    for _, node := range view.matches {
+        view.matched[node] = true
+    }
+    if len(view.matches) > 0 {
+        view.nextMatch(1)
+        return
+    }
+    view.refreshRows()
+}
+
+func (view *jsonTreeView) nextMatch(direction int) {
+    // Expands the parents of the next or previous match and moves the rows so that it is shown
+
+    if len(view.matches) == 0 {
+        return
+    }
+    view.matchIndex = (view.matchIndex + direction + len(view.matches)) % len(view.matches)
+    match := view.matches[view.matchIndex]
+    for parent := match.Parent; parent != nil; parent = parent.Parent {
+        view.expanded[parent] = true
+    }
+    view.rows = libs.VisibleJSONRows(view.roots, view.expanded)
+    for i, row := range view.rows {
+        if row == match {
+            if i < view.offset || i >= view.offset+jsonTreePageSize {
+                view.offset = i - jsonTreePageSize/2
+            }
+            break
+        }
+    }
+    view.refreshRows()
+}
+
+func (view *jsonTreeView) expandAll(expand bool) {
+    // Expands or collapses every container of the shown nodes, the top level ones staying expanded
+
+    view.offset = 0
+    for _, root := range view.roots {
+        root.Walk(func(node *libs.JSONNode) {
+            if node.Container() {
+                view.expanded[node] = expand
+            }
+        })
+        view.expanded[root] = true
+    }
+    view.refreshRows()
+}
+
+func (view *jsonTreeView) move(delta int) {
+    view.offset += delta
+    view.refreshRows()
+}
+
+func (view *jsonTreeView) refreshRows() {
+    // Binds the row widgets to the visible nodes from the current offset
+
+    view.rows = libs.VisibleJSONRows(view.roots, view.expanded)
+    if view.offset > len(view.rows)-jsonTreePageSize {
+        view.offset = len(view.rows) - jsonTreePageSize
+    }
+    if view.offset < 0 {
+        view.offset = 0
+    }
+
+    filtered := len(view.roots) != 1 || view.roots[0] != view.root
+    for i, row := range view.rowWidgets {
+        index := view.offset + i
+        if index >= len(view.rows) {
+            row.node = nil
+            row.box.Hide()
+            continue
+        }
+        node := view.rows[index]
+        row.node = node
+
+        toggle := " "
+        if node.Container() && view.expanded[node] {
+            toggle = "-"
+        } else if node.Container() {
+            toggle = "+"
+        }
+        row.toggle.SetText(toggle)
+
+        depth := node.Depth
+        name := node.Name()
+        if filtered {
+            // Top level rows are shown with their full path
+            for top := node; top != nil; top = top.Parent {
+                if view.isRoot[top] {
+                    depth -= top.Depth
+                    if top == node {
+                        name = node.Path()
+                    }
+                    break
+                }
+            }
+        }
+        marker := "  "
+        if view.matched[node] {
+            marker = "> "
+        }
+        row.text.SetText(marker + strings.Repeat("  ", depth) + name + " : " + node.Preview())
+        row.box.Show()
+    }
+
+    switch {
+    case view.root == nil:
+        view.statusLabel.SetText("No JSON")
+    case len(view.rows) == 0:
+        view.statusLabel.SetText("No node selected")
+    default:
+        last := view.offset + jsonTreePageSize
+        if last > len(view.rows) {
+            last = len(view.rows)
+        }
+        status := "Rows " + strconv.Itoa(view.offset+1) + "-" + strconv.Itoa(last) + " of " + strconv.Itoa(len(view.rows))
+        if view.searchEntry.Text != "" {
+            match := 0
+            if view.matchIndex >= 0 {
+                match = view.matchIndex + 1
+            }
+            status += ", match " + strconv.Itoa(match) + "/" + strconv.Itoa(len(view.matches))
+        }
+        view.statusLabel.SetText(status)
+    }
+}
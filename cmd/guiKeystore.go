@@ -0,0 +1,174 @@
+package main
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+
+    "fyne.io/fyne"
+    "fyne.io/fyne/dialog"
+    "fyne.io/fyne/widget"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+// Option of the key selects meaning the key is read from the entries instead of the keystore
+const useEntryOption = "Use the key entries"
+
+func keyOptions(databaseDAO *libs.DatabaseDAO, keyType string) []string {
+    // Returns the options of a key select : the entries first, then the keystore keys of a type
+
+    return append([]string{useEntryOption}, databaseDAO.Keystore.KeyNames(keyType)...)
+}
+
+func keystoreStatus(databaseDAO *libs.DatabaseDAO) string {
+    // Returns a readable state of the keystore
+
+    if databaseDAO.Keystore.Unlocked() {
+        return "Keystore : unlocked"
+    }
+    initialized, err := databaseDAO.Keystore.Initialized()
+    if err != nil {
+        return "Keystore : " + err.Error()
+    }
+    if !initialized {
+        return "Keystore : no master passphrase set yet"
+    }
+    return "Keystore : locked"
+}
+
+func showUnlockDialog(window fyne.Window, databaseDAO *libs.DatabaseDAO, onUnlocked func()) {
+    // Asks for the master passphrase, or for a new one if the keystore was never used, and unlocks the keystore
+
+    initialized, err := databaseDAO.Keystore.Initialized()
+    if err != nil {
+        dialog.ShowError(err, window)
+        return
+    }
+
+    passphraseEntry := widget.NewPasswordEntry()
+    confirmationEntry := widget.NewPasswordEntry()
+    content := widget.NewVBox(
+        widget.NewLabel("Master passphrase :"),
+        passphraseEntry,
+    )
+    title := "Unlock keystore"
+    if !initialized {
+        title = "Create keystore"
+        content.Append(widget.NewLabel("Confirm passphrase :"))
+        content.Append(confirmationEntry)
+    }
+
+    dialog.ShowCustomConfirm(title, "Unlock", "Later", content, func(confirm bool) {
+        if !confirm {
+            return
+        }
+        if !initialized && passphraseEntry.Text != confirmationEntry.Text {
+            dialog.ShowError(fmt.Errorf("passphrases do not match"), window)
+            return
+        }
+        migrated, err := databaseDAO.Unlock(passphraseEntry.Text)
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+        if migrated > 0 {
+            dialog.ShowInformation("Keystore", "Encrypted "+strconv.Itoa(migrated)+" recipient keys previously stored in clear.", window)
+        }
+        onUnlocked()
+    }, window)
+}
+
+func showImportKeyDialog(window fyne.Window, databaseDAO *libs.DatabaseDAO, onImported func()) {
+    // Asks for a named private key and encrypts it into the keystore
+
+    if !databaseDAO.Keystore.Unlocked() {
+        dialog.ShowError(fmt.Errorf("unlock the keystore first"), window)
+        return
+    }
+
+    nameEntry := widget.NewEntry()
+    typeRadio := widget.NewRadio([]string{libs.KeyTypeED25519, libs.KeyTypeX25519}, nil)
+    typeRadio.SetSelected(libs.KeyTypeED25519)
+    privateKeyEntry := widget.NewPasswordEntry()
+    content := widget.NewVBox(
+        widget.NewLabel("Name :"),
+        nameEntry,
+        widget.NewLabel("Type :"),
+        typeRadio,
+        widget.NewLabel("Private key :"),
+        privateKeyEntry,
+    )
+
+    dialog.ShowCustomConfirm("Import key...", "Import", "Cancel", content, func(confirm bool) {
+        if !confirm {
+            return
+        }
+        if err := databaseDAO.Keystore.AddKey(nameEntry.Text, typeRadio.Selected, strings.TrimSpace(privateKeyEntry.Text)); err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+        onImported()
+    }, window)
+}
+
+func showGenerateKeyDialog(window fyne.Window, databaseDAO *libs.DatabaseDAO, onGenerated func(keyType string, publicKey string, privateKey string)) {
+    // Generates a key pair of the chosen type, optionally saves it into the keystore and hands it to the caller
+
+    typeRadio := widget.NewRadio([]string{libs.KeyTypeED25519, libs.KeyTypeX25519}, nil)
+    typeRadio.SetSelected(libs.KeyTypeED25519)
+    nameEntry := widget.NewEntry()
+    saveCheck := widget.NewCheck("Save to keystore", nil)
+    content := widget.NewVBox(
+        widget.NewLabel("Type :"),
+        typeRadio,
+        saveCheck,
+        widget.NewLabel("Keystore name :"),
+        nameEntry,
+    )
+
+    dialog.ShowCustomConfirm("Generate key pair", "Generate", "Cancel", content, func(confirm bool) {
+        if !confirm {
+            return
+        }
+        if saveCheck.Checked && !databaseDAO.Keystore.Unlocked() {
+            dialog.ShowError(fmt.Errorf("unlock the keystore first"), window)
+            return
+        }
+        publicKey, privateKey, err := libs.GenerateKeyPair(typeRadio.Selected)
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+        if saveCheck.Checked {
+            if err := databaseDAO.Keystore.AddKey(nameEntry.Text, typeRadio.Selected, privateKey); err != nil {
+                dialog.ShowError(err, window)
+                return
+            }
+        }
+        onGenerated(typeRadio.Selected, publicKey, privateKey)
+
+        // Show the public key in an entry so it can be copied
+        publicKeyEntry := widget.NewEntry()
+        publicKeyEntry.SetText(publicKey)
+        dialog.ShowCustom("New "+typeRadio.Selected+" key pair", "Close", widget.NewVBox(
+            widget.NewLabel("Public key :"),
+            publicKeyEntry,
+        ), window)
+    }, window)
+}
+
+func generateKeyInto(window fyne.Window, publicKeyEntry *widget.Entry, privateKeyEntry *widget.Entry) func() {
+    // Returns a button callback filling a pair of entries with a new X25519 key pair, without opening a dialog
+    // as it is used inside the secret dialog
+
+    return func() {
+        publicKey, privateKey, err := libs.GenerateKeyPair(libs.KeyTypeX25519)
+        if err != nil {
+            dialog.ShowError(err, window)
+            return
+        }
+        publicKeyEntry.SetText(publicKey)
+        privateKeyEntry.SetText(privateKey)
+    }
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+
+    "fyne.io/fyne"
+    "fyne.io/fyne/dialog"
+    "fyne.io/fyne/widget"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+func lookupSummary(result *libs.LookupResult) string {
+    // Returns the line telling what was found for a looked up UUID
+
+    var found []string
+    if result.Certificate != nil {
+        found = append(found, "a certificate")
+    }
+    if count := result.SecretCount(); count > 0 {
+        found = append(found, strconv.Itoa(count)+" secret(s)")
+    }
+    if len(found) == 0 {
+        return "Nothing found for " + result.Uuid + " on company " + result.CompanyChainID
+    }
+    return "Found " + strings.Join(found, " and ") + " for " + result.Uuid + " on company " + result.CompanyChainID
+}
+
+func newLookupTab(window fyne.Window, databaseDAO *libs.DatabaseDAO, currentConfig func() libs.Config,
+    selectWidgetCertificates *widget.Select, selectWidgetSecrets *widget.Select) fyne.CanvasObject {
+    // Builds the tab retrieving any UUID, recorded in the database or not, and tracking it afterwards
+
+    uuidEntry := widget.NewEntry()
+    companyEntry := widget.NewEntry()
+    companyEntry.SetPlaceHolder("Configured company by default")
+    recipientKeyEntry := widget.NewPasswordEntry()
+    recipientKeyEntry.SetPlaceHolder("Optional, opens the secrets")
+    resultLabel := widget.NewLabel("")
+    view := newTransactionView(window, fyne.Size{Width: 1000, Height: 500})
+
+    var result *libs.LookupResult
+    var lookupButton, trackButton *widget.Button
+    trackButton = widget.NewButton("Track locally...", func() {
+        if result == nil {
+            return
+        }
+        config := currentConfig()
+        var kinds []string
+        if result.Certificate != nil {
+            kinds = append(kinds, libs.EntryCertificate)
+        }
+        if result.SecretCount() > 0 {
+            kinds = append(kinds, libs.EntrySecret)
+        }
+        message := "Record the " + strings.Join(kinds, " and the ") + " of " + result.Uuid + "\nin the database, retrieved from company " +
+            result.CompanyChainID + " ?"
+        if result.SecretCount() > 0 && recipientKeyEntry.Text == "" {
+            message += "\nWithout recipient private key, the secrets cannot be opened."
+        }
+        dialog.ShowConfirm("Track locally...", message, func(confirm bool) {
+            if !confirm {
+                return
+            }
+            var failures []string
+            for _, kind := range kinds {
+                if err := databaseDAO.TrackLookup(result, kind, recipientKeyEntry.Text, config.CompanyChainID, config.Profile); err != nil {
+                    failures = append(failures, err.Error())
+                }
+            }
+            showEntry(window, databaseDAO, selectWidgetCertificates, libs.EntryCertificate, result.Uuid)
+            showEntry(window, databaseDAO, selectWidgetSecrets, libs.EntrySecret, result.Uuid)
+            if len(failures) > 0 {
+                dialog.ShowError(fmt.Errorf("%s", strings.Join(failures, "\n")), window)
+                return
+            }
+            dialog.ShowInformation("Tracked", result.Uuid+" is listed in the Certificates and Secrets tabs", window)
+        }, window)
+    })
+    trackButton.Disable()
+
+    lookupButton = widget.NewButton("Look up", func() {
+        config := currentConfig()
+        uuidText := strings.TrimSpace(uuidEntry.Text)
+        companyChainID := strings.TrimSpace(companyEntry.Text)
+        recipientPrivKey := recipientKeyEntry.Text
+        result = nil
+        trackButton.Disable()
+        lookupButton.Disable()
+        resultLabel.SetText("Looking up " + uuidText + "...")
+        // Done in a goroutine as the secrets of a UUID can be of an important size
+        go func() {
+            defer lookupButton.Enable()
+            found, err := libs.Lookup(config, uuidText, companyChainID)
+            if err != nil {
+                resultLabel.SetText("")
+                view.SetMessage(err.Error())
+                return
+            }
+            summaries, raw, err := found.Describe(recipientPrivKey, config.ChainID)
+            if err != nil {
+                resultLabel.SetText("")
+                view.SetMessage(err.Error())
+                return
+            }
+            resultLabel.SetText(lookupSummary(found))
+            if len(summaries) == 0 {
+                view.SetMessage(found.CertificateError)
+                return
+            }
+            view.SetTransactions(summaries, raw)
+            result = found
+            trackButton.Enable()
+        }()
+    })
+
+    return widget.NewVBox(
+        widget.NewForm(
+            &widget.FormItem{Text: "UUID", Widget: uuidEntry},
+            &widget.FormItem{Text: "Company chain id", Widget: companyEntry},
+            &widget.FormItem{Text: "Recipient private key", Widget: recipientKeyEntry},
+        ),
+        widget.NewHBox(lookupButton, trackButton),
+        resultLabel,
+        view.Content,
+    )
+}
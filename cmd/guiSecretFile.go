@@ -0,0 +1,113 @@
+package main
+
+import (
+    "fmt"
+    "strings"
+
+    "fyne.io/fyne"
+    "fyne.io/fyne/dialog"
+    "fyne.io/fyne/widget"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+func checkSecretDialog(uuid string, hasContent bool, handoff bool, recipientPublic string, recipientPrivate string,
+    hasContact bool, useSenderEntries bool, senderPublic string, senderPrivate string) error {
+    // Returns an error naming the first field of a secret dialog left empty, the recipient keys being generated by a
+    // hand-off and the private one not being known for a contact
+
+    switch {
+    case uuid == "":
+        return fmt.Errorf("the UUID is required")
+    case !hasContent:
+        return fmt.Errorf("a content or a file is required")
+    case !handoff && recipientPublic == "":
+        return fmt.Errorf("the recipient public key is required")
+    case !handoff && recipientPrivate == "" && !hasContact:
+        return fmt.Errorf("the recipient private key is required")
+    case useSenderEntries && (senderPublic == "" || senderPrivate == ""):
+        return fmt.Errorf("the sender public and private keys are required")
+    }
+    return nil
+}
+
+func dialogSecretContent(text string, path string, compress bool) ([]byte, string, error) {
+    // Returns the content of a secret dialog, the file being wrapped with its name and MIME type, and a description
+    // of its size against the limit of the chain
+
+    path = strings.TrimSpace(path)
+    if text != "" && path != "" {
+        return nil, "", fmt.Errorf("give either a content or a file")
+    }
+    content := []byte(text)
+    description := ""
+    if path != "" {
+        file, err := libs.ReadSecretFile(path)
+        if err != nil {
+            return nil, "", err
+        }
+        if content, err = file.Envelope(compress); err != nil {
+            return nil, "", err
+        }
+        description = "File " + file.String() + "\n"
+    }
+    if err := libs.CheckSecretContentSize(len(content)); err != nil {
+        return nil, "", err
+    }
+    description += fmt.Sprintf("Content : %d bytes, transaction of about %d bytes out of the %d accepted", len(content),
+        libs.SecretTransactionSize(len(content)), libs.MaxTransactionSize)
+    return content, description, nil
+}
+
+func showSaveSecretFilesDialog(window fyne.Window, databaseDAO *libs.DatabaseDAO, config libs.Config, secretUUID string) {
+    // Asks for a directory and restores to it the files carried by the secrets of a UUID, under their original names
+
+    directoryEntry := widget.NewEntry()
+    directoryEntry.SetText(".")
+    dialogContent := widget.NewVBox(
+        widget.NewLabel("Directory the files of "+secretUUID+" are restored to :"),
+        directoryEntry,
+    )
+
+    dialog.ShowCustomConfirm("Save attached files...", "Save", "Cancel", dialogContent, func(confirm bool) {
+        if !confirm {
+            return
+        }
+        directory := strings.TrimSpace(directoryEntry.Text)
+        // Retrieving can take a while, it is done in a goroutine like the display of the secrets
+        go func() {
+            recipientPrivKey, err := databaseDAO.GetSecretDecryptingKey(secretUUID)
+            if err != nil {
+                dialog.ShowError(err, window)
+                return
+            }
+            secretData := libs.SecretHandler{
+                Config:   databaseDAO.EntryConfig(libs.EntrySecret, secretUUID, config),
+                UuidText: secretUUID,
+            }
+            decryptedSecrets, err := secretData.DecryptSecrets(recipientPrivKey)
+            if err != nil {
+                dialog.ShowError(err, window)
+                return
+            }
+
+            var lines []string
+            for i, decrypted := range decryptedSecrets {
+                if decrypted.File == nil {
+                    continue
+                }
+                path, err := decrypted.File.Save(directory)
+                if err != nil {
+                    lines = append(lines, fmt.Sprintf("Secret %d : %s", i+1, err))
+                    continue
+                }
+                lines = append(lines, fmt.Sprintf("Secret %d : %s saved to %s", i+1, decrypted.File, path))
+            }
+            if len(lines) == 0 {
+                dialog.ShowInformation("Save attached files...", "No file is carried by the secrets of "+secretUUID, window)
+                return
+            }
+            dialog.ShowInformation("Save attached files...", strings.Join(lines, "\n"), window)
+        }()
+    }, window)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+    "fyne.io/fyne"
+    "fyne.io/fyne/dialog"
+    "fyne.io/fyne/widget"
+    entityApi "github.com/katena-chain/sdk-go-client/entity/api"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+func entryOptions(window fyne.Window, databaseDAO *libs.DatabaseDAO, kind string) []string {
+    // Returns the select options of the entries of a kind, only the adding option if they cannot be read
+
+    options, err := databaseDAO.UpdateCertificateOptions()
+    addOption := libs.AddCertificateOption
+    if kind == libs.EntrySecret {
+        options, err = databaseDAO.UpdateSecretOptions()
+        addOption = libs.AddSecretOption
+    }
+    if err != nil {
+        dialog.ShowError(err, window)
+        return []string{addOption}
+    }
+    return options
+}
+
+func showEntry(window fyne.Window, databaseDAO *libs.DatabaseDAO, selectWidget *widget.Select, kind string, uuid string) {
+    // Refreshes the options of a select and shows an entry as selected, without retrieving it
+
+    selectWidget.Options = entryOptions(window, databaseDAO, kind)
+    selectWidget.Selected = libs.OptionForUuid(selectWidget.Options, uuid)
+    window.Canvas().Refresh(selectWidget)
+}
+
+func trackTransaction(window fyne.Window, databaseDAO *libs.DatabaseDAO, config libs.Config, selectWidget *widget.Select,
+    kind string, uuid string, signed *libs.SignedTransaction, sendStatus *entityApi.TransactionStatus) {
+    // Polls a sent transaction in the background, keeping its status badge up to date and retrieving the entry
+    // once it is committed if it is still the selected one
+
+    tracker := libs.NewStatusTracker(databaseDAO, config)
+    tracker.OnChange = func(kind string, uuid string, status string) {
+        selected := libs.UuidFromOption(selectWidget.Selected)
+        selectWidget.Options = entryOptions(window, databaseDAO, kind)
+        if selected != uuid {
+            window.Canvas().Refresh(selectWidget)
+            return
+        }
+        if status == libs.StatusCommitted {
+            // Selecting it again retrieves it from the API
+            selectWidget.SetSelected(libs.OptionForUuid(selectWidget.Options, uuid))
+            return
+        }
+        selectWidget.Selected = libs.OptionForUuid(selectWidget.Options, uuid)
+        window.Canvas().Refresh(selectWidget)
+    }
+
+    go tracker.Track(kind, uuid, signed, sendStatus)
+}
+
+func refreshTransactions(window fyne.Window, databaseDAO *libs.DatabaseDAO, certificatesSelect *widget.Select, secretsSelect *widget.Select) {
+    // Polls once in the background the transactions left pending or timed out by an earlier session or the command
+    // line, keeping the status badges of the entries the chain returned up to date
+
+    tracker := libs.NewStatusTracker(databaseDAO, libs.Config{})
+    tracker.OnChange = func(kind string, uuid string, status string) {
+        selectWidget := certificatesSelect
+        if kind == libs.EntrySecret {
+            selectWidget = secretsSelect
+        }
+        selected := libs.UuidFromOption(selectWidget.Selected)
+        selectWidget.Options = entryOptions(window, databaseDAO, kind)
+        if selected != "" {
+            selectWidget.Selected = libs.OptionForUuid(selectWidget.Options, selected)
+        }
+        window.Canvas().Refresh(selectWidget)
+    }
+
+    go func() {
+        _, _ = tracker.Refresh()
+    }()
+}
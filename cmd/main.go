@@ -2,32 +2,38 @@
 package main
 
 import (
-    "encoding/json"
+    "fmt"
+    "os"
     "strconv"
-    "time"
+    "strings"
 
     "fyne.io/fyne"
     "fyne.io/fyne/app"
     "fyne.io/fyne/dialog"
-    "fyne.io/fyne/layout"
     "fyne.io/fyne/theme"
     "fyne.io/fyne/widget"
     "github.com/google/uuid"
-    "github.com/katena-chain/sdk-go-client/api"
+    "github.com/katena-chain/sdk-go-client/crypto/X25519"
+    "github.com/katena-chain/sdk-go-client/utils"
     _ "github.com/mattn/go-sqlite3"
 
     "github.com/katena-chain/transactor-ui/libs"
 )
 
 func main() {
+    // A command switches to the headless command line mode
+    if isCliInvocation(os.Args[1:]) {
+        os.Exit(runCli(os.Args[1:]))
+    }
+
     // Get a data access object to manipulate the database
-    databaseDAO := libs.InitDb()
+    databaseDAO, dbErr := libs.InitDb()
 
     // Variable declarations
     var tabCont *widget.TabContainer
     var selectWidgetCertificates *widget.Select
     var selectWidgetSecrets *widget.Select
-    var entryDisplaySecrets *widget.Entry
+    var secretsView *transactionView
 
     var config libs.Config
     var certificateData libs.CertificateHandler
@@ -38,6 +44,13 @@ func main() {
     window := appl.NewWindow("Katena Transactor UI")
     appl.Settings().SetTheme(theme.LightTheme())
 
+    if dbErr != nil {
+        // Nothing works without the database, only report why it cannot be used
+        window.SetContent(widget.NewLabel("Cannot use the database : " + dbErr.Error()))
+        window.ShowAndRun()
+        return
+    }
+
     // Preparing icons
     configIcon, err := libs.MakeImageResource("Config icon", "../assets/config.png")
     libs.CheckIcon(err)
@@ -48,41 +61,205 @@ func main() {
     appIcon, err := libs.MakeImageResource("App icon", "../assets/katena-icon.png")
     libs.CheckIcon(err)
 
+    // Load the saved configuration profiles, the private key is never part of them
+    profileStore, profileErr := libs.LoadProfiles()
+
     // Build tabs for tabContainer
-    privKeyEntry := widget.NewEntry()
+    privKeyEntry := widget.NewPasswordEntry()
     companyChainIDEntry := widget.NewEntry()
     chainIDEntry := widget.NewEntry()
-    chainIDEntry.SetText("katena-chain-test")
+    chainIDEntry.SetText(libs.DefaultChainID)
     apiURLEntry := widget.NewEntry()
-    apiURLEntry.SetText("https://api.test.katena.transchain.io/api/v1")
+    apiURLEntry.SetText(libs.DefaultApiUrl)
+    profileNameEntry := widget.NewEntry()
+    keystoreStatusLabel := widget.NewLabel(keystoreStatus(&databaseDAO))
+    selectWidgetTransactorKey := widget.NewSelect(keyOptions(&databaseDAO, libs.KeyTypeED25519), nil)
+    selectWidgetTransactorKey.SetSelected(useEntryOption)
+    readConfig := func() libs.Config {
+        keyName := ""
+        if selectWidgetTransactorKey.Selected != useEntryOption {
+            keyName = selectWidgetTransactorKey.Selected
+        }
+        return libs.Config{
+            Profile:        profileNameEntry.Text,
+            PrivKey:        privKeyEntry.Text,
+            KeyName:        keyName,
+            Keystore:       databaseDAO.Keystore,
+            History:        &databaseDAO,
+            CompanyChainID: companyChainIDEntry.Text,
+            ChainID:        chainIDEntry.Text,
+            ApiUrl:         apiURLEntry.Text,
+        }
+    }
+    saveProfiles := func() bool {
+        // Writes the profiles file, reporting why it is not possible
+        if profileStore == nil {
+            dialog.ShowError(profileErr, window)
+            return false
+        }
+        if err := profileStore.Save(); err != nil {
+            dialog.ShowError(err, window)
+            return false
+        }
+        return true
+    }
+
+    var profileNames []string
+    if profileStore != nil {
+        profileNames = profileStore.Names()
+    }
+    selectWidgetProfiles := widget.NewSelect(profileNames, func(name string) {
+        // Fills the entries with the selected profile
+        if profileStore == nil {
+            return
+        }
+        profile, err := profileStore.Get(name)
+        if err != nil {
+            return
+        }
+        profileNameEntry.SetText(profile.Name)
+        if profile.KeyName != "" {
+            selectWidgetTransactorKey.SetSelected(profile.KeyName)
+        } else {
+            selectWidgetTransactorKey.SetSelected(useEntryOption)
+        }
+        companyChainIDEntry.SetText(profile.CompanyChainID)
+        chainIDEntry.SetText(profile.ChainID)
+        apiURLEntry.SetText(profile.ApiUrl)
+    })
+
+    // The audit log head is certified on chain periodically while the check is ticked
+    auditStatusLabel := widget.NewLabel(auditStatus(&databaseDAO))
+    autoAnchorCheck := widget.NewCheck("Anchor automatically", nil)
+    startAuditAnchoring(&databaseDAO, readConfig, autoAnchorCheck, auditStatusLabel)
+
     tabConfig := widget.NewVBox(
+        widget.NewLabel("Profile :"),
+        selectWidgetProfiles,
         widget.NewLabel("Company chain id :"),
         companyChainIDEntry,
+        widget.NewLabel("Transactor key :"),
+        selectWidgetTransactorKey,
         widget.NewLabel("Private key :"),
         privKeyEntry,
+        widget.NewButton("Generate key pair...", func() {
+            showGenerateKeyDialog(window, &databaseDAO, func(keyType string, publicKey string, privateKey string) {
+                if keyType == libs.KeyTypeED25519 {
+                    privKeyEntry.SetText(privateKey)
+                }
+                selectWidgetTransactorKey.Options = keyOptions(&databaseDAO, libs.KeyTypeED25519)
+                widget.Refresh(selectWidgetTransactorKey)
+            })
+        }),
         widget.NewLabel("Chain id :"),
         chainIDEntry,
         widget.NewLabel("API URL :"),
         apiURLEntry,
+        widget.NewLabel("Profile name :"),
+        profileNameEntry,
+        widget.NewHBox(
+            widget.NewButton("Save profile", func() {
+                // Saves the entries, except the private key, under the profile name and makes it active
+                if profileStore == nil {
+                    dialog.ShowError(profileErr, window)
+                    return
+                }
+                if err := profileStore.Set(libs.NewProfile(profileNameEntry.Text, readConfig())); err != nil {
+                    dialog.ShowError(err, window)
+                    return
+                }
+                _ = profileStore.Use(profileNameEntry.Text)
+                if !saveProfiles() {
+                    return
+                }
+                selectWidgetProfiles.Options = profileStore.Names()
+                selectWidgetProfiles.SetSelected(profileNameEntry.Text)
+            }),
+            widget.NewButton("Delete profile", func() {
+                // Removes the selected profile
+                if profileStore == nil {
+                    dialog.ShowError(profileErr, window)
+                    return
+                }
+                if err := profileStore.Remove(selectWidgetProfiles.Selected); err != nil {
+                    dialog.ShowError(err, window)
+                    return
+                }
+                if !saveProfiles() {
+                    return
+                }
+                selectWidgetProfiles.Options = profileStore.Names()
+                selectWidgetProfiles.Selected = ""
+                widget.Refresh(selectWidgetProfiles)
+            }),
+        ),
+        keystoreStatusLabel,
+        widget.NewHBox(
+            widget.NewButton("Unlock keystore", func() {
+                showUnlockDialog(window, &databaseDAO, func() {
+                    keystoreStatusLabel.SetText(keystoreStatus(&databaseDAO))
+                })
+            }),
+            widget.NewButton("Import key...", func() {
+                showImportKeyDialog(window, &databaseDAO, func() {
+                    selectWidgetTransactorKey.Options = keyOptions(&databaseDAO, libs.KeyTypeED25519)
+                    widget.Refresh(selectWidgetTransactorKey)
+                })
+            }),
+        ),
+        widget.NewHBox(
+            widget.NewButton("Export database...", func() {
+                showExportDatabaseDialog(window, &databaseDAO)
+            }),
+            widget.NewButton("Import database...", func() {
+                showImportDatabaseDialog(window, &databaseDAO, func() {
+                    // Shows the merged entries and keys
+                    selectWidgetTransactorKey.Options = keyOptions(&databaseDAO, libs.KeyTypeED25519)
+                    widget.Refresh(selectWidgetTransactorKey)
+                    selectWidgetCertificates.Options = entryOptions(window, &databaseDAO, libs.EntryCertificate)
+                    window.Canvas().Refresh(selectWidgetCertificates)
+                    selectWidgetSecrets.Options = entryOptions(window, &databaseDAO, libs.EntrySecret)
+                    window.Canvas().Refresh(selectWidgetSecrets)
+                })
+            }),
+        ),
+        auditStatusLabel,
+        widget.NewHBox(
+            widget.NewButton("Verify database...", func() {
+                showVerifyDatabaseDialog(window, &databaseDAO, readConfig())
+            }),
+            widget.NewButton("Anchor audit log...", func() {
+                showAnchorAuditDialog(window, &databaseDAO, readConfig(), auditStatusLabel)
+            }),
+            autoAnchorCheck,
+        ),
         widget.NewButton("Confirm", func() {
             // Opens transactions tab & gets entered the values
             // TODO - verify valid input ?
 
-            config = libs.Config{
-                PrivKey:        privKeyEntry.Text,
-                CompanyChainID: companyChainIDEntry.Text,
-                ChainID:        chainIDEntry.Text,
-                ApiUrl:         apiURLEntry.Text,
+            config = readConfig()
+
+            // Remember the selected profile for the next launch
+            if profileStore != nil && selectWidgetProfiles.Selected != "" && profileStore.Active != selectWidgetProfiles.Selected {
+                _ = profileStore.Use(selectWidgetProfiles.Selected)
+                saveProfiles()
             }
 
             tabCont.SelectTabIndex(1)
         }),
     )
 
-    entryDisplayCertificates := widget.NewMultiLineEntry()
+    // Load the profile that was active last time
+    if profileStore != nil && profileStore.GetActive() != nil {
+        selectWidgetProfiles.SetSelected(profileStore.Active)
+        config = readConfig()
+    }
+
+    certificateView := newTransactionView(window, fyne.Size{Width: 1000, Height: 650})
+    verificationLabel := widget.NewLabel("")
     selectWidgetCertificates = widget.NewSelect(
-        databaseDAO.UpdateCertificateOptions(), func(optionSelected string) {
-            if optionSelected == "Add certificate..." {
+        entryOptions(window, &databaseDAO, libs.EntryCertificate), func(optionSelected string) {
+            if optionSelected == libs.AddCertificateOption {
                 // Adding new uuid
 
                 // Build dialog canvas
@@ -107,9 +284,11 @@ func main() {
 
                 // Build child dialog canvas
                 jsonZone := widget.NewMultiLineEntry()
+                digestLabel := widget.NewLabel("")
                 childDialogContent := widget.NewVBox(
                     widget.NewLabel("Expected transaction :"),
                     jsonZone,
+                    digestLabel,
                 )
 
                 dialog.ShowCustomConfirm("Add certificate...", "Confirm", "Cancel", dialogContent,
@@ -117,6 +296,12 @@ func main() {
                         // If confirm, we prepare the certificate and ask for confirmation
                         if confirm && uuidEntry.Text != "" && signatureEntry.Text != "" && signerEntry.Text != "" {
 
+                            // A certificate can only be tracked once
+                            if err := databaseDAO.CheckNewEntry(libs.EntryCertificate, uuidEntry.Text); err != nil {
+                                dialog.ShowError(err, window)
+                                return
+                            }
+
                             // Prepare data for the certificate
                             certificateData = libs.CertificateHandler{
                                 Config:        config,
@@ -131,27 +316,36 @@ func main() {
                                 dialog.ShowError(err, window)
                                 return
                             }
-                            // Display it in the dedicated zone
+                            // Display it in the dedicated zone along with the hash of the bytes that will be sent
                             jsonZone.SetText(previewData)
+                            digestLabel.SetText("Request digest : " + certificateData.Signed.Digest())
 
                             dialog.ShowCustomConfirm("Confirm certificate...", "Send certificate", "Cancel", childDialogContent,
                                 func(confirm bool) {
                                     // If confirms, save the certificate and send the transaction
                                     if confirm {
 
-                                        // Send the transaction
-                                        transactionStatus, err := certificateData.SendCertificate()
-                                        if err != nil {
-                                            dialog.ShowError(err, window)
+                                        // Send the exact transaction shown in the preview
+                                        transactionStatus, historyErr := certificateData.SendCertificate()
+                                        if transactionStatus == nil {
+                                            dialog.ShowError(historyErr, window)
                                             return
                                         }
 
-                                        // Add the certificate to the DB
-                                        databaseDAO.AddCertificateEntry(certificateData.UuidText, certificateData.SignatureText, certificateData.SignerText)
-                                        // Updates the options list & select the created uuid
-                                        selectWidgetCertificates.Options = databaseDAO.UpdateCertificateOptions()
-                                        window.Canvas().Refresh(selectWidgetCertificates)
-                                        selectWidgetCertificates.SetSelected(uuidEntry.Text)
+                                        // Add the certificate to the DB, even if the history could not keep the transaction
+                                        if err := databaseDAO.AddCertificateEntry(certificateData.UuidText, certificateData.SignatureText, certificateData.SignerText,
+                                            certificateData.Signed.Digest(), config.Profile); err != nil {
+                                            dialog.ShowError(err, window)
+                                            return
+                                        }
+                                        // Updates the options list & show the created uuid, it is retrieved once committed
+                                        showEntry(window, &databaseDAO, selectWidgetCertificates, libs.EntryCertificate, certificateData.UuidText)
+                                        trackTransaction(window, &databaseDAO, config, selectWidgetCertificates, libs.EntryCertificate,
+                                            certificateData.UuidText, certificateData.Signed, transactionStatus)
+                                        if historyErr != nil {
+                                            dialog.ShowError(historyErr, window)
+                                            return
+                                        }
 
                                         // Show results dialog
                                         // => Convert uint32 statuscode to string
@@ -160,6 +354,8 @@ func main() {
 
                                     }
                                 }, window)
+                        } else if confirm {
+                            dialog.ShowError(fmt.Errorf("the UUID, signature and signer are required"), window)
                         }
                     }, window)
                 return
@@ -168,54 +364,121 @@ func main() {
             // From here - if an already existing UUID is selected
             // Get information about the certificate from the API and display it in the textEntry
 
+            // A tracked certificate of another company is retrieved from it
+            certificateUuid := libs.UuidFromOption(selectWidgetCertificates.Selected)
             certificateData = libs.CertificateHandler{
-                Config:   config,
-                UuidText: selectWidgetCertificates.Selected,
+                Config:   databaseDAO.EntryConfig(libs.EntryCertificate, certificateUuid, config),
+                UuidText: certificateUuid,
             }
 
-            resultData, err := certificateData.RetrieveCertificate()
+            summary, raw, verification, err := certificateData.RetrieveAndVerifyCertificate(&databaseDAO)
             if err != nil {
                 dialog.ShowError(err, window)
                 return
             }
 
-            entryDisplayCertificates.SetText(resultData)
+            verificationLabel.SetText("Verification : " + verification.String())
+            certificateView.SetTransactions([]*libs.TransactionSummary{summary}, raw)
         },
     )
 
-    // The scrollcontainer has to be wrapped in a fixed grid layout in order to be displayed in the proper size
-    entryDisplayCertificatesWrapper := widget.NewScrollContainer(entryDisplayCertificates)
-    size := fyne.Size{Width: 1000, Height: 700}
-    entryCertificatesWrap := fyne.NewContainerWithLayout(layout.NewFixedGridLayout(size), entryDisplayCertificatesWrapper)
-
     tabCertificates := widget.NewVBox(
         selectWidgetCertificates,
-        entryCertificatesWrap,
+        verificationLabel,
+        certificateView.Content,
+        widget.NewHBox(
+            widget.NewButton("Certify files...", func() {
+                showCertifyFilesDialog(window, &databaseDAO, config, selectWidgetCertificates)
+            }),
+            widget.NewButton("Import certificates...", func() {
+                showImportCertificatesDialog(window, &databaseDAO, config, selectWidgetCertificates)
+            }),
+            widget.NewButton("Broadcast transaction file...", func() {
+                showBroadcastFileDialog(window, &databaseDAO, config, selectWidgetCertificates, selectWidgetSecrets)
+            }),
+            widget.NewButton("Verify file...", func() {
+                if selectWidgetCertificates.Selected == "" || selectWidgetCertificates.Selected == libs.AddCertificateOption {
+                    dialog.ShowError(fmt.Errorf("select a certificate first"), window)
+                    return
+                }
+                showVerifyFileDialog(window, &databaseDAO, config, libs.UuidFromOption(selectWidgetCertificates.Selected))
+            }),
+        ),
         widget.NewButton("Remove this certificate", func() {
             // Removes the selected certificate
 
-            databaseDAO.RemoveCertificate(selectWidgetCertificates.Selected)
+            if err := databaseDAO.RemoveCertificate(libs.UuidFromOption(selectWidgetCertificates.Selected)); err != nil {
+                dialog.ShowError(err, window)
+                return
+            }
             // Updates the options list & selected the created certificate
-            selectWidgetCertificates.Options = databaseDAO.UpdateCertificateOptions()
+            selectWidgetCertificates.Options = entryOptions(window, &databaseDAO, libs.EntryCertificate)
             window.Canvas().Refresh(selectWidgetCertificates)
             // Selects first option by default
-            selectWidgetCertificates.SetSelected(databaseDAO.UpdateCertificateOptions()[0])
+            selectWidgetCertificates.SetSelected(selectWidgetCertificates.Options[0])
         }),
     )
 
     selectWidgetSecrets = widget.NewSelect(
-        databaseDAO.UpdateSecretOptions(),
+        entryOptions(window, &databaseDAO, libs.EntrySecret),
         func(optionSelected string) {
-            if optionSelected == "Add secret..." {
+            if optionSelected == libs.AddSecretOption {
                 // Adding a secret
 
                 // Preparing dialog canvas
                 uuidEntrySecrets := widget.NewEntry()
                 contentEntry := widget.NewEntry()
+                // A file is sent with its name and MIME type, restored under that name when decrypted
+                contentFileEntry := widget.NewEntry()
+                contentFileEntry.SetPlaceHolder("Path of a file to send instead of the content")
+                compressCheck := widget.NewCheck("Compress the file before sealing it", nil)
                 recipientPublicEntry := widget.NewEntry()
                 recipientPrivateEntry := widget.NewEntry()
                 senderPublicEntry := widget.NewEntry()
-                senderPrivateEntry := widget.NewEntry()
+                senderPrivateEntry := widget.NewPasswordEntry()
+                selectWidgetSenderKey := widget.NewSelect(keyOptions(&databaseDAO, libs.KeyTypeX25519), nil)
+                selectWidgetSenderKey.SetSelected(useEntryOption)
+                // A contact holds its own private key, none is recorded for it
+                recipientContactLabel := widget.NewLabel("")
+                recipientPublicEntry.OnChanged = func(publicKey string) {
+                    recipientContactLabel.SetText("")
+                    if fingerprint, err := libs.X25519Fingerprint(publicKey); err == nil {
+                        recipientContactLabel.SetText("Fingerprint : " + fingerprint)
+                    }
+                }
+                recipientContact := func() *libs.Contact {
+                    contacts, err := databaseDAO.ListContacts(recipientPublicEntry.Text)
+                    if err != nil || recipientPublicEntry.Text == "" {
+                        return nil
+                    }
+                    for _, contact := range contacts {
+                        if contact.PublicKey == recipientPublicEntry.Text {
+                            return &contact
+                        }
+                    }
+                    return nil
+                }
+                // The recipient key pair can also be generated for this secret only, its private key being handed off
+                // to the recipient in a passphrase protected bundle instead of being kept in the database
+                handoffCheck := widget.NewCheck("Generate the recipient key pair and hand it off in a bundle", nil)
+                handoffPathEntry := widget.NewEntry()
+                handoffPathEntry.SetPlaceHolder("UUID.handoff.json")
+                handoffPassphraseEntry := widget.NewPasswordEntry()
+                handoffConfirmationEntry := widget.NewPasswordEntry()
+                handoffBox := widget.NewVBox(
+                    widget.NewLabel("Hand-off bundle file :"),
+                    handoffPathEntry,
+                    widget.NewLabel("Hand-off passphrase, to be given to the recipient separately :"),
+                    handoffPassphraseEntry,
+                    widget.NewLabel("Confirm passphrase :"),
+                    handoffConfirmationEntry,
+                )
+                handoffBox.Hide()
+                contactPicker := newContactPicker(window, &databaseDAO, func(contact libs.Contact) {
+                    recipientPublicEntry.SetText(contact.PublicKey)
+                    recipientPrivateEntry.SetText("")
+                    recipientContactLabel.SetText("Fingerprint : " + contact.Fingerprint + " (" + contact.Name + ")")
+                })
                 dialogContentSecrets := widget.NewVBox(
                     widget.NewLabel("UUID :"),
                     uuidEntrySecrets,
@@ -228,32 +491,113 @@ func main() {
                     }),
                     widget.NewLabel("Content :"),
                     contentEntry,
+                    widget.NewLabel("Content file :"),
+                    contentFileEntry,
+                    compressCheck,
+                    widget.NewLabel("Recipient contact :"),
+                    contactPicker,
                     widget.NewLabel("Recipient public key :"),
                     recipientPublicEntry,
-                    widget.NewLabel("Recipient private key :"),
+                    recipientContactLabel,
+                    widget.NewLabel("Recipient private key, empty for a contact :"),
                     recipientPrivateEntry,
+                    widget.NewButton("Generate recipient key pair", generateKeyInto(window, recipientPublicEntry, recipientPrivateEntry)),
+                    handoffCheck,
+                    handoffBox,
+                    widget.NewLabel("Sender key :"),
+                    selectWidgetSenderKey,
                     widget.NewLabel("Sender public key :"),
                     senderPublicEntry,
                     widget.NewLabel("Sender private key :"),
                     senderPrivateEntry,
+                    widget.NewButton("Generate sender key pair", func() {
+                        generateKeyInto(window, senderPublicEntry, senderPrivateEntry)()
+                        selectWidgetSenderKey.SetSelected(useEntryOption)
+                    }),
                 )
+                handoffCheck.OnChanged = func(checked bool) {
+                    if checked {
+                        handoffBox.Show()
+                    } else {
+                        handoffBox.Hide()
+                    }
+                    widget.Refresh(dialogContentSecrets)
+                }
 
                 // Build child dialog canvas
                 jsonZoneSecrets := widget.NewMultiLineEntry()
+                digestLabelSecrets := widget.NewLabel("")
                 secretsChildDialogContent := widget.NewVBox(
                     widget.NewLabel("Expected transaction :"),
                     jsonZoneSecrets,
+                    digestLabelSecrets,
                 )
 
                 dialog.ShowCustomConfirm("Add a secret...", "Confirm", "Cancel", dialogContentSecrets, func(confirm bool) {
-                    if confirm && uuidEntrySecrets.Text != "" && contentEntry.Text != "" && recipientPublicEntry.Text != "" && recipientPrivateEntry.Text != "" && senderPrivateEntry.Text != "" && senderPublicEntry.Text != "" {
+                    useSenderEntries := selectWidgetSenderKey.Selected == useEntryOption
+                    contact := recipientContact()
+                    handoff := handoffCheck.Checked
+                    missingErr := checkSecretDialog(uuidEntrySecrets.Text, contentEntry.Text != "" || contentFileEntry.Text != "", handoff,
+                        recipientPublicEntry.Text, recipientPrivateEntry.Text, contact != nil, useSenderEntries, senderPublicEntry.Text,
+                        senderPrivateEntry.Text)
+                    if confirm && missingErr != nil {
+                        dialog.ShowError(missingErr, window)
+                    } else if confirm {
                         // If confirmed, prepare the secret and ask for confirmation
 
+                        // The recipient private key can only be recorded encrypted
+                        if !handoff && recipientPrivateEntry.Text != "" && !databaseDAO.Keystore.Unlocked() {
+                            dialog.ShowError(fmt.Errorf("unlock the keystore first, the recipient private key is stored encrypted"), window)
+                            return
+                        }
+                        // A single secret per UUID can be tracked
+                        if err := databaseDAO.CheckNewEntry(libs.EntrySecret, uuidEntrySecrets.Text); err != nil {
+                            dialog.ShowError(err, window)
+                            return
+                        }
+                        // A content too large for the chain is rejected before anything is signed
+                        content, contentDescription, err := dialogSecretContent(contentEntry.Text, contentFileEntry.Text, compressCheck.Checked)
+                        if err != nil {
+                            dialog.ShowError(err, window)
+                            return
+                        }
+
                         // For the db
                         recipientPrivateKeyX25519Base64 := recipientPrivateEntry.Text
 
+                        // The bundle of a hand-off is written once the secret is confirmed, before it is sent
+                        var handoffBundle *libs.HandoffBundle
+                        handoffPath := strings.TrimSpace(handoffPathEntry.Text)
+                        if handoff {
+                            if handoffPassphraseEntry.Text != handoffConfirmationEntry.Text {
+                                dialog.ShowError(fmt.Errorf("passphrases do not match"), window)
+                                return
+                            }
+                            if handoffPath == "" {
+                                handoffPath = uuidEntrySecrets.Text + ".handoff.json"
+                            }
+                            handoffPublicKey, bundle, err := libs.GenerateHandoff(uuidEntrySecrets.Text, config.CompanyChainID, handoffPassphraseEntry.Text)
+                            if err != nil {
+                                dialog.ShowError(err, window)
+                                return
+                            }
+                            handoffBundle = bundle
+                            recipientPublicEntry.SetText(handoffPublicKey)
+                            recipientPrivateEntry.SetText("")
+                            recipientPrivateKeyX25519Base64 = ""
+                        }
+
                         // Prepare the keys in the right format
-                        recipientPublicKey, senderPublicKey, senderPrivateKey, err := libs.ConvertKeys(recipientPublicEntry.Text, senderPublicEntry.Text, senderPrivateEntry.Text)
+                        var recipientPublicKey, senderPublicKey *X25519.PublicKey
+                        var senderPrivateKey *X25519.PrivateKey
+                        if useSenderEntries {
+                            recipientPublicKey, senderPublicKey, senderPrivateKey, err = libs.ConvertKeys(recipientPublicEntry.Text, senderPublicEntry.Text, senderPrivateEntry.Text)
+                        } else {
+                            recipientPublicKey, err = utils.CreatePublicKeyX25519FromBase64(recipientPublicEntry.Text)
+                            if err == nil {
+                                senderPublicKey, senderPrivateKey, err = databaseDAO.Keystore.GetX25519Key(selectWidgetSenderKey.Selected)
+                            }
+                        }
                         if err != nil {
                             dialog.ShowError(err, window)
                             return
@@ -263,7 +607,7 @@ func main() {
                         secretData = libs.SecretHandler{
                             Config:          config,
                             UuidText:        uuidEntrySecrets.Text,
-                            Content:         []byte(contentEntry.Text),
+                            Content:         content,
                             RecipientPubKey: recipientPublicKey,
                             SenderPubKey:    senderPublicKey,
                             SenderPrivKey:   senderPrivateKey,
@@ -276,32 +620,46 @@ func main() {
                             return
                         }
 
-                        // Display the preview
+                        // Display the preview along with the hash of the bytes that will be sent
                         jsonZoneSecrets.SetText(previewData)
+                        digestLabelSecrets.SetText("Request digest : " + secretData.Signed.Digest() + "\n" + contentDescription)
+                        if contact != nil && !handoff {
+                            digestLabelSecrets.SetText(digestLabelSecrets.Text + "\nRecipient : " + contact.Label())
+                        }
+                        if handoff {
+                            digestLabelSecrets.SetText(digestLabelSecrets.Text + "\nRecipient private key handed off in " + handoffPath +
+                                ", no copy is kept in the database")
+                        }
 
                         dialog.ShowCustomConfirm("Confirm secret", "Send secret", "Cancel", secretsChildDialogContent, func(confirm bool) {
                             // If confirmed, save the secret to DB and send it to the API
                             if confirm {
-                                // API send
-                                transactionStatusSecret, err := secretData.SendSecret()
-                                if err != nil {
-                                    dialog.ShowError(err, window)
+                                if handoffBundle != nil {
+                                    if err := writeHandoffBundle(handoffBundle, handoffPath); err != nil {
+                                        dialog.ShowError(err, window)
+                                        return
+                                    }
+                                }
+                                // API send of the exact transaction shown in the preview
+                                transactionStatusSecret, historyErr := secretData.SendSecret()
+                                if transactionStatusSecret == nil {
+                                    dialog.ShowError(historyErr, window)
                                     return
                                 }
 
-                                // DB save
-                                databaseDAO.AddSecretEntry(secretData.UuidText, recipientPrivateKeyX25519Base64)
-                                // Updates the options list & select the created uuid
-                                selectWidgetSecrets.Options = databaseDAO.UpdateSecretOptions()
-                                window.Canvas().Refresh(selectWidgetSecrets)
-
-                                // If we don't wait, the selection prompts an error because the secret can't be retrieved from the API yet
-                                // Done in a goroutine in order to not freeze the UI for a few seconds
-                                go func() {
-                                    // TODO - Remove and not select
-                                    time.Sleep(2 * time.Second)
-                                    selectWidgetSecrets.SetSelected(secretData.UuidText)
-                                }()
+                                // DB save, even if the history could not keep the transaction
+                                if err := databaseDAO.AddSecretEntry(secretData.UuidText, recipientPrivateKeyX25519Base64, secretData.Signed.Digest(), config.Profile); err != nil {
+                                    dialog.ShowError(err, window)
+                                    return
+                                }
+                                // Updates the options list & show the created uuid, it is retrieved once committed
+                                showEntry(window, &databaseDAO, selectWidgetSecrets, libs.EntrySecret, secretData.UuidText)
+                                trackTransaction(window, &databaseDAO, config, selectWidgetSecrets, libs.EntrySecret,
+                                    secretData.UuidText, secretData.Signed, transactionStatusSecret)
+                                if historyErr != nil {
+                                    dialog.ShowError(historyErr, window)
+                                    return
+                                }
 
                                 // Show results dialog
                                 // => Convert uint32 statuscode to string
@@ -315,58 +673,85 @@ func main() {
                 }, window)
             } else {
                 // An already existing secret has been selected
-                secretUUID := selectWidgetSecrets.Selected
+                secretUUID := libs.UuidFromOption(selectWidgetSecrets.Selected)
                 // Done in a goroutine because this can return data of an important size which can freeze the UI for a little while if done
                 // on the main routine
                 go func() {
-                    // Retrieve corresponding secret
-                    apiHandler := api.NewHandler(config.ApiUrl)
-                    transactionWrappers, err := apiHandler.RetrieveSecrets(config.CompanyChainID, secretUUID)
+                    // Retrieve corresponding secret and open it with the recipient key stored when it was sent
+                    secretsData := libs.SecretHandler{
+                        Config:   databaseDAO.EntryConfig(libs.EntrySecret, secretUUID, config),
+                        UuidText: secretUUID,
+                    }
+
+                    recipientPrivKey, err := databaseDAO.GetSecretDecryptingKey(secretUUID)
                     if err != nil {
                         dialog.ShowError(err, window)
                         return
                     }
-
-                    // Indent and display the json corresponding to the transaction
-                    data, err := json.MarshalIndent(transactionWrappers, " ", "    ")
+                    summaries, raw, err := secretsData.RetrieveSecretDetails(recipientPrivKey)
                     if err != nil {
                         dialog.ShowError(err, window)
                         return
                     }
+                    if len(summaries) == 0 {
+                        secretsView.SetMessage("No secret found for this UUID")
+                        return
+                    }
 
-                    entryDisplaySecrets.SetText(string(data))
+                    secretsView.SetTransactions(summaries, raw)
                 }()
             }
         },
     )
 
-    // The scrollcontainer has to be wrapped in a fixed grid layout in order to be displayed in the proper size
-    entryDisplaySecrets = widget.NewMultiLineEntry()
-    entryDisplaySecretsWrapper := widget.NewScrollContainer(entryDisplaySecrets)
-    size = fyne.Size{Height: 1000, Width: 700}
-    entrySecretsWrap := fyne.NewContainerWithLayout(layout.NewFixedGridLayout(size), entryDisplaySecretsWrapper)
+    secretsView = newTransactionView(window, fyne.Size{Width: 1000, Height: 650})
+
+    secretGroups := newSecretGroupsView(window, &databaseDAO, func() libs.Config { return config })
 
     tabSecrets := widget.NewVBox(
         selectWidgetSecrets,
-        entrySecretsWrap,
+        secretsView.Content,
         widget.NewButton("Remove this secret", func() {
             // Removes the selected secret
-            databaseDAO.RemoveSecret(selectWidgetSecrets.Selected)
+            if err := databaseDAO.RemoveSecret(libs.UuidFromOption(selectWidgetSecrets.Selected)); err != nil {
+                dialog.ShowError(err, window)
+                return
+            }
             // Updates the options list & selected the created certificate
-            selectWidgetSecrets.Options = databaseDAO.UpdateSecretOptions()
+            selectWidgetSecrets.Options = entryOptions(window, &databaseDAO, libs.EntrySecret)
             window.Canvas().Refresh(selectWidgetSecrets)
             // Selects first option by default
-            selectWidgetSecrets.SetSelected(databaseDAO.UpdateSecretOptions()[0])
+            selectWidgetSecrets.SetSelected(selectWidgetSecrets.Options[0])
+        }),
+        widget.NewButton("Save attached files...", func() {
+            if selectWidgetSecrets.Selected == "" || selectWidgetSecrets.Selected == libs.AddSecretOption {
+                dialog.ShowError(fmt.Errorf("select a secret first"), window)
+                return
+            }
+            showSaveSecretFilesDialog(window, &databaseDAO, config, libs.UuidFromOption(selectWidgetSecrets.Selected))
+        }),
+        widget.NewButton("Import hand-off bundle...", func() {
+            showImportHandoffDialog(window, &databaseDAO, config, func(uuid string) {
+                showEntry(window, &databaseDAO, selectWidgetSecrets, libs.EntrySecret, uuid)
+            })
         }),
+        secretGroups.Content,
     )
 
-    entryDisplaySecretsWrapper.Resize(fyne.NewSize(500, 2000))
+    tabHistory := newHistoryTab(window, &databaseDAO)
+    tabContacts := newContactsTab(window, &databaseDAO)
+    tabInbox := newInboxTab(window, &databaseDAO, func() libs.Config { return config })
+    tabLookup := newLookupTab(window, &databaseDAO, func() libs.Config { return config }, selectWidgetCertificates, selectWidgetSecrets)
 
     // Build tabContainer
     tabCont = widget.NewTabContainer(
         widget.NewTabItemWithIcon("Configuration", configIcon, tabConfig),
         widget.NewTabItemWithIcon("Certificates", transactionIcon, tabCertificates),
         widget.NewTabItemWithIcon("Secrets", resultIcon, tabSecrets),
+        widget.NewTabItemWithIcon("Contacts", theme.MailComposeIcon(), tabContacts),
+        widget.NewTabItemWithIcon("Inbox", theme.MailReplyIcon(), tabInbox),
+        widget.NewTabItemWithIcon("Lookup", theme.SearchIcon(), tabLookup),
+        widget.NewTabItemWithIcon("History", theme.InfoIcon(), tabHistory),
     )
     tabCont.SetTabLocation(widget.TabLocationLeading)
 
@@ -376,5 +761,16 @@ func main() {
         tabCont,
     ))
     window.CenterOnScreen()
+    // Only one dialog can be displayed at a time, the keystore can still be unlocked from the Configuration tab
+    if profileErr != nil {
+        dialog.ShowError(profileErr, window)
+    } else {
+        // Unlock the keystore once for the session
+        showUnlockDialog(window, &databaseDAO, func() {
+            keystoreStatusLabel.SetText(keystoreStatus(&databaseDAO))
+        })
+    }
+    // The transactions sent before are followed up once, the command line sends leaving them pending
+    refreshTransactions(window, &databaseDAO, selectWidgetCertificates, selectWidgetSecrets)
     window.ShowAndRun()
 }
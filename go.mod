@@ -8,5 +8,5 @@ require (
 	github.com/katena-chain/sdk-go-client v1.1.2
 	github.com/mattn/go-sqlite3 v1.10.0
 	github.com/valyala/fasthttp v1.4.0 // indirect
-	golang.org/x/crypto v0.0.0-20190701094942-4def268fd1a4 // indirect
+	golang.org/x/crypto v0.0.0-20190701094942-4def268fd1a4
 )
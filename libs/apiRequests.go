@@ -2,10 +2,12 @@ package libs
 
 import (
     "encoding/json"
+    "fmt"
+    "strings"
     "time"
 
     "github.com/katena-chain/sdk-go-client/api"
-    "github.com/katena-chain/sdk-go-client/client"
+    "github.com/katena-chain/sdk-go-client/crypto/ED25519"
     "github.com/katena-chain/sdk-go-client/crypto/X25519"
     "github.com/katena-chain/sdk-go-client/entity"
     entityApi "github.com/katena-chain/sdk-go-client/entity/api"
@@ -13,18 +15,57 @@ import (
     "github.com/katena-chain/sdk-go-client/utils"
 )
 
+const DefaultChainID = "katena-chain-test"
+const DefaultApiUrl = "https://api.test.katena.transchain.io/api/v1"
+
 type Config struct {
-    PrivKey        string
+    Profile  string
+    PrivKey  string
+    KeyName  string
+    Keystore *Keystore
+    // Records the broadcast transactions when set
+    History        *DatabaseDAO
     ChainID        string
     CompanyChainID string
     ApiUrl         string
 }
 
+func (config Config) TransactorKey() (*ED25519.PrivateKey, error) {
+    // Returns the transactor key, from the keystore when a key name is configured
+
+    if config.KeyName != "" {
+        if config.Keystore == nil {
+            return nil, fmt.Errorf("no keystore to load key %s from", config.KeyName)
+        }
+        return config.Keystore.GetED25519Key(config.KeyName)
+    }
+    if config.PrivKey == "" {
+        return nil, fmt.Errorf("missing private key")
+    }
+    return utils.CreatePrivateKeyED25519FromBase64(config.PrivKey)
+}
+
+func (config Config) Check() error {
+    // Returns an error naming the first missing configuration value
+
+    if config.CompanyChainID == "" {
+        return fmt.Errorf("missing company chain id")
+    }
+    if config.ChainID == "" {
+        return fmt.Errorf("missing chain id")
+    }
+    if config.ApiUrl == "" {
+        return fmt.Errorf("missing API URL")
+    }
+    return nil
+}
+
 type CertificateHandler struct {
     Config
     UuidText      string
     SignatureText string
     SignerText    string
+    Signed        *SignedTransaction
 }
 
 func ConvertKeys(recipientPubKey string, senderPubKey string, senderPrivKey string) (*X25519.PublicKey, *X25519.PublicKey, *X25519.PrivateKey, error) {
@@ -46,71 +87,69 @@ func ConvertKeys(recipientPubKey string, senderPubKey string, senderPrivKey stri
     return recipientPublicKey, senderPublicKey, senderPrivateKey, nil
 }
 
-func (certHandler *CertificateHandler) GetCertificatePreview() (string, error) {
-    // Calls the API to retrieve the certificate corresponding to the structs data and returns the indented JSON result
-
-    privateKeyForTransactor, err := utils.CreatePrivateKeyED25519FromBase64(certHandler.Config.PrivKey)
-    if err != nil {
-        return "Error loading", err
-    }
+func (certHandler *CertificateHandler) Message() entity.Message {
+    // Builds the certificate message, before it is signed
 
-    // Build the certificate
     certificate := certify.NewCertificateV1(certHandler.UuidText, certHandler.Config.CompanyChainID, []byte(certHandler.SignatureText), []byte(certHandler.SignerText))
-    message := &certify.MsgCreateCertificate{
+    return &certify.MsgCreateCertificate{
         Certificate: certificate,
     }
+}
 
-    // getTransaction() reproduction here
-    nonceTime := entity.Time{
-        Time: time.Now(),
-    }
-    sealState := &entity.SealState{
-        Message:   message,
-        ChainID:   certHandler.Config.ChainID,
-        NonceTime: &nonceTime,
+func (certHandler *CertificateHandler) BuildTransaction() error {
+    // Builds and signs the certificate transaction once, the send step broadcasts it unchanged
+
+    privateKeyForTransactor, err := certHandler.Config.TransactorKey()
+    if err != nil {
+        return err
     }
-    sealStateBytes, err := sealState.GetSignBytes()
+
+    signed, err := SignMessage(certHandler.Message(), certHandler.Config.ChainID, privateKeyForTransactor)
     if err != nil {
-        return "Error loading", err
+        return err
     }
+    certHandler.Signed = signed
+    return nil
+}
 
-    msgSignature := privateKeyForTransactor.Sign(sealStateBytes)
-    transaction := entityApi.NewTransaction(message, msgSignature, privateKeyForTransactor.GetPublicKey(), &nonceTime)
+func (certHandler *CertificateHandler) GetCertificatePreview() (string, error) {
+    // Builds and signs the certificate transaction and returns its indented JSON
 
-    // Indent the json corresponding to the transaction
-    data, err := json.MarshalIndent(transaction, " ", "    ")
+    if err := certHandler.BuildTransaction(); err != nil {
+        return "Error loading", err
+    }
+
+    data, err := certHandler.Signed.Preview()
     if err != nil {
         return "Error loading", err
     }
 
-    return string(data), nil
+    return data, nil
 }
 
 func (certHandler *CertificateHandler) SendCertificate() (*entityApi.TransactionStatus, error) {
-    // Sends a certificate to the API using the data in the built struct
+    // Broadcasts the transaction shown in the preview, building it first if no preview was requested
 
-    privateKeyForTransactor, err := utils.CreatePrivateKeyED25519FromBase64(certHandler.Config.PrivKey)
-    if err != nil {
-        return nil, err
+    if certHandler.Signed == nil {
+        if err := certHandler.BuildTransaction(); err != nil {
+            return nil, err
+        }
     }
 
-    // Build the transactor
-    transactor := client.NewTransactor(certHandler.Config.ApiUrl, certHandler.Config.ChainID, privateKeyForTransactor, certHandler.Config.CompanyChainID)
+    return certHandler.Config.Broadcast(certHandler.Signed)
+}
 
-    // Send the transaction and display the resulting code and message in the last tab
-    transactionStatus, err := transactor.SendCertificateV1(certHandler.UuidText, []byte(certHandler.SignatureText), []byte(certHandler.SignerText))
-    if err != nil {
-        return nil, err
-    }
+func (certHandler *CertificateHandler) GetCertificate() (*entityApi.TransactionWrapper, error) {
+    // Retrieves the certificate corresponding to the struct's UUID from the API
 
-    return transactionStatus, nil
+    apiHandler := api.NewHandler(certHandler.Config.ApiUrl)
+    return apiHandler.RetrieveCertificate(certHandler.Config.CompanyChainID, certHandler.UuidText)
 }
 
 func (certHandler *CertificateHandler) RetrieveCertificate() (string, error) {
     // Create transactor & retrieve certificate with the data in the struct
 
-    apiHandler := api.NewHandler(certHandler.Config.ApiUrl)
-    transactionWrapper, err := apiHandler.RetrieveCertificate(certHandler.Config.CompanyChainID, certHandler.UuidText)
+    transactionWrapper, err := certHandler.GetCertificate()
     if err != nil {
         return "", err
     }
@@ -131,23 +170,23 @@ type SecretHandler struct {
     RecipientPubKey *X25519.PublicKey
     SenderPubKey    *X25519.PublicKey
     SenderPrivKey   *X25519.PrivateKey
+    Signed          *SignedTransaction
 }
 
-func (secHandler *SecretHandler) GetSecretPreview() (string, error) {
-    // Calls the API to retrieve the secret using the struct's data and returns the indented JSON result
+func (secHandler *SecretHandler) Message() (entity.Message, error) {
+    // Seals the content for the recipient and builds the secret message, before it is signed
 
-    privateKeyForTransactor, err := utils.CreatePrivateKeyED25519FromBase64(secHandler.Config.PrivKey)
-    if err != nil {
-        return "Error loading", err
+    // A transaction over the chain limit is refused before being signed
+    if err := CheckSecretContentSize(len(secHandler.Content)); err != nil {
+        return nil, err
     }
 
     // Encrypt the secret
     nonce, encryptedContent, err := secHandler.SenderPrivKey.Seal(secHandler.Content, secHandler.RecipientPubKey)
     if err != nil {
-        return "Error loading", err
+        return nil, err
     }
 
-    // Getting the transaction
     secret := certify.NewSecretV1(
         secHandler.UuidText,
         secHandler.Config.CompanyChainID,
@@ -155,52 +194,214 @@ func (secHandler *SecretHandler) GetSecretPreview() (string, error) {
         nonce,
         encryptedContent,
     )
-    messageSecret := &certify.MsgCreateSecret{
+    return &certify.MsgCreateSecret{
         Secret: secret,
+    }, nil
+}
+
+func (secHandler *SecretHandler) BuildTransaction() error {
+    // Seals the content and signs the secret transaction once, the send step broadcasts it unchanged
+
+    privateKeyForTransactor, err := secHandler.Config.TransactorKey()
+    if err != nil {
+        return err
     }
-    nonceTime := entity.Time{
-        Time: time.Now(),
+
+    messageSecret, err := secHandler.Message()
+    if err != nil {
+        return err
     }
-    sealState := &entity.SealState{
-        Message:   messageSecret,
-        ChainID:   secHandler.Config.ChainID,
-        NonceTime: &nonceTime,
+
+    signed, err := SignMessage(messageSecret, secHandler.Config.ChainID, privateKeyForTransactor)
+    if err != nil {
+        return err
     }
-    sealStateBytes, err := sealState.GetSignBytes()
+    secHandler.Signed = signed
+    return nil
+}
+
+func (secHandler *SecretHandler) GetSecretPreview() (string, error) {
+    // Builds and signs the secret transaction and returns its indented JSON
+
+    if err := secHandler.BuildTransaction(); err != nil {
+        return "Error loading", err
+    }
+
+    data, err := secHandler.Signed.Preview()
     if err != nil {
         return "Error loading", err
     }
 
-    msgSignature := privateKeyForTransactor.Sign(sealStateBytes)
-    transaction := entityApi.NewTransaction(messageSecret, msgSignature, privateKeyForTransactor.GetPublicKey(), &nonceTime)
+    return data, nil
+}
 
-    // Indent and display the json corresponding to the transaction
-    data, err := json.MarshalIndent(transaction, " ", "    ")
+func (secHandler *SecretHandler) SendSecret() (*entityApi.TransactionStatus, error) {
+    // Broadcasts the transaction shown in the preview, building it first if no preview was requested
+
+    if secHandler.Signed == nil {
+        if err := secHandler.BuildTransaction(); err != nil {
+            return nil, err
+        }
+    }
+
+    return secHandler.Config.Broadcast(secHandler.Signed)
+}
+
+func (secHandler *SecretHandler) GetSecrets() (*entityApi.TransactionWrappers, error) {
+    // Retrieves the secrets attached to the struct's UUID from the API
+
+    apiHandler := api.NewHandler(secHandler.Config.ApiUrl)
+    return apiHandler.RetrieveSecrets(secHandler.Config.CompanyChainID, secHandler.UuidText)
+}
+
+func (secHandler *SecretHandler) RetrieveSecrets() (string, error) {
+    // Retrieves the secrets attached to the struct's UUID and returns the indented JSON result
+
+    transactionWrappers, err := secHandler.GetSecrets()
     if err != nil {
-        return "Error loading", err
+        return "", err
+    }
+
+    // Indent the json corresponding to the transactions
+    data, err := json.MarshalIndent(transactionWrappers, " ", "    ")
+    if err != nil {
+        return "", err
     }
 
     return string(data), nil
 }
 
-func (secHandler *SecretHandler) SendSecret() (*entityApi.TransactionStatus, error) {
-    // Sends a secret to the API using the data in the struct
+type DecryptedSecret struct {
+    Uuid           string
+    CompanyChainID string
+    NonceTime      time.Time
+    Status         *entityApi.TransactionStatus
+    Content        []byte
+    // Fingerprint of the sender public key the content was sealed with
+    SenderFingerprint string
+    // Set when the content is a file envelope
+    File *SecretFile
+    Err  error
+}
+
+func (decrypted *DecryptedSecret) Text() string {
+    // Returns the content of an opened secret, or a description of the file it carries
+
+    if decrypted.File != nil {
+        return "File " + decrypted.File.String()
+    }
+    return string(decrypted.Content)
+}
 
-    privateKeyForTransactor, err := utils.CreatePrivateKeyED25519FromBase64(secHandler.Config.PrivKey)
+func OpenSecrets(transactionWrappers *entityApi.TransactionWrappers, recipientPrivKey string) ([]DecryptedSecret, error) {
+    // Opens every secret of the wrappers with the recipient private key and the sender's public key and nonce from the chain
+
+    if recipientPrivKey == "" {
+        return nil, fmt.Errorf("no recipient private key stored for this secret")
+    }
+    recipientPrivateKey, err := utils.CreatePrivateKeyX25519FromBase64(recipientPrivKey)
     if err != nil {
-        return nil, err
+        return nil, fmt.Errorf("invalid recipient private key: %s", err)
     }
 
-    transactorSecret := client.NewTransactor(secHandler.Config.ApiUrl, secHandler.Config.ChainID, privateKeyForTransactor, secHandler.Config.CompanyChainID)
-    // Encrypt the secret (again to refresh nonce)
-    nonce, encryptedContent, err := secHandler.SenderPrivKey.Seal(secHandler.Content, secHandler.RecipientPubKey)
+    result := make([]DecryptedSecret, 0, len(transactionWrappers.Transactions))
+    for _, transactionWrapper := range transactionWrappers.Transactions {
+        result = append(result, openSecret(transactionWrapper, recipientPrivateKey))
+    }
+
+    return result, nil
+}
+
+func openSecret(transactionWrapper *entityApi.TransactionWrapper, recipientPrivateKey *X25519.PrivateKey) DecryptedSecret {
+    // Opens a secret with the recipient private key, the content being left nil if the key does not open it
+
+    decrypted := DecryptedSecret{
+        Status: transactionWrapper.Status,
+    }
+    if transactionWrapper.Transaction.NonceTime != nil {
+        decrypted.NonceTime = transactionWrapper.Transaction.NonceTime.Time
+    }
+
+    message, ok := transactionWrapper.Transaction.Message.(*certify.MsgCreateSecret)
+    if !ok {
+        decrypted.Err = fmt.Errorf("bad message type: %s", transactionWrapper.Transaction.Message.GetType())
+        return decrypted
+    }
+    secret, ok := message.Secret.(*certify.SecretV1)
+    if !ok {
+        decrypted.Err = fmt.Errorf("bad secret type: %s", message.Secret.GetType())
+        return decrypted
+    }
+    decrypted.Uuid = secret.CertificateUuid
+    decrypted.CompanyChainID = secret.CompanyChainID
+
+    if secret.Lock == nil || secret.Lock.Encryptor == nil || secret.Lock.Nonce == nil {
+        decrypted.Err = fmt.Errorf("incomplete secret lock")
+        return decrypted
+    }
+    decrypted.SenderFingerprint = Fingerprint(secret.Lock.Encryptor[:])
+    if content, ok := recipientPrivateKey.Open(secret.Lock.Content, secret.Lock.Encryptor, secret.Lock.Nonce); ok {
+        decrypted.Content = content
+        decrypted.File, decrypted.Err = OpenSecretEnvelope(content)
+    } else {
+        decrypted.Err = fmt.Errorf("authentication failed: the stored key does not match the recipient or the content was altered")
+    }
+    return decrypted
+}
+
+func (secHandler *SecretHandler) DecryptSecrets(recipientPrivKey string) ([]DecryptedSecret, error) {
+    // Retrieves the secrets attached to the struct's UUID and opens them with the recipient private key
+
+    transactionWrappers, err := secHandler.GetSecrets()
     if err != nil {
         return nil, err
     }
-    transactionStatusSecret, err := transactorSecret.SendSecretV1(secHandler.UuidText, secHandler.SenderPubKey, nonce, encryptedContent)
+    return OpenSecrets(transactionWrappers, recipientPrivKey)
+}
+
+func (secHandler *SecretHandler) RetrieveSecretDetails(recipientPrivKey string) ([]*TransactionSummary, string, error) {
+    // Retrieves the secrets attached to the struct's UUID and returns their decoded fields, opened with the
+    // recipient private key if there is one, and their indented JSON
+
+    transactionWrappers, err := secHandler.GetSecrets()
+    if err != nil {
+        return nil, "", err
+    }
+    summaries, err := DescribeSecrets(transactionWrappers, recipientPrivKey, secHandler.Config.ChainID)
     if err != nil {
-        return nil, err
+        return nil, "", err
+    }
+    raw, err := RawJSON(transactionWrappers)
+    if err != nil {
+        return nil, "", err
+    }
+    return summaries, raw, nil
+}
+
+func (secHandler *SecretHandler) RetrieveDecryptedSecrets(recipientPrivKey string) (string, error) {
+    // Retrieves and opens the secrets attached to the struct's UUID and returns a readable listing of their plaintext
+
+    decryptedSecrets, err := secHandler.DecryptSecrets(recipientPrivKey)
+    if err != nil {
+        return "", err
+    }
+    if len(decryptedSecrets) == 0 {
+        return "No secret found for this UUID", nil
+    }
+
+    var builder strings.Builder
+    for i, decrypted := range decryptedSecrets {
+        builder.WriteString(fmt.Sprintf("Secret %d/%d\n", i+1, len(decryptedSecrets)))
+        builder.WriteString("Nonce time : " + decrypted.NonceTime.Local().Format(DisplayTimeFormat) + "\n")
+        if decrypted.Status != nil {
+            builder.WriteString(fmt.Sprintf("Transaction code : %d\nTransaction message : %s\n", decrypted.Status.Code, decrypted.Status.Message))
+        }
+        if decrypted.Err != nil {
+            builder.WriteString("Error : " + decrypted.Err.Error() + "\n\n")
+            continue
+        }
+        builder.WriteString("Content :\n" + decrypted.Text() + "\n\n")
     }
 
-    return transactionStatusSecret, nil
+    return builder.String(), nil
 }
@@ -0,0 +1,625 @@
+package libs
+
+import (
+    "crypto/sha256"
+    "database/sql"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// Changes recorded in the audit log
+const (
+    AuditExisting = "existing"
+    AuditInsert   = "insert"
+    AuditUpdate   = "update"
+    AuditDelete   = "delete"
+    // Marker switching the following entries to the row hash layout held by its key
+    AuditLayout = "layout"
+)
+
+// Table name of the layout markers in the audit log
+const auditLogTable = "auditLog"
+
+// Row hash layout of the new audit entries, increased by a migration whenever columns are appended to an audited
+// table, a table joins the audit log or an audited column is renamed
+const latestAuditLayout = 4
+
+// Signer of the certificates anchoring the audit log on chain
+const AuditAnchorSigner = "transactor-ui audit log"
+
+// Default delay between two automatic anchors of the audit log
+const DefaultAnchorInterval = time.Hour
+
+// Table whose rows are chained in the audit log. The columns are hashed in this order and never change once
+// released : a later column is appended by a new row hash layout, the entries keeping the layout they were
+// written with.
+type auditedTable struct {
+    name    string
+    key     string
+    columns []string
+    // Columns appended to the row hash by the later layouts, keyed by layout
+    appended map[int][]string
+    // Layout the table joined the audit log with
+    since int
+}
+
+var auditedTables = []auditedTable{
+    {"certificates", "uuid", []string{"uuid", "signature", "signer", "status", "requestDigest", "profile", "createdAt", "updatedAt"},
+        map[int][]string{2: {"companyChainID"}}, 1},
+    {"secrets", "uuid", []string{"uuid", "recipientPrivateKey", "status", "requestDigest", "profile", "createdAt", "updatedAt"},
+        map[int][]string{2: {"companyChainID"}}, 1},
+    {"transactions", "id", []string{"id", "profile", "chainID", "apiUrl", "messageType", "kind", "uuid", "companyChainID",
+        "nonceTime", "signedBytes", "requestDigest", "code", "message", "status", "createdAt", "updatedAt"}, nil, 1},
+    {"contacts", "name", []string{"name", "publicKey", "fingerprint", "companyChainID", "notes", "createdAt", "updatedAt"}, nil, 1},
+    {"secretGroups", "uuid", []string{"uuid", "profile", "createdAt"}, nil, 1},
+    {"secretGroupMembers", "secretUuid", []string{"secretUuid", "groupUuid", "contactName", "fingerprint", "result", "reason",
+        "createdAt", "updatedAt"}, nil, 1},
+    {"inboxSources", "source", []string{"source", "companyChainID", "uuid", "createdAt"}, nil, 1},
+    {"certificateFiles", "uuid", []string{"uuid", "name", "size", "path", "algorithm", "digest"}, nil, 3},
+    {"importResults", "id", []string{"id", "file", "line", "uuid", "result", "reason", "code", "message", "requestDigest", "createdAt"}, nil, 3},
+    {"keys", "name", []string{"name", "type", "publicKey", "privateKey"}, nil, 3},
+    {"auditAnchors", "uuid", []string{"uuid", "seq", "hash", "companyChainID", "requestDigest", "profile", "createdAt"}, nil, 3},
+}
+
+// Audited columns renamed by a layout, selected under their former name for the earlier layouts the migrations
+// write before the rename. The values and so the row hashes are unchanged.
+var renamedAuditColumns = map[string]struct {
+    former string
+    layout int
+}{
+    "requestDigest": {"txHash", 4},
+}
+
+// Serializes the appends to the audit log, each one reading the hash of the previous entry
+var auditMutex sync.Mutex
+
+// Entry of the audit log, whose hash covers the previous entry's hash and the content of the changed row
+type AuditEntry struct {
+    Seq       int64  `json:"seq"`
+    Table     string `json:"table"`
+    Key       string `json:"key"`
+    Action    string `json:"action"`
+    RowHash   string `json:"row_hash"`
+    PrevHash  string `json:"prev_hash"`
+    Hash      string `json:"hash"`
+    CreatedAt string `json:"created_at"`
+    // Row hash layout, given by the last marker before the entry
+    layout int
+}
+
+// Head of the audit log certified on chain
+type AuditAnchor struct {
+    Seq            int64  `json:"seq"`
+    Hash           string `json:"hash"`
+    Uuid           string `json:"uuid"`
+    CompanyChainID string `json:"company_chain_id"`
+    RequestDigest  string `json:"request_digest"`
+    Profile        string `json:"profile"`
+    CreatedAt      string `json:"created_at"`
+}
+
+type AuditProblem struct {
+    // Audit entry the problem was found at, 0 for a row the log does not know
+    Seq    int64  `json:"seq"`
+    Table  string `json:"table,omitempty"`
+    Key    string `json:"key,omitempty"`
+    Reason string `json:"reason"`
+}
+
+type AuditReport struct {
+    Entries  int64          `json:"entries"`
+    Head     string         `json:"head"`
+    Rows     int            `json:"rows"`
+    Anchors  int            `json:"anchors"`
+    Problems []AuditProblem `json:"problems"`
+}
+
+func auditTable(name string) (*auditedTable, error) {
+    for i := range auditedTables {
+        if auditedTables[i].name == name {
+            return &auditedTables[i], nil
+        }
+    }
+    return nil, fmt.Errorf("table %s is not audited", name)
+}
+
+func (table *auditedTable) layoutColumns(layout int) []string {
+    // Returns the columns hashed by a layout, the ones appended by a later layout coming after the earlier ones
+
+    columns := table.columns
+    for version := 2; version <= layout; version++ {
+        columns = append(columns[:len(columns):len(columns)], table.appended[version]...)
+    }
+    return columns
+}
+
+func (table *auditedTable) selectColumns(layout int) string {
+    columns := make([]string, 0, len(table.columns))
+    for _, column := range table.layoutColumns(layout) {
+        if renamed, ok := renamedAuditColumns[column]; ok && layout < renamed.layout {
+            column = renamed.former
+        }
+        columns = append(columns, column)
+    }
+    return "SELECT " + strings.Join(columns, ", ") + " FROM " + table.name
+}
+
+func scanRow(rows interface{ Scan(...interface{}) error }, count int) ([]sql.NullString, error) {
+    // Reads the audited columns of a row, its key being the first one
+
+    values := make([]sql.NullString, count)
+    pointers := make([]interface{}, count)
+    for i := range values {
+        pointers[i] = &values[i]
+    }
+    return values, rows.Scan(pointers...)
+}
+
+func rowHash(values []sql.NullString) (string, error) {
+    // Returns the hash of the audited values of a row
+
+    canonical := make([]*string, len(values))
+    for i, value := range values {
+        if value.Valid {
+            canonical[i] = &values[i].String
+        }
+    }
+    data, err := json.Marshal(canonical)
+    if err != nil {
+        return "", err
+    }
+    hash := sha256.Sum256(data)
+    return hex.EncodeToString(hash[:]), nil
+}
+
+func (entry *AuditEntry) computeHash() string {
+    // Returns the hash chaining an entry to the previous one
+
+    hash := sha256.Sum256([]byte(strings.Join([]string{entry.PrevHash, strconv.FormatInt(entry.Seq, 10), entry.Table,
+        entry.Key, entry.Action, entry.RowHash, entry.CreatedAt}, "\n")))
+    return hex.EncodeToString(hash[:])
+}
+
+func appendAudit(transaction *sql.Tx, tableName string, key string, action string) error {
+    // Appends the current content of a row to the audit log, within the transaction that changed it
+
+    return appendAuditLayout(transaction, tableName, key, action, latestAuditLayout)
+}
+
+func appendAuditLayout(transaction *sql.Tx, tableName string, key string, action string, layout int) error {
+    // Appends the content of a row hashed with a given layout, the migrations writing to the audit log before the
+    // columns of the latest layout exist
+
+    table, err := auditTable(tableName)
+    if err != nil {
+        return err
+    }
+    entry := AuditEntry{Table: tableName, Key: key, Action: action, CreatedAt: now()}
+    if action != AuditDelete {
+        row := transaction.QueryRow(table.selectColumns(layout)+" WHERE "+table.key+" = ?", key)
+        values, err := scanRow(row, len(table.layoutColumns(layout)))
+        if err == nil {
+            entry.RowHash, err = rowHash(values)
+        }
+        if err != nil {
+            return fmt.Errorf("audit of %s %s: %s", tableName, key, err)
+        }
+    }
+    return insertAuditEntry(transaction, entry)
+}
+
+func insertAuditEntry(transaction *sql.Tx, entry AuditEntry) error {
+    // Chains an entry to the last one of the audit log
+
+    var seq sql.NullInt64
+    var prevHash sql.NullString
+    err := transaction.QueryRow("SELECT seq, hash FROM auditLog ORDER BY seq DESC LIMIT 1").Scan(&seq, &prevHash)
+    if err != nil && err != sql.ErrNoRows {
+        return err
+    }
+    entry.Seq, entry.PrevHash = seq.Int64+1, prevHash.String
+    entry.Hash = entry.computeHash()
+    _, err = transaction.Exec("INSERT INTO auditLog (seq, tableName, rowKey, action, rowHash, prevHash, hash, createdAt) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+        entry.Seq, entry.Table, entry.Key, entry.Action, entry.RowHash, entry.PrevHash, entry.Hash, entry.CreatedAt)
+    return err
+}
+
+func auditExistingRows(transaction *sql.Tx, layout int) error {
+    // Chains the rows written before their table joined the audit log with a layout, the first one starting the log
+
+    for _, table := range auditedTables {
+        if table.since != layout {
+            continue
+        }
+        // Tables created by a later migration start empty
+        var count int
+        err := transaction.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table.name).Scan(&count)
+        if err != nil {
+            return err
+        }
+        if count == 0 {
+            continue
+        }
+        rows, err := transaction.Query("SELECT " + table.key + " FROM " + table.name + " ORDER BY " + table.key)
+        if err != nil {
+            return err
+        }
+        var keys []string
+        for rows.Next() {
+            var key string
+            if err := rows.Scan(&key); err != nil {
+                _ = rows.Close()
+                return err
+            }
+            keys = append(keys, key)
+        }
+        _ = rows.Close()
+        for _, key := range keys {
+            if err := appendAuditLayout(transaction, table.name, key, AuditExisting, layout); err != nil {
+                return err
+            }
+        }
+    }
+    return nil
+}
+
+func auditLayoutChange(transaction *sql.Tx, layout int) error {
+    // Switches the audit log to a new row hash layout with a marker entry, then chains again under it the rows of
+    // the tables it appends columns to and chains the rows of the tables joining the log. Only the rows still
+    // matching their last entry are chained again, the other ones being reported as modified as before.
+
+    type rowKey struct {
+        table string
+        key   string
+    }
+    var current []rowKey
+    for _, table := range auditedTables {
+        if len(table.appended[layout]) == 0 {
+            continue
+        }
+        previousColumns := table.layoutColumns(layout - 1)
+        rows, err := transaction.Query(table.selectColumns(layout-1) + " ORDER BY " + table.key)
+        if err != nil {
+            return err
+        }
+        hashes := map[string]string{}
+        var keys []string
+        for rows.Next() {
+            values, err := scanRow(rows, len(previousColumns))
+            if err == nil {
+                hashes[values[0].String], err = rowHash(values)
+            }
+            if err != nil {
+                _ = rows.Close()
+                return err
+            }
+            keys = append(keys, values[0].String)
+        }
+        _ = rows.Close()
+        if err := rows.Err(); err != nil {
+            return err
+        }
+        for _, key := range keys {
+            var action, recorded string
+            err := transaction.QueryRow("SELECT action, rowHash FROM auditLog WHERE tableName = ? AND rowKey = ? ORDER BY seq DESC LIMIT 1",
+                table.name, key).Scan(&action, &recorded)
+            if err != nil && err != sql.ErrNoRows {
+                return err
+            }
+            if err == nil && action != AuditDelete && recorded == hashes[key] {
+                current = append(current, rowKey{table.name, key})
+            }
+        }
+    }
+
+    marker := AuditEntry{Table: auditLogTable, Key: strconv.Itoa(layout), Action: AuditLayout, CreatedAt: now()}
+    if err := insertAuditEntry(transaction, marker); err != nil {
+        return err
+    }
+    for _, row := range current {
+        if err := appendAuditLayout(transaction, row.table, row.key, AuditExisting, layout); err != nil {
+            return err
+        }
+    }
+    return auditExistingRows(transaction, layout)
+}
+
+func (dao *DatabaseDAO) auditedExec(table string, key string, action string, query string, args ...interface{}) (sql.Result, error) {
+    return auditedDbExec(dao.Db, table, key, action, query, args...)
+}
+
+func auditedDbExec(database *sql.DB, table string, key string, action string, query string, args ...interface{}) (sql.Result, error) {
+    // Changes a row and appends it to the audit log in the same transaction, an empty key standing for the id of
+    // an inserted row
+
+    auditMutex.Lock()
+    defer auditMutex.Unlock()
+    transaction, err := database.Begin()
+    if err != nil {
+        return nil, err
+    }
+    result, err := transaction.Exec(query, args...)
+    if err != nil {
+        _ = transaction.Rollback()
+        return nil, err
+    }
+    if count, _ := result.RowsAffected(); count > 0 {
+        if key == "" {
+            id, _ := result.LastInsertId()
+            key = strconv.FormatInt(id, 10)
+        }
+        if err := appendAudit(transaction, table, key, action); err != nil {
+            _ = transaction.Rollback()
+            return nil, err
+        }
+    }
+    return result, transaction.Commit()
+}
+
+func (dao *DatabaseDAO) AuditHead() (*AuditEntry, error) {
+    // Returns the last entry of the audit log, nil if it is empty
+
+    entry := &AuditEntry{}
+    err := dao.Db.QueryRow("SELECT seq, tableName, rowKey, action, rowHash, prevHash, hash, createdAt FROM auditLog ORDER BY seq DESC LIMIT 1").
+        Scan(&entry.Seq, &entry.Table, &entry.Key, &entry.Action, &entry.RowHash, &entry.PrevHash, &entry.Hash, &entry.CreatedAt)
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    return entry, err
+}
+
+func (dao *DatabaseDAO) ListAuditAnchors() ([]AuditAnchor, error) {
+    // Returns the anchors of the audit log, oldest first
+
+    rows, err := dao.Db.Query("SELECT seq, hash, uuid, companyChainID, requestDigest, profile, createdAt FROM auditAnchors ORDER BY seq")
+    if err != nil {
+        return nil, err
+    }
+    defer func() {
+        _ = rows.Close()
+    }()
+    var anchors []AuditAnchor
+    for rows.Next() {
+        var anchor AuditAnchor
+        if err := rows.Scan(&anchor.Seq, &anchor.Hash, &anchor.Uuid, &anchor.CompanyChainID, &anchor.RequestDigest, &anchor.Profile, &anchor.CreatedAt); err != nil {
+            return nil, err
+        }
+        anchors = append(anchors, anchor)
+    }
+    return anchors, rows.Err()
+}
+
+func (dao *DatabaseDAO) AnchorDue() (bool, error) {
+    // Indicates if the audit log has entries no anchor covers yet, besides the history of the anchors themselves
+
+    var seq sql.NullInt64
+    if err := dao.Db.QueryRow("SELECT MAX(seq) FROM auditAnchors").Scan(&seq); err != nil {
+        return false, err
+    }
+    var count int
+    err := dao.Db.QueryRow("SELECT COUNT(*) FROM auditLog WHERE seq > ? AND tableName != 'auditAnchors' AND NOT (tableName = 'transactions' "+
+        "AND rowKey IN (SELECT id FROM transactions WHERE uuid IN (SELECT uuid FROM auditAnchors)))", seq.Int64).Scan(&count)
+    return count > 0, err
+}
+
+func AnchorSignature(seq int64, hash string) string {
+    // Returns the signature of the certificate anchoring the audit log head
+
+    return "audit:" + strconv.FormatInt(seq, 10) + ":" + hash
+}
+
+func (dao *DatabaseDAO) AnchorAuditLog(config Config) (*AuditAnchor, error) {
+    // Certifies the head of the audit log on chain and records the anchor
+
+    head, err := dao.AuditHead()
+    if err != nil {
+        return nil, err
+    }
+    if head == nil {
+        return nil, fmt.Errorf("the audit log is empty")
+    }
+    anchor := &AuditAnchor{
+        Seq:            head.Seq,
+        Hash:           head.Hash,
+        Uuid:           uuid.New().String(),
+        CompanyChainID: config.CompanyChainID,
+        Profile:        config.Profile,
+    }
+    certHandler := CertificateHandler{
+        Config:        config,
+        UuidText:      anchor.Uuid,
+        SignatureText: AnchorSignature(anchor.Seq, anchor.Hash),
+        SignerText:    AuditAnchorSigner,
+    }
+    // A history error comes with the status, the anchor being recorded all the same
+    transactionStatus, historyErr := certHandler.SendCertificate()
+    if transactionStatus == nil {
+        return nil, historyErr
+    }
+    if transactionStatus.Code != 0 {
+        return nil, fmt.Errorf("anchor rejected: %s", transactionStatus.Message)
+    }
+    anchor.RequestDigest, anchor.CreatedAt = certHandler.Signed.Digest(), now()
+    _, err = dao.auditedExec("auditAnchors", anchor.Uuid, AuditInsert,
+        "INSERT INTO auditAnchors (seq, hash, uuid, companyChainID, requestDigest, profile, createdAt) VALUES (?, ?, ?, ?, ?, ?, ?)",
+        anchor.Seq, anchor.Hash, anchor.Uuid, anchor.CompanyChainID, anchor.RequestDigest, anchor.Profile, anchor.CreatedAt)
+    if err != nil {
+        return nil, fmt.Errorf("anchor %s sent but not recorded: %s", anchor.Uuid, err)
+    }
+    return anchor, historyErr
+}
+
+func (dao *DatabaseDAO) VerifyAuditLog() (*AuditReport, error) {
+    // Recomputes the audit log chain and compares the current rows with their last recorded content, the
+    // problems being sorted from the first entry they affect
+
+    report := &AuditReport{}
+    problem := func(seq int64, table string, key string, reason string) {
+        report.Problems = append(report.Problems, AuditProblem{seq, table, key, reason})
+    }
+
+    rows, err := dao.Db.Query("SELECT seq, tableName, rowKey, action, rowHash, prevHash, hash, createdAt FROM auditLog ORDER BY seq")
+    if err != nil {
+        return nil, err
+    }
+    latest := map[string]AuditEntry{}
+    hashes := map[int64]string{}
+    var previous AuditEntry
+    layout := 1
+    for rows.Next() {
+        var entry AuditEntry
+        if err := rows.Scan(&entry.Seq, &entry.Table, &entry.Key, &entry.Action, &entry.RowHash, &entry.PrevHash, &entry.Hash, &entry.CreatedAt); err != nil {
+            _ = rows.Close()
+            return nil, err
+        }
+        if entry.Seq != previous.Seq+1 {
+            if entry.Seq == previous.Seq+2 {
+                problem(previous.Seq+1, "", "", fmt.Sprintf("audit entry #%d was deleted", previous.Seq+1))
+            } else {
+                problem(previous.Seq+1, "", "", fmt.Sprintf("audit entries #%d to #%d were deleted", previous.Seq+1, entry.Seq-1))
+            }
+        } else if entry.PrevHash != previous.Hash {
+            problem(entry.Seq, entry.Table, entry.Key, "the chain is broken: the previous hash does not match audit entry #"+strconv.FormatInt(previous.Seq, 10))
+        }
+        if entry.computeHash() != entry.Hash {
+            problem(entry.Seq, entry.Table, entry.Key, "audit entry was modified")
+        }
+        if entry.Action == AuditLayout {
+            if next, err := strconv.Atoi(entry.Key); err != nil || next <= layout || next > latestAuditLayout {
+                problem(entry.Seq, "", "", "invalid row hash layout marker: "+entry.Key)
+            } else {
+                layout = next
+            }
+        } else {
+            entry.layout = layout
+            latest[entry.Table+"\x00"+entry.Key] = entry
+        }
+        hashes[entry.Seq] = entry.Hash
+        report.Entries++
+        report.Head = entry.Hash
+        previous = entry
+    }
+    _ = rows.Close()
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+
+    for _, table := range auditedTables {
+        // Every layout hashes a prefix of the latest columns
+        rows, err := dao.Db.Query(table.selectColumns(latestAuditLayout))
+        if err != nil {
+            return nil, err
+        }
+        for rows.Next() {
+            values, err := scanRow(rows, len(table.layoutColumns(latestAuditLayout)))
+            if err != nil {
+                _ = rows.Close()
+                return nil, err
+            }
+            key := values[0].String
+            report.Rows++
+            entry, ok := latest[table.name+"\x00"+key]
+            delete(latest, table.name+"\x00"+key)
+            if !ok || entry.Action == AuditDelete {
+                problem(0, table.name, key, "row added outside the application")
+                continue
+            }
+            hash, err := rowHash(values[:len(table.layoutColumns(entry.layout))])
+            if err != nil {
+                _ = rows.Close()
+                return nil, err
+            }
+            if entry.RowHash != hash {
+                problem(entry.Seq, table.name, key, "row modified after audit entry #"+strconv.FormatInt(entry.Seq, 10))
+            }
+        }
+        _ = rows.Close()
+        if err := rows.Err(); err != nil {
+            return nil, err
+        }
+    }
+    for _, entry := range latest {
+        if entry.Action != AuditDelete {
+            problem(entry.Seq, entry.Table, entry.Key, "row deleted after audit entry #"+strconv.FormatInt(entry.Seq, 10))
+        }
+    }
+
+    anchors, err := dao.ListAuditAnchors()
+    if err != nil {
+        return nil, err
+    }
+    report.Anchors = len(anchors)
+    for _, anchor := range anchors {
+        hash, ok := hashes[anchor.Seq]
+        switch {
+        case anchor.Seq > previous.Seq:
+            // The log is shorter than its anchors : its last entries were deleted
+            problem(previous.Seq+1, "", "", fmt.Sprintf("the audit log ends at entry #%d, before entry #%d anchored by %s",
+                previous.Seq, anchor.Seq, anchor.Uuid))
+        case !ok:
+            problem(anchor.Seq, "", "", fmt.Sprintf("audit entry #%d anchored by %s is missing", anchor.Seq, anchor.Uuid))
+        case hash != anchor.Hash:
+            problem(anchor.Seq, "", "", fmt.Sprintf("audit entry #%d differs from its anchor %s", anchor.Seq, anchor.Uuid))
+        }
+    }
+
+    sort.SliceStable(report.Problems, func(i, j int) bool {
+        // The rows the log does not know come last
+        first, second := report.Problems[i].Seq, report.Problems[j].Seq
+        if first == 0 || second == 0 {
+            return second == 0 && first != 0
+        }
+        return first < second
+    })
+    return report, nil
+}
+
+func (dao *DatabaseDAO) VerifyAuditAnchors(config Config) ([]AuditProblem, error) {
+    // Compares the anchors recorded in the database with the certificates retrieved from the chain
+
+    anchors, err := dao.ListAuditAnchors()
+    if err != nil {
+        return nil, err
+    }
+    var problems []AuditProblem
+    for _, anchor := range anchors {
+        anchorConfig := config
+        anchorConfig.CompanyChainID = anchor.CompanyChainID
+        certHandler := CertificateHandler{Config: anchorConfig, UuidText: anchor.Uuid}
+        transactionWrapper, err := certHandler.GetCertificate()
+        if err != nil {
+            problems = append(problems, AuditProblem{Seq: anchor.Seq, Reason: fmt.Sprintf("anchor %s cannot be retrieved: %s", anchor.Uuid, err)})
+            continue
+        }
+        certificate, err := CertificateOf(transactionWrapper.Transaction)
+        if err != nil || certificate.Seal == nil || string(certificate.Seal.Signature) != AnchorSignature(anchor.Seq, anchor.Hash) {
+            problems = append(problems, AuditProblem{Seq: anchor.Seq, Reason: fmt.Sprintf("anchor %s on chain does not match audit entry #%d", anchor.Uuid, anchor.Seq)})
+        }
+    }
+    return problems, nil
+}
+
+func (problem AuditProblem) String() string {
+    if problem.Table == "" {
+        return problem.Reason
+    }
+    return problem.Table + " " + problem.Key + ": " + problem.Reason
+}
+
+func (report *AuditReport) Summary() string {
+    // Returns the number of entries checked and the first problem found
+
+    summary := fmt.Sprintf("%d audit entries, %d rows, %d anchors checked", report.Entries, report.Rows, report.Anchors)
+    if len(report.Problems) == 0 {
+        return summary + ", no tampering found"
+    }
+    return summary + fmt.Sprintf(", %d problems, the first one: %s", len(report.Problems), report.Problems[0].String())
+}
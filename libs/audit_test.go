@@ -0,0 +1,213 @@
+package libs
+
+import (
+    "database/sql"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/katena-chain/transactor-ui/libs/testutil"
+)
+
+func openTestDatabaseAt(t *testing.T, version int) (*DatabaseDAO, func()) {
+    // Opens a database in a temporary directory migrated up to a given schema version
+
+    directory, removeDirectory := testutil.TempDir(t)
+    database, err := sql.Open("sqlite3", filepath.Join(directory, "test.db"))
+    if err != nil {
+        removeDirectory()
+        t.Fatal(err)
+    }
+    cleanup := func() {
+        _ = database.Close()
+        removeDirectory()
+    }
+    released := migrations
+    for i := range migrations {
+        if migrations[i].version == version {
+            migrations = migrations[:i+1]
+            break
+        }
+    }
+    err = Migrate(database)
+    migrations = released
+    if err != nil {
+        cleanup()
+        t.Fatal(err)
+    }
+    return &DatabaseDAO{Db: database, Keystore: &Keystore{Db: database}}, cleanup
+}
+
+func auditProblems(t *testing.T, dao *DatabaseDAO) []string {
+    report, err := dao.VerifyAuditLog()
+    if err != nil {
+        t.Fatal(err)
+    }
+    problems := make([]string, len(report.Problems))
+    for i, problem := range report.Problems {
+        problems[i] = problem.String()
+    }
+    return problems
+}
+
+func TestAuditCoversCompanyChainID(t *testing.T) {
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+    if err := dao.AddCertificateEntry(testutil.Uuid, "signature", "signer", "", "default"); err != nil {
+        t.Fatal(err)
+    }
+    if problems := auditProblems(t, dao); len(problems) != 0 {
+        t.Fatalf("unexpected problems: %v", problems)
+    }
+    if _, err := dao.Db.Exec("UPDATE certificates SET companyChainID = 'other' WHERE uuid = ?", testutil.Uuid); err != nil {
+        t.Fatal(err)
+    }
+    if problems := auditProblems(t, dao); len(problems) != 1 || !strings.Contains(problems[0], "row modified") {
+        t.Fatalf("company change not reported: %v", problems)
+    }
+}
+
+func TestAuditLayoutUpgrade(t *testing.T) {
+    // A log written with the first layout keeps verifying once upgraded, the rows it chains again being covered
+    // by the new columns and the ones modified before the upgrade still being reported
+
+    dao, cleanup := openTestDatabaseAt(t, 12)
+    defer cleanup()
+    transaction, err := dao.Db.Begin()
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, uuid := range []string{testutil.Uuid, testutil.OtherUuid} {
+        _, err := transaction.Exec("INSERT INTO certificates (uuid, signature, signer, status, txHash, profile, createdAt, updatedAt) "+
+            "VALUES (?, 'signature', 'signer', '', '', 'default', '', '')", uuid)
+        if err == nil {
+            err = appendAuditLayout(transaction, "certificates", uuid, AuditInsert, 1)
+        }
+        if err != nil {
+            _ = transaction.Rollback()
+            t.Fatal(err)
+        }
+    }
+    if err := transaction.Commit(); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := dao.Db.Exec("UPDATE certificates SET signer = 'forged' WHERE uuid = ?", testutil.OtherUuid); err != nil {
+        t.Fatal(err)
+    }
+
+    if err := Migrate(dao.Db); err != nil {
+        t.Fatal(err)
+    }
+    var markers int
+    if err := dao.Db.QueryRow("SELECT COUNT(*) FROM auditLog WHERE action = ?", AuditLayout).Scan(&markers); err != nil || markers != latestAuditLayout-1 {
+        t.Fatalf("unexpected layout markers: %d, %v", markers, err)
+    }
+    problems := auditProblems(t, dao)
+    if len(problems) != 1 || !strings.HasPrefix(problems[0], "certificates "+testutil.OtherUuid+": row modified") {
+        t.Fatalf("unexpected problems after the upgrade: %v", problems)
+    }
+
+    if _, err := dao.Db.Exec("UPDATE certificates SET companyChainID = 'other' WHERE uuid = ?", testutil.Uuid); err != nil {
+        t.Fatal(err)
+    }
+    if problems := auditProblems(t, dao); len(problems) != 2 {
+        t.Fatalf("company change not reported after the upgrade: %v", problems)
+    }
+}
+
+func TestAuditCoversFilesKeysAndImports(t *testing.T) {
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+    if err := dao.AddCertificateEntry(testutil.Uuid, "signature", "signer", "", "default"); err != nil {
+        t.Fatal(err)
+    }
+    fileDigest := &FileDigest{Name: "file.txt", Size: 4, Path: "file.txt", Algorithm: "SHA-256", Digest: "digest"}
+    if err := dao.AddCertificateFile(testutil.Uuid, fileDigest); err != nil {
+        t.Fatal(err)
+    }
+    _, privateKey, err := GenerateKeyPair(KeyTypeX25519)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := dao.Keystore.AddKey("recipient", KeyTypeX25519, privateKey); err != nil {
+        t.Fatal(err)
+    }
+    plan := &ImportPlan{Rows: []*ImportResult{{ImportRow: ImportRow{Line: 2, Uuid: testutil.OtherUuid}, Result: "failed", Reason: "test"}}}
+    if err := dao.RecordImport("import.csv", plan); err != nil {
+        t.Fatal(err)
+    }
+    if problems := auditProblems(t, dao); len(problems) != 0 {
+        t.Fatalf("unexpected problems: %v", problems)
+    }
+
+    for _, statement := range []string{
+        "UPDATE certificateFiles SET digest = 'forged'",
+        "UPDATE importResults SET result = 'forged'",
+        "INSERT INTO keys VALUES ('forged', 'X25519', '', '')",
+    } {
+        if _, err := dao.Db.Exec(statement); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if problems := auditProblems(t, dao); len(problems) != 3 {
+        t.Fatalf("unexpected problems: %v", problems)
+    }
+
+    // The removals through the application are chained
+    if _, err := dao.Db.Exec("DELETE FROM keys WHERE name = 'forged'"); err != nil {
+        t.Fatal(err)
+    }
+    if err := dao.RemoveCertificate(testutil.Uuid); err != nil {
+        t.Fatal(err)
+    }
+    if err := dao.Keystore.RemoveKey("recipient"); err != nil {
+        t.Fatal(err)
+    }
+    if problems := auditProblems(t, dao); len(problems) != 1 || !strings.HasPrefix(problems[0], "importResults") {
+        t.Fatalf("unexpected problems: %v", problems)
+    }
+}
+
+func TestAuditAnchors(t *testing.T) {
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+    if err := dao.AddCertificateEntry(testutil.Uuid, "signature", "signer", "", "default"); err != nil {
+        t.Fatal(err)
+    }
+    head, err := dao.AuditHead()
+    if err != nil {
+        t.Fatal(err)
+    }
+    _, err = dao.auditedExec("auditAnchors", testutil.OtherUuid, AuditInsert,
+        "INSERT INTO auditAnchors (seq, hash, uuid, companyChainID, requestDigest, profile, createdAt) VALUES (?, ?, ?, ?, '', 'default', ?)",
+        head.Seq, head.Hash, testutil.OtherUuid, testutil.CompanyChainID, now())
+    if err != nil {
+        t.Fatal(err)
+    }
+    if problems := auditProblems(t, dao); len(problems) != 0 {
+        t.Fatalf("unexpected problems: %v", problems)
+    }
+    if due, err := dao.AnchorDue(); err != nil || due {
+        t.Fatalf("anchor due after recording an anchor: %v, %v", due, err)
+    }
+
+    // Changing an anchor is reported both by its row and against the log
+    if _, err := dao.Db.Exec("UPDATE auditAnchors SET hash = 'forged'"); err != nil {
+        t.Fatal(err)
+    }
+    if problems := auditProblems(t, dao); len(problems) != 2 {
+        t.Fatalf("unexpected problems: %v", problems)
+    }
+    if _, err := dao.Db.Exec("UPDATE auditAnchors SET hash = ?", head.Hash); err != nil {
+        t.Fatal(err)
+    }
+
+    // Truncating the log from the anchored entry on leaves it shorter than its anchor
+    if _, err := dao.Db.Exec("DELETE FROM auditLog WHERE seq >= ?", head.Seq); err != nil {
+        t.Fatal(err)
+    }
+    problems := auditProblems(t, dao)
+    if len(problems) == 0 || !strings.Contains(problems[0], "the audit log ends at entry") {
+        t.Fatalf("truncated log not reported: %v", problems)
+    }
+}
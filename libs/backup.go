@@ -0,0 +1,589 @@
+package libs
+
+import (
+    "crypto/rand"
+    "crypto/subtle"
+    "database/sql"
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+
+    "golang.org/x/crypto/argon2"
+    "golang.org/x/crypto/chacha20poly1305"
+)
+
+// Identifies backup files, the version being increased whenever their layout changes
+const BackupFormat = "transactor-ui-backup"
+const BackupVersion = 2
+
+// Key derivation of the backups encrypted with a passphrase
+const backupKdf = "argon2id"
+
+// Bounds of the key derivation parameters read from a file, a tampered one asking for an unbounded amount of memory
+// or time otherwise. The memory is in KiB, at most four times the 64 MiB the files are written with, the threads
+// being bounded to 255 by their type.
+const (
+    maxKdfTime   = 64
+    maxKdfMemory = 4 * kdfMemory
+)
+
+// Kind of the keystore keys in a backup report, next to EntryCertificate and EntrySecret
+const EntryKey = "key"
+
+// Outcomes of an entry of a merged backup
+const (
+    BackupAdded    = "added"
+    BackupSkipped  = "skipped"
+    BackupConflict = "conflict"
+)
+
+type BackupEncryption struct {
+    Kdf     string `json:"kdf"`
+    Time    uint32 `json:"time"`
+    Memory  uint32 `json:"memory"`
+    Threads uint8  `json:"threads"`
+    Salt    string `json:"salt"`
+    // Known value encrypted under the backup key to check the passphrase
+    Check string `json:"check"`
+}
+
+type BackupCertificate struct {
+    Uuid          string      `json:"uuid"`
+    Signature     string      `json:"signature"`
+    Signer        string      `json:"signer"`
+    Status        string      `json:"status"`
+    RequestDigest string      `json:"request_digest"`
+    Profile       string      `json:"profile"`
+    CreatedAt     string      `json:"created_at"`
+    UpdatedAt     string      `json:"updated_at"`
+    File          *FileDigest `json:"file,omitempty"`
+    // Company of a tracked certificate, empty for the ones sent by the configured company
+    CompanyChainID string `json:"company_chain_id,omitempty"`
+    // Request digest of a version 1 backup, moved to RequestDigest when it is read
+    TxHash string `json:"tx_hash,omitempty"`
+}
+
+type BackupSecret struct {
+    Uuid string `json:"uuid"`
+    // In clear, or encrypted under the backup key if the backup has a passphrase
+    RecipientPrivateKey string `json:"recipient_private_key"`
+    Status              string `json:"status"`
+    RequestDigest       string `json:"request_digest"`
+    Profile             string `json:"profile"`
+    CreatedAt           string `json:"created_at"`
+    UpdatedAt           string `json:"updated_at"`
+    CompanyChainID      string `json:"company_chain_id,omitempty"`
+    // Request digest of a version 1 backup, moved to RequestDigest when it is read
+    TxHash string `json:"tx_hash,omitempty"`
+}
+
+type BackupKey struct {
+    Name      string `json:"name"`
+    Type      string `json:"type"`
+    PublicKey string `json:"public_key"`
+    // In clear, or encrypted under the backup key if the backup has a passphrase
+    PrivateKey string `json:"private_key"`
+}
+
+type Backup struct {
+    Format        string              `json:"format"`
+    Version       int                 `json:"version"`
+    SchemaVersion int                 `json:"schema_version"`
+    CreatedAt     string              `json:"created_at"`
+    Encryption    *BackupEncryption   `json:"encryption,omitempty"`
+    Certificates  []BackupCertificate `json:"certificates"`
+    Secrets       []BackupSecret      `json:"secrets"`
+    Keys          []BackupKey         `json:"keys"`
+    Contacts      []Contact           `json:"contacts"`
+}
+
+type BackupReportEntry struct {
+    Kind   string `json:"kind"`
+    Id     string `json:"id"`
+    Result string `json:"result"`
+    Reason string `json:"reason,omitempty"`
+}
+
+type BackupReport struct {
+    Entries []BackupReportEntry
+}
+
+func newBackupEncryption(passphrase string) (*BackupEncryption, *Keystore, error) {
+    // Derives a new backup key from a passphrase, returned as a keystore not bound to any database
+
+    if passphrase == "" {
+        return nil, nil, fmt.Errorf("empty passphrase")
+    }
+    salt := make([]byte, kdfSaltLen)
+    if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+        return nil, nil, err
+    }
+    backupKeystore := &Keystore{key: deriveKey(passphrase, salt)}
+    check, err := backupKeystore.Encrypt([]byte(checkValue))
+    if err != nil {
+        return nil, nil, err
+    }
+    encryption := &BackupEncryption{
+        Kdf:     backupKdf,
+        Time:    kdfTime,
+        Memory:  kdfMemory,
+        Threads: kdfThreads,
+        Salt:    base64.StdEncoding.EncodeToString(salt),
+        Check:   check,
+    }
+    return encryption, backupKeystore, nil
+}
+
+func (encryption *BackupEncryption) check() error {
+    // Checks the key derivation parameters read from a file before anything is derived from them
+
+    if encryption.Kdf != backupKdf {
+        return fmt.Errorf("unknown key derivation: %s", encryption.Kdf)
+    }
+    if encryption.Time < 1 || encryption.Time > maxKdfTime {
+        return fmt.Errorf("invalid key derivation time: %d", encryption.Time)
+    }
+    if encryption.Memory > maxKdfMemory {
+        return fmt.Errorf("invalid key derivation memory: %d KiB", encryption.Memory)
+    }
+    if encryption.Threads < 1 {
+        return fmt.Errorf("invalid key derivation threads: %d", encryption.Threads)
+    }
+    return nil
+}
+
+func (encryption *BackupEncryption) open(passphrase string) (*Keystore, error) {
+    // Derives the key of an encrypted backup and checks the passphrase
+
+    if err := encryption.check(); err != nil {
+        return nil, fmt.Errorf("invalid backup encryption: %s", err)
+    }
+    if passphrase == "" {
+        return nil, fmt.Errorf("the backup is encrypted, its passphrase is needed")
+    }
+    salt, err := base64.StdEncoding.DecodeString(encryption.Salt)
+    if err != nil {
+        return nil, fmt.Errorf("invalid backup salt: %s", err)
+    }
+    // The parameters are read from the file so that changing the defaults keeps older backups readable
+    key := argon2.IDKey([]byte(passphrase), salt, encryption.Time, encryption.Memory, encryption.Threads, chacha20poly1305.KeySize)
+    backupKeystore := &Keystore{key: key}
+    plainCheck, err := backupKeystore.Decrypt(encryption.Check)
+    if err != nil || subtle.ConstantTimeCompare(plainCheck, []byte(checkValue)) != 1 {
+        return nil, fmt.Errorf("wrong backup passphrase")
+    }
+    return backupKeystore, nil
+}
+
+func (dao *DatabaseDAO) HasKeyMaterial() (bool, error) {
+    // Indicates if the database holds secret recipient keys or keystore keys
+
+    var count int
+    err := dao.Db.QueryRow("SELECT (SELECT COUNT(*) FROM secrets) + (SELECT COUNT(*) FROM keys)").Scan(&count)
+    return count > 0, err
+}
+
+func (dao *DatabaseDAO) ExportBackup(passphrase string, withKeys bool) (*Backup, error) {
+    // Gathers the certificates, secrets, contacts and optionally the keystore keys of the database, the key material
+    // being decrypted from the keystore and encrypted again under the passphrase if one is given
+
+    schemaVersion, err := SchemaVersion(dao.Db)
+    if err != nil {
+        return nil, err
+    }
+    backup := &Backup{
+        Format:        BackupFormat,
+        Version:       BackupVersion,
+        SchemaVersion: schemaVersion,
+        CreatedAt:     now(),
+        Certificates:  []BackupCertificate{},
+        Secrets:       []BackupSecret{},
+        Keys:          []BackupKey{},
+        Contacts:      []Contact{},
+    }
+    var backupKeystore *Keystore
+    if passphrase != "" {
+        if backup.Encryption, backupKeystore, err = newBackupEncryption(passphrase); err != nil {
+            return nil, err
+        }
+        defer backupKeystore.Lock()
+    }
+    seal := func(plaintext string) (string, error) {
+        if backupKeystore == nil {
+            return plaintext, nil
+        }
+        return backupKeystore.Encrypt([]byte(plaintext))
+    }
+
+    if backup.Certificates, err = dao.exportCertificates(); err != nil {
+        return nil, err
+    }
+    if backup.Contacts, err = dao.ListContacts(""); err != nil {
+        return nil, err
+    }
+
+    secrets, err := dao.exportSecrets()
+    if err != nil {
+        return nil, err
+    }
+    for i := range secrets {
+        privateKey, err := dao.GetSecretDecryptingKey(secrets[i].Uuid)
+        if err != nil {
+            return nil, fmt.Errorf("secret %s: %s", secrets[i].Uuid, err)
+        }
+        if secrets[i].RecipientPrivateKey, err = seal(privateKey); err != nil {
+            return nil, err
+        }
+    }
+    backup.Secrets = secrets
+
+    if !withKeys {
+        return backup, nil
+    }
+    entries, err := dao.Keystore.ListKeys("")
+    if err != nil {
+        return nil, err
+    }
+    for _, entry := range entries {
+        _, privateKey, err := dao.Keystore.GetKey(entry.Name)
+        if err != nil {
+            return nil, fmt.Errorf("key %s: %s", entry.Name, err)
+        }
+        sealedKey, err := seal(privateKey)
+        if err != nil {
+            return nil, err
+        }
+        backup.Keys = append(backup.Keys, BackupKey{Name: entry.Name, Type: entry.Type, PublicKey: entry.PublicKey, PrivateKey: sealedKey})
+    }
+    return backup, nil
+}
+
+func (dao *DatabaseDAO) exportCertificates() ([]BackupCertificate, error) {
+    // Returns every certificate with the file it was built from, if any
+
+    rows, err := dao.Db.Query("SELECT certificates.uuid, signature, signer, status, requestDigest, profile, createdAt, updatedAt, companyChainID, " +
+        "name, size, path, algorithm, digest FROM certificates LEFT JOIN certificateFiles ON certificateFiles.uuid = certificates.uuid " +
+        "ORDER BY createdAt, certificates.uuid")
+    if err != nil {
+        return nil, err
+    }
+    defer func() {
+        _ = rows.Close()
+    }()
+
+    certificates := []BackupCertificate{}
+    for rows.Next() {
+        var certificate BackupCertificate
+        var name, path, algorithm, digest sql.NullString
+        var size sql.NullInt64
+        err := rows.Scan(&certificate.Uuid, &certificate.Signature, &certificate.Signer, &certificate.Status, &certificate.RequestDigest,
+            &certificate.Profile, &certificate.CreatedAt, &certificate.UpdatedAt, &certificate.CompanyChainID, &name, &size, &path,
+            &algorithm, &digest)
+        if err != nil {
+            return nil, err
+        }
+        if digest.Valid {
+            certificate.File = &FileDigest{Name: name.String, Size: size.Int64, Path: path.String, Algorithm: algorithm.String, Digest: digest.String}
+        }
+        certificates = append(certificates, certificate)
+    }
+    return certificates, rows.Err()
+}
+
+func (dao *DatabaseDAO) exportSecrets() ([]BackupSecret, error) {
+    // Returns every secret, without its recipient key
+
+    rows, err := dao.Db.Query("SELECT uuid, status, requestDigest, profile, createdAt, updatedAt, companyChainID FROM secrets ORDER BY createdAt, uuid")
+    if err != nil {
+        return nil, err
+    }
+    defer func() {
+        _ = rows.Close()
+    }()
+
+    secrets := []BackupSecret{}
+    for rows.Next() {
+        var secret BackupSecret
+        err := rows.Scan(&secret.Uuid, &secret.Status, &secret.RequestDigest, &secret.Profile, &secret.CreatedAt, &secret.UpdatedAt, &secret.CompanyChainID)
+        if err != nil {
+            return nil, err
+        }
+        secrets = append(secrets, secret)
+    }
+    return secrets, rows.Err()
+}
+
+func (backup *Backup) Write(writer io.Writer) error {
+    // Writes the indented JSON representation of a backup
+
+    encoder := json.NewEncoder(writer)
+    encoder.SetIndent("", "    ")
+    return encoder.Encode(backup)
+}
+
+func ReadBackup(reader io.Reader) (*Backup, error) {
+    // Reads a backup and checks it can be understood by this version of the application
+
+    backup := &Backup{}
+    if err := json.NewDecoder(reader).Decode(backup); err != nil {
+        return nil, fmt.Errorf("invalid backup: %s", err)
+    }
+    if backup.Format != BackupFormat {
+        return nil, fmt.Errorf("not a transactor-ui backup")
+    }
+    if backup.Version < 1 || backup.Version > BackupVersion {
+        return nil, fmt.Errorf("backup version %d is not supported by this version of the application (%d)", backup.Version, BackupVersion)
+    }
+    // Version 1 named the request digest a transaction hash
+    if backup.Version < 2 {
+        for i := range backup.Certificates {
+            backup.Certificates[i].RequestDigest, backup.Certificates[i].TxHash = backup.Certificates[i].TxHash, ""
+        }
+        for i := range backup.Secrets {
+            backup.Secrets[i].RequestDigest, backup.Secrets[i].TxHash = backup.Secrets[i].TxHash, ""
+        }
+    }
+    return backup, nil
+}
+
+func (backup *Backup) HasKeyMaterial() bool {
+    return len(backup.Secrets)+len(backup.Keys) > 0
+}
+
+func (dao *DatabaseDAO) ImportBackup(backup *Backup, passphrase string, dryRun bool) (*BackupReport, error) {
+    // Merges a backup into the database : entries with a new UUID, key or contact name are added, identical ones
+    // are skipped and the ones differing from the database are reported as conflicts and left untouched. The key
+    // material is encrypted by the keystore, which must be unlocked. Nothing is written on a dry run.
+
+    var backupKeystore *Keystore
+    if backup.Encryption != nil {
+        var err error
+        if backupKeystore, err = backup.Encryption.open(passphrase); err != nil {
+            return nil, err
+        }
+        defer backupKeystore.Lock()
+    }
+    open := func(value string) (string, error) {
+        if backupKeystore == nil {
+            return value, nil
+        }
+        plaintext, err := backupKeystore.Decrypt(value)
+        return string(plaintext), err
+    }
+    if backup.HasKeyMaterial() && !dao.Keystore.Unlocked() {
+        return nil, fmt.Errorf("unlock the keystore to import the key material")
+    }
+
+    auditMutex.Lock()
+    defer auditMutex.Unlock()
+    transaction, err := dao.Db.Begin()
+    if err != nil {
+        return nil, err
+    }
+    report := &BackupReport{}
+    for _, certificate := range backup.Certificates {
+        entry, err := importBackupCertificate(transaction, certificate)
+        if err != nil {
+            _ = transaction.Rollback()
+            return nil, fmt.Errorf("certificate %s: %s", certificate.Uuid, err)
+        }
+        report.Entries = append(report.Entries, entry)
+    }
+    for _, secret := range backup.Secrets {
+        privateKey, err := open(secret.RecipientPrivateKey)
+        if err == nil {
+            secret.RecipientPrivateKey = privateKey
+            var entry BackupReportEntry
+            if entry, err = dao.importBackupSecret(transaction, secret); err == nil {
+                report.Entries = append(report.Entries, entry)
+            }
+        }
+        if err != nil {
+            _ = transaction.Rollback()
+            return nil, fmt.Errorf("secret %s: %s", secret.Uuid, err)
+        }
+    }
+    for _, key := range backup.Keys {
+        privateKey, err := open(key.PrivateKey)
+        if err == nil {
+            key.PrivateKey = privateKey
+            var entry BackupReportEntry
+            if entry, err = dao.importBackupKey(transaction, key); err == nil {
+                report.Entries = append(report.Entries, entry)
+            }
+        }
+        if err != nil {
+            _ = transaction.Rollback()
+            return nil, fmt.Errorf("key %s: %s", key.Name, err)
+        }
+    }
+    for _, contact := range backup.Contacts {
+        entry, err := importContact(transaction, contact)
+        if err != nil {
+            _ = transaction.Rollback()
+            return nil, fmt.Errorf("contact %s: %s", contact.Name, err)
+        }
+        report.Entries = append(report.Entries, entry)
+    }
+
+    if dryRun {
+        return report, transaction.Rollback()
+    }
+    return report, transaction.Commit()
+}
+
+func importBackupCertificate(transaction *sql.Tx, certificate BackupCertificate) (BackupReportEntry, error) {
+    // Adds a certificate of a backup unless its UUID is already used
+
+    entry := BackupReportEntry{Kind: EntryCertificate, Id: certificate.Uuid}
+    var signature, signer string
+    err := transaction.QueryRow("SELECT signature, signer FROM certificates WHERE uuid = ?", certificate.Uuid).Scan(&signature, &signer)
+    if err == nil {
+        entry.Result, entry.Reason = BackupSkipped, "already in the database"
+        if signature != certificate.Signature || signer != certificate.Signer {
+            entry.Result, entry.Reason = BackupConflict, "different signature or signer in the database"
+        }
+        return entry, nil
+    }
+    if err != sql.ErrNoRows {
+        return entry, err
+    }
+
+    _, err = transaction.Exec("INSERT INTO certificates (uuid, signature, signer, status, requestDigest, profile, createdAt, updatedAt, "+
+        "companyChainID) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", certificate.Uuid, certificate.Signature, certificate.Signer, certificate.Status,
+        certificate.RequestDigest, certificate.Profile, certificate.CreatedAt, certificate.UpdatedAt, certificate.CompanyChainID)
+    if err == nil && certificate.File != nil {
+        _, err = transaction.Exec("INSERT OR REPLACE INTO certificateFiles (uuid, name, size, path, algorithm, digest) VALUES (?, ?, ?, ?, ?, ?)",
+            certificate.Uuid, certificate.File.Name, certificate.File.Size, certificate.File.Path, certificate.File.Algorithm, certificate.File.Digest)
+        if err == nil {
+            err = appendAudit(transaction, "certificateFiles", certificate.Uuid, AuditInsert)
+        }
+    }
+    if err == nil {
+        err = appendAudit(transaction, "certificates", certificate.Uuid, AuditInsert)
+    }
+    entry.Result = BackupAdded
+    return entry, err
+}
+
+func (dao *DatabaseDAO) importBackupSecret(transaction *sql.Tx, secret BackupSecret) (BackupReportEntry, error) {
+    // Adds a secret of a backup unless its UUID is already used, comparing the recipient keys otherwise
+
+    entry := BackupReportEntry{Kind: EntrySecret, Id: secret.Uuid}
+    var storedKey string
+    err := transaction.QueryRow("SELECT recipientPrivateKey FROM secrets WHERE uuid = ?", secret.Uuid).Scan(&storedKey)
+    if err == nil {
+        if IsEncrypted(storedKey) {
+            plainKey, err := dao.Keystore.Decrypt(storedKey)
+            if err != nil {
+                return entry, err
+            }
+            storedKey = string(plainKey)
+        }
+        entry.Result, entry.Reason = BackupSkipped, "already in the database"
+        if storedKey != secret.RecipientPrivateKey {
+            entry.Result, entry.Reason = BackupConflict, "different recipient key in the database"
+        }
+        return entry, nil
+    }
+    if err != sql.ErrNoRows {
+        return entry, err
+    }
+
+    // The key of a secret sent to a contact is empty, the contact holding it
+    encryptedKey := ""
+    if secret.RecipientPrivateKey != "" {
+        if encryptedKey, err = dao.Keystore.Encrypt([]byte(secret.RecipientPrivateKey)); err != nil {
+            return entry, err
+        }
+    }
+    _, err = transaction.Exec("INSERT INTO secrets (uuid, recipientPrivateKey, status, requestDigest, profile, createdAt, updatedAt, companyChainID) "+
+        "VALUES (?, ?, ?, ?, ?, ?, ?, ?)", secret.Uuid, encryptedKey, secret.Status, secret.RequestDigest, secret.Profile, secret.CreatedAt,
+        secret.UpdatedAt, secret.CompanyChainID)
+    if err == nil {
+        err = appendAudit(transaction, "secrets", secret.Uuid, AuditInsert)
+    }
+    entry.Result = BackupAdded
+    return entry, err
+}
+
+func (dao *DatabaseDAO) importBackupKey(transaction *sql.Tx, key BackupKey) (BackupReportEntry, error) {
+    // Adds a keystore key of a backup unless its name is already used, comparing the public keys otherwise
+
+    entry := BackupReportEntry{Kind: EntryKey, Id: key.Name}
+    publicKey, err := PublicKeyFromPrivate(key.Type, key.PrivateKey)
+    if err != nil {
+        return entry, err
+    }
+    if key.PublicKey != "" && key.PublicKey != publicKey {
+        return entry, fmt.Errorf("the private key does not match the public key")
+    }
+
+    var storedType, storedPublicKey string
+    err = transaction.QueryRow("SELECT type, publicKey FROM keys WHERE name = ?", key.Name).Scan(&storedType, &storedPublicKey)
+    if err == nil {
+        entry.Result, entry.Reason = BackupSkipped, "already in the keystore"
+        if storedType != key.Type || storedPublicKey != publicKey {
+            entry.Result, entry.Reason = BackupConflict, "another key has this name in the keystore"
+        }
+        return entry, nil
+    }
+    if err != sql.ErrNoRows {
+        return entry, err
+    }
+
+    encryptedKey, err := dao.Keystore.Encrypt([]byte(key.PrivateKey))
+    if err != nil {
+        return entry, err
+    }
+    _, err = transaction.Exec("INSERT INTO keys (name, type, publicKey, privateKey) VALUES (?, ?, ?, ?)", key.Name, key.Type, publicKey, encryptedKey)
+    if err == nil {
+        err = appendAudit(transaction, "keys", key.Name, AuditInsert)
+    }
+    entry.Result = BackupAdded
+    return entry, err
+}
+
+func (report *BackupReport) Count(result string) int {
+    // Returns the number of entries with a given outcome
+
+    count := 0
+    for _, entry := range report.Entries {
+        if entry.Result == result {
+            count++
+        }
+    }
+    return count
+}
+
+func (report *BackupReport) Summary() string {
+    // Returns a one line summary of a merge
+
+    return strconv.Itoa(report.Count(BackupAdded)) + " added, " +
+        strconv.Itoa(report.Count(BackupSkipped)) + " skipped, " +
+        strconv.Itoa(report.Count(BackupConflict)) + " conflicts"
+}
+
+func (report *BackupReport) Lines(results ...string) string {
+    // Returns one readable line per entry with one of the given outcomes, or every entry if none is given
+
+    var lines []string
+    for _, entry := range report.Entries {
+        wanted := len(results) == 0
+        for _, result := range results {
+            wanted = wanted || entry.Result == result
+        }
+        if !wanted {
+            continue
+        }
+        line := entry.Kind + " " + entry.Id + " : " + entry.Result
+        if entry.Reason != "" {
+            line += ", " + entry.Reason
+        }
+        lines = append(lines, line)
+    }
+    return strings.Join(lines, "\n")
+}
@@ -0,0 +1,117 @@
+package libs
+
+import (
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/katena-chain/transactor-ui/libs/testutil"
+)
+
+func newTestDatabase(t *testing.T) (*DatabaseDAO, func()) {
+    // Opens a database in a temporary directory with an unlocked keystore, returning the function removing it
+
+    directory, removeDirectory := testutil.TempDir(t)
+    dao, err := OpenDb(filepath.Join(directory, "test.db"))
+    if err != nil {
+        removeDirectory()
+        t.Fatal(err)
+    }
+    cleanup := func() {
+        dao.Keystore.Lock()
+        _ = dao.Db.Close()
+        removeDirectory()
+    }
+    if err := dao.Keystore.Unlock(testutil.Passphrase); err != nil {
+        cleanup()
+        t.Fatal(err)
+    }
+    return &dao, cleanup
+}
+
+// Key derivation parameters a tampered backup or hand-off bundle may carry
+var tamperedEncryptions = []struct {
+    name   string
+    tamper func(encryption *BackupEncryption)
+}{
+    {"zero time", func(encryption *BackupEncryption) { encryption.Time = 0 }},
+    {"huge time", func(encryption *BackupEncryption) { encryption.Time = 1 << 31 }},
+    {"time over the bound", func(encryption *BackupEncryption) { encryption.Time = maxKdfTime + 1 }},
+    {"huge memory", func(encryption *BackupEncryption) { encryption.Memory = 1 << 31 }},
+    {"memory over the bound", func(encryption *BackupEncryption) { encryption.Memory = maxKdfMemory + 1 }},
+    {"zero threads", func(encryption *BackupEncryption) { encryption.Threads = 0 }},
+    {"unknown kdf", func(encryption *BackupEncryption) { encryption.Kdf = "scrypt" }},
+}
+
+func TestBackupEncryptionBounds(t *testing.T) {
+    encryption, backupKeystore, err := newBackupEncryption(testutil.Passphrase)
+    if err != nil {
+        t.Fatal(err)
+    }
+    backupKeystore.Lock()
+    if _, err := encryption.open(testutil.Passphrase); err != nil {
+        t.Fatalf("valid encryption rejected: %s", err)
+    }
+    if _, err := encryption.open("wrong"); err == nil {
+        t.Fatal("wrong passphrase accepted")
+    }
+
+    for _, test := range tamperedEncryptions {
+        t.Run(test.name, func(t *testing.T) {
+            tampered := *encryption
+            test.tamper(&tampered)
+            if _, err := tampered.open(testutil.Passphrase); err == nil {
+                t.Fatal("tampered parameters accepted")
+            }
+        })
+    }
+}
+
+func TestBackupKeepsContactSecretsWithoutKey(t *testing.T) {
+    // The recipient key of a secret sent to a contact is empty and has to stay so once imported
+
+    source, cleanupSource := newTestDatabase(t)
+    defer cleanupSource()
+    if err := source.AddSecretEntry(testutil.Uuid, "", "", "default"); err != nil {
+        t.Fatal(err)
+    }
+    backup, err := source.ExportBackup(testutil.Passphrase, false)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    target, cleanupTarget := newTestDatabase(t)
+    defer cleanupTarget()
+    report, err := target.ImportBackup(backup, testutil.Passphrase, false)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if report.Count(BackupAdded) != 1 {
+        t.Fatalf("unexpected report: %s", report.Lines())
+    }
+    var storedKey string
+    if err := target.Db.QueryRow("SELECT recipientPrivateKey FROM secrets WHERE uuid = ?", testutil.Uuid).Scan(&storedKey); err != nil {
+        t.Fatal(err)
+    }
+    if storedKey != "" {
+        t.Fatalf("empty recipient key stored as %q", storedKey)
+    }
+
+    // Importing it again compares the empty keys
+    if report, err = target.ImportBackup(backup, testutil.Passphrase, false); err != nil || report.Count(BackupSkipped) != 1 {
+        t.Fatalf("unexpected second import: %v, %v", report, err)
+    }
+}
+
+func TestReadBackupVersion1(t *testing.T) {
+    // The request digest of a version 1 backup is read from its transaction hash
+
+    backup, err := ReadBackup(strings.NewReader(`{"format":"` + BackupFormat + `","version":1,` +
+        `"certificates":[{"uuid":"` + testutil.Uuid + `","tx_hash":"DIGEST"}],"secrets":[{"uuid":"` + testutil.OtherUuid + `","tx_hash":"OTHER"}]}`))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if backup.Certificates[0].RequestDigest != "DIGEST" || backup.Secrets[0].RequestDigest != "OTHER" {
+        t.Fatalf("request digests lost: %+v, %+v", backup.Certificates[0], backup.Secrets[0])
+    }
+}
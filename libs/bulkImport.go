@@ -0,0 +1,462 @@
+package libs
+
+import (
+    "bufio"
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "io"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"
+    entityApi "github.com/katena-chain/sdk-go-client/entity/api"
+)
+
+// Formats of the import files
+const (
+    ImportFormatCSV   = "csv"
+    ImportFormatJSONL = "jsonl"
+)
+
+// Outcomes of an import row
+const (
+    ImportSend     = "send"
+    ImportSkipped  = "skipped"
+    ImportInvalid  = "invalid"
+    ImportAccepted = "accepted"
+    ImportRejected = "rejected"
+    ImportError    = "error"
+)
+
+// Default pool size and send rate of an import
+const DefaultImportWorkers = 4
+const DefaultImportRate = 5.0
+
+// Namespace of the UUIDs derived for the rows without one, so that importing a file again gives the same UUIDs
+var importNamespace = uuid.NewSHA1(uuid.NameSpaceOID, []byte("transactor-ui certificate import"))
+
+type ImportRow struct {
+    Line      int    `json:"-"`
+    Uuid      string `json:"uuid"`
+    Signature string `json:"signature"`
+    Signer    string `json:"signer"`
+}
+
+type ImportResult struct {
+    ImportRow
+    Result        string `json:"result"`
+    Reason        string `json:"reason,omitempty"`
+    Code          uint32 `json:"code"`
+    Message       string `json:"message,omitempty"`
+    RequestDigest string `json:"request_digest,omitempty"`
+    // Status of an accepted row on chain, once it is tracked
+    Status string `json:"status,omitempty"`
+    // What was broadcast and answered, kept for the history
+    signed     *SignedTransaction
+    sendStatus *entityApi.TransactionStatus
+    sendErr    error
+}
+
+type ImportPlan struct {
+    Rows []*ImportResult
+}
+
+type ImportRunner struct {
+    Dao    *DatabaseDAO
+    Config Config
+    // Number of concurrent sends
+    Workers int
+    // Maximum transactions sent per second, unlimited if not positive
+    Rate float64
+    // Called for each row sent, from the goroutine running the import
+    OnResult func(result *ImportResult)
+    // Called for each accepted row once its status is known, from the goroutine tracking it
+    OnStatus func(result *ImportResult)
+}
+
+func ImportFormatFromPath(path string) (string, error) {
+    // Guesses the format of an import file from its extension
+
+    switch strings.ToLower(filepath.Ext(path)) {
+    case ".csv":
+        return ImportFormatCSV, nil
+    case ".jsonl", ".ndjson", ".json":
+        return ImportFormatJSONL, nil
+    default:
+        return "", fmt.Errorf("cannot guess the format of %s, it should be csv or jsonl", path)
+    }
+}
+
+func ReadImportRows(reader io.Reader, format string) ([]ImportRow, error) {
+    // Reads the rows of a CSV file with a uuid, signature and signer header, or of a file of JSON objects, one per line
+
+    switch format {
+    case ImportFormatCSV:
+        return readImportCSV(reader)
+    case ImportFormatJSONL:
+        return readImportJSONL(reader)
+    default:
+        return nil, fmt.Errorf("unknown import format: %s", format)
+    }
+}
+
+func readImportCSV(reader io.Reader) ([]ImportRow, error) {
+    csvReader := csv.NewReader(reader)
+    csvReader.FieldsPerRecord = -1
+    csvReader.TrimLeadingSpace = true
+
+    header, err := csvReader.Read()
+    if err == io.EOF {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    columns := map[string]int{}
+    for i, name := range header {
+        columns[strings.ToLower(strings.TrimSpace(name))] = i
+    }
+    for _, name := range []string{"signature", "signer"} {
+        if _, ok := columns[name]; !ok {
+            return nil, fmt.Errorf("missing %s column in the CSV header", name)
+        }
+    }
+    field := func(record []string, name string) string {
+        index, ok := columns[name]
+        if !ok || index >= len(record) {
+            return ""
+        }
+        return strings.TrimSpace(record[index])
+    }
+
+    var rows []ImportRow
+    line := 1
+    for {
+        record, err := csvReader.Read()
+        if err == io.EOF {
+            return rows, nil
+        }
+        if err != nil {
+            return nil, err
+        }
+        line++
+        rows = append(rows, ImportRow{
+            Line:      line,
+            Uuid:      field(record, "uuid"),
+            Signature: field(record, "signature"),
+            Signer:    field(record, "signer"),
+        })
+    }
+}
+
+func readImportJSONL(reader io.Reader) ([]ImportRow, error) {
+    scanner := bufio.NewScanner(reader)
+    scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+    var rows []ImportRow
+    line := 0
+    for scanner.Scan() {
+        line++
+        text := strings.TrimSpace(scanner.Text())
+        if text == "" {
+            continue
+        }
+        row := ImportRow{}
+        if err := json.Unmarshal([]byte(text), &row); err != nil {
+            return nil, fmt.Errorf("line %d: %s", line, err)
+        }
+        row.Line = line
+        rows = append(rows, row)
+    }
+    return rows, scanner.Err()
+}
+
+func PlanImport(rows []ImportRow, dao *DatabaseDAO, companyChainID string) (*ImportPlan, error) {
+    // Validates every row and decides which ones to send : rows already in the database are skipped, rows without
+    // UUID get one derived from their content
+
+    plan := &ImportPlan{}
+    seen := map[string]int{}
+    for _, row := range rows {
+        result := &ImportResult{ImportRow: row, Result: ImportSend}
+        plan.Rows = append(plan.Rows, result)
+
+        switch {
+        case row.Signature == "":
+            result.Result, result.Reason = ImportInvalid, "missing signature"
+            continue
+        case row.Signer == "":
+            result.Result, result.Reason = ImportInvalid, "missing signer"
+            continue
+        }
+        if result.Uuid == "" {
+            result.Uuid = uuid.NewSHA1(importNamespace, []byte(companyChainID+"\n"+row.Signature+"\n"+row.Signer)).String()
+        } else if _, err := uuid.Parse(result.Uuid); err != nil {
+            result.Result, result.Reason = ImportInvalid, "invalid UUID"
+            continue
+        }
+        if line, ok := seen[result.Uuid]; ok {
+            result.Result, result.Reason = ImportInvalid, "same UUID as line "+strconv.Itoa(line)
+            continue
+        }
+        seen[result.Uuid] = row.Line
+
+        exists, err := dao.EntryExists(EntryCertificate, result.Uuid)
+        if err != nil {
+            return nil, err
+        }
+        if exists {
+            result.Result, result.Reason = ImportSkipped, "already sent"
+        }
+    }
+    return plan, nil
+}
+
+func (plan *ImportPlan) Count(result string) int {
+    // Returns the number of rows with a given outcome
+
+    count := 0
+    for _, row := range plan.Rows {
+        if row.Result == result {
+            count++
+        }
+    }
+    return count
+}
+
+func (plan *ImportPlan) Summary() string {
+    // Returns a one line summary of the plan or of its results
+
+    labels := []struct{ result, label string }{
+        {ImportSend, "to send"},
+        {ImportAccepted, "accepted"},
+        {ImportRejected, "rejected"},
+        {ImportError, "in error"},
+        {ImportSkipped, "already sent"},
+        {ImportInvalid, "invalid"},
+    }
+    parts := []string{strconv.Itoa(len(plan.Rows)) + " rows"}
+    for _, label := range labels {
+        if count := plan.Count(label.result); count > 0 {
+            parts = append(parts, strconv.Itoa(count)+" "+label.label)
+        }
+    }
+    if count := plan.TimedOut(); count > 0 {
+        parts = append(parts, strconv.Itoa(count)+" not committed in time")
+    }
+    return strings.Join(parts, ", ")
+}
+
+func (plan *ImportPlan) TimedOut() int {
+    // Returns the number of accepted rows not found on chain before their tracking stopped
+
+    count := 0
+    for _, row := range plan.Rows {
+        if row.Status == StatusTimedOut {
+            count++
+        }
+    }
+    return count
+}
+
+func (plan *ImportPlan) Failed() bool {
+    // Indicates if a row was invalid or could not be sent
+
+    return plan.Count(ImportInvalid)+plan.Count(ImportRejected)+plan.Count(ImportError) > 0
+}
+
+func NewImportRunner(dao *DatabaseDAO, config Config) *ImportRunner {
+    return &ImportRunner{
+        Dao:     dao,
+        Config:  config,
+        Workers: DefaultImportWorkers,
+        Rate:    DefaultImportRate,
+    }
+}
+
+func (runner *ImportRunner) send(result *ImportResult) {
+    // Builds and sends the certificate of a row, the history being written by record
+
+    config := runner.Config
+    config.History = nil
+    certHandler := CertificateHandler{
+        Config:        config,
+        UuidText:      result.Uuid,
+        SignatureText: result.Signature,
+        SignerText:    result.Signer,
+    }
+    transactionStatus, err := certHandler.SendCertificate()
+    result.signed, result.sendStatus, result.sendErr = certHandler.Signed, transactionStatus, err
+    if certHandler.Signed != nil {
+        result.RequestDigest = certHandler.Signed.Digest()
+    }
+    if err != nil {
+        result.Result, result.Reason = ImportError, err.Error()
+        return
+    }
+    result.Code, result.Message = transactionStatus.Code, transactionStatus.Message
+    result.Result = ImportAccepted
+    if transactionStatus.Code != 0 {
+        result.Result = ImportRejected
+    }
+}
+
+func (runner *ImportRunner) record(result *ImportResult) {
+    // Records an accepted row as a pending certificate so a later import skips it, and every sent row in the
+    // history
+
+    if runner.Config.History != nil && result.signed != nil {
+        err := runner.Config.History.RecordTransaction(runner.Config, result.signed, result.sendStatus, result.sendErr)
+        if err != nil && result.Result == ImportAccepted {
+            result.Reason = "sent but not recorded in the history: " + err.Error()
+        }
+    }
+    if result.Result != ImportAccepted {
+        return
+    }
+    err := runner.Dao.AddCertificateEntry(result.Uuid, result.Signature, result.Signer, result.RequestDigest, runner.Config.Profile)
+    if err == nil {
+        err = runner.Dao.SetStatus(EntryCertificate, result.Uuid, StatusPending)
+    }
+    if err != nil {
+        result.Reason = "sent but not recorded: " + err.Error()
+        return
+    }
+    result.Status = StatusPending
+}
+
+func (runner *ImportRunner) Run(plan *ImportPlan) {
+    // Sends the rows of a plan through a bounded pool of workers sharing a rate limit, recording each result
+    // from the calling goroutine so the database is written sequentially
+
+    workers := runner.Workers
+    if workers < 1 {
+        workers = 1
+    }
+    var throttle <-chan time.Time
+    if runner.Rate > 0 {
+        ticker := time.NewTicker(time.Duration(float64(time.Second) / runner.Rate))
+        defer ticker.Stop()
+        throttle = ticker.C
+    }
+
+    jobs := make(chan *ImportResult)
+    results := make(chan *ImportResult)
+    var group sync.WaitGroup
+    for i := 0; i < workers; i++ {
+        group.Add(1)
+        go func() {
+            defer group.Done()
+            for result := range jobs {
+                if throttle != nil {
+                    <-throttle
+                }
+                runner.send(result)
+                results <- result
+            }
+        }()
+    }
+    go func() {
+        for _, result := range plan.Rows {
+            if result.Result == ImportSend {
+                jobs <- result
+            }
+        }
+        close(jobs)
+        group.Wait()
+        close(results)
+    }()
+
+    for result := range results {
+        runner.record(result)
+        if runner.OnResult != nil {
+            runner.OnResult(result)
+        }
+    }
+}
+
+func (runner *ImportRunner) Track(plan *ImportPlan, timeout time.Duration) {
+    // Follows the accepted rows of a run until they are committed, rejected or the timeout expires, through a pool
+    // of as many workers as the sends. A row the chain rejects becomes rejected.
+
+    workers := runner.Workers
+    if workers < 1 {
+        workers = 1
+    }
+    jobs := make(chan *ImportResult)
+    var group sync.WaitGroup
+    for i := 0; i < workers; i++ {
+        group.Add(1)
+        go func() {
+            defer group.Done()
+            for result := range jobs {
+                tracker := NewStatusTracker(runner.Dao, runner.Config)
+                tracker.Timeout = timeout
+                var detail string
+                result.Status, detail = tracker.Track(EntryCertificate, result.Uuid, result.signed, result.sendStatus)
+                if result.Status == StatusFailed {
+                    result.Result, result.Reason = ImportRejected, detail
+                }
+                if runner.OnStatus != nil {
+                    runner.OnStatus(result)
+                }
+            }
+        }()
+    }
+    for _, result := range plan.Rows {
+        if result.Result == ImportAccepted && result.Status == StatusPending {
+            jobs <- result
+        }
+    }
+    close(jobs)
+    group.Wait()
+}
+
+func (plan *ImportPlan) WriteReport(writer io.Writer) error {
+    // Writes the outcome of every row as CSV
+
+    csvWriter := csv.NewWriter(writer)
+    _ = csvWriter.Write([]string{"line", "uuid", "signature", "signer", "result", "reason", "code", "message", "request_digest", "status"})
+    for _, row := range plan.Rows {
+        _ = csvWriter.Write([]string{strconv.Itoa(row.Line), row.Uuid, row.Signature, row.Signer, row.Result, row.Reason,
+            strconv.FormatUint(uint64(row.Code), 10), row.Message, row.RequestDigest, row.Status})
+    }
+    csvWriter.Flush()
+    return csvWriter.Error()
+}
+
+func (dao *DatabaseDAO) RecordImport(importPath string, plan *ImportPlan) error {
+    // Keeps the outcome of every row of an import in the database
+
+    auditMutex.Lock()
+    defer auditMutex.Unlock()
+    transaction, err := dao.Db.Begin()
+    if err != nil {
+        return err
+    }
+    for _, row := range plan.Rows {
+        result, err := transaction.Exec("INSERT INTO importResults (file, line, uuid, result, reason, code, message, requestDigest, createdAt) "+
+            "VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", importPath, row.Line, row.Uuid, row.Result, row.Reason, row.Code, row.Message, row.RequestDigest, now())
+        if err == nil {
+            var id int64
+            if id, err = result.LastInsertId(); err == nil {
+                err = appendAudit(transaction, "importResults", strconv.FormatInt(id, 10), AuditInsert)
+            }
+        }
+        if err != nil {
+            _ = transaction.Rollback()
+            return err
+        }
+    }
+    return transaction.Commit()
+}
+
+func ReportPath(importPath string) string {
+    // Returns the default path of the report of an import file
+
+    return strings.TrimSuffix(importPath, filepath.Ext(importPath)) + ".report.csv"
+}
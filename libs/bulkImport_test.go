@@ -0,0 +1,163 @@
+package libs
+
+import (
+    "io/ioutil"
+    "net/http"
+    "net/http/httptest"
+    "reflect"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/katena-chain/transactor-ui/libs/testutil"
+)
+
+func TestReadImportCSV(t *testing.T) {
+    tests := []struct {
+        name string
+        file string
+        rows []ImportRow
+        err  string
+    }{
+        {"empty file", "", nil, ""},
+        {"header only", "uuid,signature,signer\n", nil, ""},
+        {"columns in any order and case", " Signer ,UUID,signature\nsigner,  " + testutil.Uuid + "  ,signature\n",
+            []ImportRow{{Line: 2, Uuid: testutil.Uuid, Signature: "signature", Signer: "signer"}}, ""},
+        {"without uuid column", "signature,signer\nfirst,signer\nsecond,signer\n",
+            []ImportRow{{Line: 2, Signature: "first", Signer: "signer"}, {Line: 3, Signature: "second", Signer: "signer"}}, ""},
+        {"short record", "uuid,signature,signer\n" + testutil.Uuid + ",signature\n",
+            []ImportRow{{Line: 2, Uuid: testutil.Uuid, Signature: "signature"}}, ""},
+        {"missing signer column", "uuid,signature\n" + testutil.Uuid + ",signature\n", nil, "missing signer column"},
+        {"unterminated quote", "signature,signer\n\"signature,signer\n", nil, "quote"},
+    }
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            rows, err := ReadImportRows(strings.NewReader(test.file), ImportFormatCSV)
+            if test.err != "" {
+                if err == nil || !strings.Contains(err.Error(), test.err) {
+                    t.Fatalf("unexpected error: %v", err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(rows, test.rows) {
+                t.Fatalf("unexpected rows: %+v", rows)
+            }
+        })
+    }
+}
+
+func TestReadImportJSONL(t *testing.T) {
+    // Blank lines are skipped but still counted, so that a row is reported at its line in the file
+
+    file := `{"uuid":"` + testutil.Uuid + `","signature":"first","signer":"signer"}` + "\n\n" + `  {"signature":"second","signer":"signer"}  ` + "\n"
+    rows, err := ReadImportRows(strings.NewReader(file), ImportFormatJSONL)
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := []ImportRow{{Line: 1, Uuid: testutil.Uuid, Signature: "first", Signer: "signer"}, {Line: 3, Signature: "second", Signer: "signer"}}
+    if !reflect.DeepEqual(rows, expected) {
+        t.Fatalf("unexpected rows: %+v", rows)
+    }
+
+    if _, err := ReadImportRows(strings.NewReader(file+"{\"signature\":\n"), ImportFormatJSONL); err == nil || !strings.HasPrefix(err.Error(), "line 4:") {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if _, err := ReadImportRows(strings.NewReader(file), "xml"); err == nil {
+        t.Fatal("unknown format accepted")
+    }
+}
+
+func TestPlanImport(t *testing.T) {
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+    if err := dao.AddCertificateEntry(testutil.OtherUuid, "signature", "signer", "", "default"); err != nil {
+        t.Fatal(err)
+    }
+
+    plan, err := PlanImport([]ImportRow{
+        {Line: 2, Uuid: testutil.Uuid, Signature: "signature", Signer: "signer"},
+        {Line: 3, Signer: "signer"},
+        {Line: 4, Signature: "signature"},
+        {Line: 5, Uuid: "not a uuid", Signature: "signature", Signer: "signer"},
+        {Line: 6, Uuid: testutil.Uuid, Signature: "other", Signer: "signer"},
+        {Line: 7, Uuid: testutil.OtherUuid, Signature: "signature", Signer: "signer"},
+        {Line: 8, Signature: "derived", Signer: "signer"},
+    }, dao, testutil.CompanyChainID)
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := []struct{ result, reason string }{
+        {ImportSend, ""},
+        {ImportInvalid, "missing signature"},
+        {ImportInvalid, "missing signer"},
+        {ImportInvalid, "invalid UUID"},
+        {ImportInvalid, "same UUID as line 2"},
+        {ImportSkipped, "already sent"},
+        {ImportSend, ""},
+    }
+    for i, row := range plan.Rows {
+        if row.Result != expected[i].result || row.Reason != expected[i].reason {
+            t.Errorf("line %d: %s, %s", row.Line, row.Result, row.Reason)
+        }
+    }
+
+    // A row without UUID gets the same one from one run to the next, and another one in another company
+    again, err := PlanImport([]ImportRow{{Line: 2, Signature: "derived", Signer: "signer"}}, dao, testutil.CompanyChainID)
+    if err != nil {
+        t.Fatal(err)
+    }
+    other, err := PlanImport([]ImportRow{{Line: 2, Signature: "derived", Signer: "signer"}}, dao, "other-company")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if derived := plan.Rows[6].Uuid; derived == "" || again.Rows[0].Uuid != derived || other.Rows[0].Uuid == derived {
+        t.Fatalf("unexpected derived UUIDs: %s, %s, %s", derived, again.Rows[0].Uuid, other.Rows[0].Uuid)
+    }
+}
+
+func TestImportTrack(t *testing.T) {
+    // The accepted rows of an import are followed until the chain commits them, like a single send
+
+    var mutex sync.Mutex
+    var sent string
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        mutex.Lock()
+        defer mutex.Unlock()
+        if request.Method == http.MethodPost {
+            body, _ := ioutil.ReadAll(request.Body)
+            sent = string(body)
+            writer.WriteHeader(http.StatusAccepted)
+            _, _ = writer.Write([]byte(`{"code":0,"message":"accepted"}`))
+            return
+        }
+        _, _ = writer.Write([]byte(`{"transaction":` + sent + `,"status":{"code":0,"message":"committed"}}`))
+    }))
+    defer server.Close()
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+
+    config := Config{PrivKey: testutil.PrivateKey(t), ChainID: testutil.ChainID, CompanyChainID: testutil.CompanyChainID,
+        ApiUrl: server.URL, History: dao}
+    plan, err := PlanImport([]ImportRow{{Line: 2, Uuid: testutil.Uuid, Signature: "signature", Signer: "signer"}}, dao, testutil.CompanyChainID)
+    if err != nil {
+        t.Fatal(err)
+    }
+    runner := NewImportRunner(dao, config)
+    runner.Workers, runner.Rate = 1, 0
+    runner.Run(plan)
+    if row := plan.Rows[0]; row.Result != ImportAccepted || row.Status != StatusPending {
+        t.Fatalf("unexpected row after the run: %+v", row)
+    }
+
+    runner.Track(plan, time.Second)
+    if row := plan.Rows[0]; row.Result != ImportAccepted || row.Status != StatusCommitted {
+        t.Fatalf("unexpected row after tracking: %+v", row)
+    }
+    if status, err := dao.GetStatus(EntryCertificate, testutil.Uuid); err != nil || status != StatusCommitted {
+        t.Fatalf("entry recorded as %s: %v", status, err)
+    }
+}
@@ -0,0 +1,266 @@
+package libs
+
+import (
+    "database/sql"
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "io"
+    "strings"
+)
+
+// Identifies contacts files, the version being increased whenever their layout changes
+const ContactsFormat = "transactor-ui-contacts"
+const ContactsVersion = 1
+
+// Kind of the contacts in an import report, next to EntryCertificate, EntrySecret and EntryKey
+const EntryContact = "contact"
+
+// Length of an X25519 public key
+const x25519KeyLen = 32
+
+// Named recipient of secrets
+type Contact struct {
+    Name           string `json:"name"`
+    PublicKey      string `json:"public_key"`
+    Fingerprint    string `json:"fingerprint"`
+    CompanyChainID string `json:"company_chain_id"`
+    Notes          string `json:"notes"`
+    CreatedAt      string `json:"created_at,omitempty"`
+    UpdatedAt      string `json:"updated_at,omitempty"`
+}
+
+type ContactsFile struct {
+    Format   string    `json:"format"`
+    Version  int       `json:"version"`
+    Contacts []Contact `json:"contacts"`
+}
+
+func X25519Fingerprint(publicKeyBase64 string) (string, error) {
+    // Checks a base64 X25519 public key and returns its fingerprint
+
+    publicKey, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKeyBase64))
+    if err != nil {
+        return "", fmt.Errorf("invalid public key: %s", err)
+    }
+    if len(publicKey) != x25519KeyLen {
+        return "", fmt.Errorf("an X25519 public key is %d bytes long", x25519KeyLen)
+    }
+    return Fingerprint(publicKey), nil
+}
+
+func (contact *Contact) normalize() error {
+    // Trims the fields of a contact, checks its name and public key and computes its fingerprint
+
+    contact.Name = strings.TrimSpace(contact.Name)
+    contact.PublicKey = strings.TrimSpace(contact.PublicKey)
+    contact.CompanyChainID = strings.TrimSpace(contact.CompanyChainID)
+    if contact.Name == "" {
+        return fmt.Errorf("missing contact name")
+    }
+    fingerprint, err := X25519Fingerprint(contact.PublicKey)
+    if err != nil {
+        return fmt.Errorf("contact %s: %s", contact.Name, err)
+    }
+    contact.Fingerprint = fingerprint
+    return nil
+}
+
+func (contact *Contact) Label() string {
+    // Returns the option of a contact in the recipient pickers
+
+    label := contact.Name + " - " + contact.Fingerprint
+    if contact.CompanyChainID != "" {
+        label += " (" + contact.CompanyChainID + ")"
+    }
+    return label
+}
+
+func (dao *DatabaseDAO) AddContact(contact Contact) error {
+    // Adds a recipient to the address book
+
+    if err := contact.normalize(); err != nil {
+        return err
+    }
+    _, err := dao.auditedExec("contacts", contact.Name, AuditInsert,
+        "INSERT INTO contacts (name, publicKey, fingerprint, companyChainID, notes, createdAt, updatedAt) VALUES (?, ?, ?, ?, ?, ?, ?)",
+        contact.Name, contact.PublicKey, contact.Fingerprint, contact.CompanyChainID, contact.Notes, now(), now())
+    if isConstraintError(err) {
+        return fmt.Errorf("a contact named %s is already in the address book", contact.Name)
+    }
+    return err
+}
+
+func (dao *DatabaseDAO) UpdateContact(contact Contact) error {
+    // Replaces the public key, company and notes of a recipient
+
+    if err := contact.normalize(); err != nil {
+        return err
+    }
+    result, err := dao.auditedExec("contacts", contact.Name, AuditUpdate,
+        "UPDATE contacts SET publicKey = ?, fingerprint = ?, companyChainID = ?, notes = ?, updatedAt = ? WHERE name = ?",
+        contact.PublicKey, contact.Fingerprint, contact.CompanyChainID, contact.Notes, now(), contact.Name)
+    if err != nil {
+        return err
+    }
+    if count, _ := result.RowsAffected(); count == 0 {
+        return fmt.Errorf("no contact named %s", contact.Name)
+    }
+    return nil
+}
+
+func (dao *DatabaseDAO) RemoveContact(name string) error {
+    // Removes a recipient from the address book
+
+    result, err := dao.auditedExec("contacts", name, AuditDelete, "DELETE FROM contacts WHERE name = ?", name)
+    if err != nil {
+        return err
+    }
+    if count, _ := result.RowsAffected(); count == 0 {
+        return fmt.Errorf("no contact named %s", name)
+    }
+    return nil
+}
+
+func (dao *DatabaseDAO) GetContact(name string) (*Contact, error) {
+    rows, err := dao.Db.Query("SELECT name, publicKey, fingerprint, companyChainID, notes, createdAt, updatedAt FROM contacts WHERE name = ?", name)
+    if err != nil {
+        return nil, err
+    }
+    contacts, err := scanContacts(rows)
+    if err != nil {
+        return nil, err
+    }
+    if len(contacts) == 0 {
+        return nil, fmt.Errorf("no contact named %s", name)
+    }
+    return &contacts[0], nil
+}
+
+func (dao *DatabaseDAO) ListContacts(search string) ([]Contact, error) {
+    // Returns the recipients sorted by name, only the ones whose name, fingerprint, public key, company or notes
+    // contain the search text if any
+
+    query := "SELECT name, publicKey, fingerprint, companyChainID, notes, createdAt, updatedAt FROM contacts"
+    var args []interface{}
+    if search = strings.TrimSpace(search); search != "" {
+        query += " WHERE name LIKE ? ESCAPE '\\' OR fingerprint LIKE ? ESCAPE '\\' OR publicKey LIKE ? ESCAPE '\\'" +
+            " OR companyChainID LIKE ? ESCAPE '\\' OR notes LIKE ? ESCAPE '\\'"
+        pattern := "%" + strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(search) + "%"
+        args = []interface{}{pattern, pattern, pattern, pattern, pattern}
+    }
+    rows, err := dao.Db.Query(query+" ORDER BY name COLLATE NOCASE", args...)
+    if err != nil {
+        return nil, err
+    }
+    return scanContacts(rows)
+}
+
+func scanContacts(rows *sql.Rows) ([]Contact, error) {
+    defer func() {
+        _ = rows.Close()
+    }()
+
+    contacts := []Contact{}
+    for rows.Next() {
+        var contact Contact
+        err := rows.Scan(&contact.Name, &contact.PublicKey, &contact.Fingerprint, &contact.CompanyChainID, &contact.Notes,
+            &contact.CreatedAt, &contact.UpdatedAt)
+        if err != nil {
+            return nil, err
+        }
+        contacts = append(contacts, contact)
+    }
+    return contacts, rows.Err()
+}
+
+func (dao *DatabaseDAO) ExportContacts(writer io.Writer) error {
+    // Writes the address book as indented JSON
+
+    contacts, err := dao.ListContacts("")
+    if err != nil {
+        return err
+    }
+    encoder := json.NewEncoder(writer)
+    encoder.SetIndent("", "    ")
+    return encoder.Encode(ContactsFile{Format: ContactsFormat, Version: ContactsVersion, Contacts: contacts})
+}
+
+func ReadContacts(reader io.Reader) ([]Contact, error) {
+    // Reads a contacts file and checks it can be understood by this version of the application
+
+    file := ContactsFile{}
+    if err := json.NewDecoder(reader).Decode(&file); err != nil {
+        return nil, fmt.Errorf("invalid contacts file: %s", err)
+    }
+    if file.Format != ContactsFormat {
+        return nil, fmt.Errorf("not a transactor-ui contacts file")
+    }
+    if file.Version < 1 || file.Version > ContactsVersion {
+        return nil, fmt.Errorf("contacts file version %d is not supported by this version of the application (%d)", file.Version, ContactsVersion)
+    }
+    return file.Contacts, nil
+}
+
+func (dao *DatabaseDAO) ImportContacts(contacts []Contact, dryRun bool) (*BackupReport, error) {
+    // Merges contacts into the address book : new names are added, identical contacts are skipped and the ones
+    // differing from the address book are reported as conflicts and left untouched. Nothing is written on a dry run.
+
+    auditMutex.Lock()
+    defer auditMutex.Unlock()
+    transaction, err := dao.Db.Begin()
+    if err != nil {
+        return nil, err
+    }
+    report := &BackupReport{}
+    for _, contact := range contacts {
+        entry, err := importContact(transaction, contact)
+        if err != nil {
+            _ = transaction.Rollback()
+            return nil, fmt.Errorf("contact %s: %s", contact.Name, err)
+        }
+        report.Entries = append(report.Entries, entry)
+    }
+    if dryRun {
+        return report, transaction.Rollback()
+    }
+    return report, transaction.Commit()
+}
+
+func importContact(transaction *sql.Tx, contact Contact) (BackupReportEntry, error) {
+    // Adds a contact unless its name is already used, comparing the public keys otherwise
+
+    entry := BackupReportEntry{Kind: EntryContact, Id: contact.Name}
+    if err := contact.normalize(); err != nil {
+        return entry, err
+    }
+    entry.Id = contact.Name
+    var publicKey string
+    err := transaction.QueryRow("SELECT publicKey FROM contacts WHERE name = ?", contact.Name).Scan(&publicKey)
+    if err == nil {
+        entry.Result, entry.Reason = BackupSkipped, "already in the address book"
+        if publicKey != contact.PublicKey {
+            entry.Result, entry.Reason = BackupConflict, "different public key in the address book"
+        }
+        return entry, nil
+    }
+    if err != sql.ErrNoRows {
+        return entry, err
+    }
+
+    createdAt, updatedAt := contact.CreatedAt, contact.UpdatedAt
+    if createdAt == "" {
+        createdAt = now()
+    }
+    if updatedAt == "" {
+        updatedAt = createdAt
+    }
+    _, err = transaction.Exec("INSERT INTO contacts (name, publicKey, fingerprint, companyChainID, notes, createdAt, updatedAt) "+
+        "VALUES (?, ?, ?, ?, ?, ?, ?)", contact.Name, contact.PublicKey, contact.Fingerprint, contact.CompanyChainID, contact.Notes,
+        createdAt, updatedAt)
+    if err == nil {
+        err = appendAudit(transaction, "contacts", contact.Name, AuditInsert)
+    }
+    entry.Result = BackupAdded
+    return entry, err
+}
@@ -0,0 +1,150 @@
+package libs
+
+import (
+    "bytes"
+    "encoding/base64"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+// Base64 of 32 zero bytes, a well-formed X25519 public key
+var testContactKey = base64.StdEncoding.EncodeToString(make([]byte, x25519KeyLen))
+
+func TestX25519Fingerprint(t *testing.T) {
+    fingerprint, err := X25519Fingerprint("  " + testContactKey + "\n")
+    if err != nil {
+        t.Fatal(err)
+    }
+    // SHA-256 of 32 zero bytes starts with 66687aadf862bd776c8fc18b8e9f8e20
+    if fingerprint != "66:68:7A:AD:F8:62:BD:77:6C:8F:C1:8B:8E:9F:8E:20" {
+        t.Fatalf("unexpected fingerprint: %s", fingerprint)
+    }
+
+    for _, publicKey := range []string{"", "not base64!", base64.StdEncoding.EncodeToString(make([]byte, x25519KeyLen-1)),
+        base64.StdEncoding.EncodeToString(make([]byte, x25519KeyLen+1))} {
+        if _, err := X25519Fingerprint(publicKey); err == nil {
+            t.Errorf("public key %q accepted", publicKey)
+        }
+    }
+}
+
+func TestContactNormalize(t *testing.T) {
+    contact := Contact{Name: " Alice ", PublicKey: " " + testContactKey + " ", CompanyChainID: " company ", Notes: " kept as typed "}
+    if err := contact.normalize(); err != nil {
+        t.Fatal(err)
+    }
+    expected := Contact{Name: "Alice", PublicKey: testContactKey, Fingerprint: contact.Fingerprint, CompanyChainID: "company",
+        Notes: " kept as typed "}
+    if contact.Fingerprint == "" || !reflect.DeepEqual(contact, expected) {
+        t.Fatalf("unexpected contact: %+v", contact)
+    }
+
+    tests := []struct {
+        contact Contact
+        err     string
+    }{
+        {Contact{Name: "  ", PublicKey: testContactKey}, "missing contact name"},
+        {Contact{Name: "Bob", PublicKey: "short"}, "contact Bob: invalid public key"},
+        {Contact{Name: "Bob", PublicKey: base64.StdEncoding.EncodeToString([]byte("short"))}, "contact Bob: an X25519 public key"},
+    }
+    for _, test := range tests {
+        if err := test.contact.normalize(); err == nil || !strings.HasPrefix(err.Error(), test.err) {
+            t.Errorf("unexpected error for %+v: %v", test.contact, err)
+        }
+    }
+}
+
+func TestListContactsSearch(t *testing.T) {
+    // The wildcards of LIKE typed in a search match themselves only
+
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+    for _, name := range []string{"100% sure", "1000 sure", "first_name", "firstXname", `back\slash`, "Zed"} {
+        if err := dao.AddContact(Contact{Name: name, PublicKey: testContactKey, Notes: "notes of " + name}); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    tests := []struct {
+        search string
+        names  []string
+    }{
+        {"", []string{"100% sure", "1000 sure", `back\slash`, "first_name", "firstXname", "Zed"}},
+        {"%", []string{"100% sure"}},
+        {"0%", []string{"100% sure"}},
+        {"_", []string{"first_name"}},
+        {"t_n", []string{"first_name"}},
+        {`\`, []string{`back\slash`}},
+        {"  zed ", []string{"Zed"}},
+        {"66:68:7A", []string{"100% sure", "1000 sure", `back\slash`, "first_name", "firstXname", "Zed"}},
+        {"nothing", []string{}},
+    }
+    for _, test := range tests {
+        contacts, err := dao.ListContacts(test.search)
+        if err != nil {
+            t.Fatal(err)
+        }
+        names := []string{}
+        for _, contact := range contacts {
+            names = append(names, contact.Name)
+        }
+        if !reflect.DeepEqual(names, test.names) {
+            t.Errorf("search %q found %q", test.search, names)
+        }
+    }
+}
+
+func TestImportContactsDryRun(t *testing.T) {
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+    if err := dao.AddContact(Contact{Name: "Alice", PublicKey: testContactKey}); err != nil {
+        t.Fatal(err)
+    }
+    otherKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, x25519KeyLen))
+    contacts := []Contact{
+        {Name: "Alice", PublicKey: testContactKey},
+        {Name: "Bob", PublicKey: otherKey},
+        {Name: " Alice ", PublicKey: otherKey},
+    }
+    expected := []BackupReportEntry{
+        {Kind: EntryContact, Id: "Alice", Result: BackupSkipped, Reason: "already in the address book"},
+        {Kind: EntryContact, Id: "Bob", Result: BackupAdded},
+        {Kind: EntryContact, Id: "Alice", Result: BackupConflict, Reason: "different public key in the address book"},
+    }
+
+    // A dry run reports what an import would do and rolls it back
+    report, err := dao.ImportContacts(contacts, true)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(report.Entries, expected) {
+        t.Fatalf("unexpected dry run report: %+v", report.Entries)
+    }
+    if listed, err := dao.ListContacts(""); err != nil || len(listed) != 1 {
+        t.Fatalf("dry run wrote contacts: %+v, %v", listed, err)
+    }
+    var auditEntries int
+    if err := dao.Db.QueryRow("SELECT COUNT(*) FROM auditLog WHERE tableName = 'contacts'").Scan(&auditEntries); err != nil || auditEntries != 1 {
+        t.Fatalf("dry run left audit entries: %d, %v", auditEntries, err)
+    }
+
+    report, err = dao.ImportContacts(contacts, false)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(report.Entries, expected) {
+        t.Fatalf("unexpected import report: %+v", report.Entries)
+    }
+    if bob, err := dao.GetContact("Bob"); err != nil || bob.PublicKey != otherKey || bob.CreatedAt == "" || bob.UpdatedAt != bob.CreatedAt {
+        t.Fatalf("unexpected imported contact: %+v, %v", bob, err)
+    }
+
+    // An invalid contact aborts the whole import
+    if _, err := dao.ImportContacts([]Contact{{Name: "Carol", PublicKey: otherKey}, {Name: "Dave"}}, false); err == nil {
+        t.Fatal("invalid contact imported")
+    }
+    if _, err := dao.GetContact("Carol"); err == nil {
+        t.Fatal("contact before the invalid one imported")
+    }
+}
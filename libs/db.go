@@ -2,140 +2,329 @@ package libs
 
 import (
     "database/sql"
+    "errors"
     "fmt"
+    "time"
 
-    _ "github.com/mattn/go-sqlite3"
+    "github.com/mattn/go-sqlite3"
 )
 
+// Database file used by the application, in the working directory
+const DefaultDbPath = "./transactor.db"
+
+// Returned when a UUID is not recorded in the database
+var ErrNotFound = errors.New("no such uuid in the database")
+
+// Last option of the certificate and secret selects, opening the adding dialog
+const AddCertificateOption = "Add certificate..."
+const AddSecretOption = "Add secret..."
+
 type DatabaseDAO struct {
-    Db *sql.DB
+    Db       *sql.DB
+    Keystore *Keystore
 }
 
-func InitDb() DatabaseDAO {
-    // Opens the database & if needed creates the tables
-    database, _ := sql.Open("sqlite3", "./transactor.db")
-    statement, _ := database.Prepare(
-        "CREATE TABLE IF NOT EXISTS certificates (UUID string primary key, SIGNATURE string, SIGNER string)")
-    _, _ = statement.Exec()
+func InitDb() (DatabaseDAO, error) {
+    // Opens the application database
+    return OpenDb(DefaultDbPath)
+}
 
-    statement, _ = database.Prepare(
-        "CREATE TABLE IF NOT EXISTS secrets (UUID string primary key, recipientPrivateKey string)")
-    _, _ = statement.Exec()
+func OpenDb(path string) (DatabaseDAO, error) {
+    // Opens a database & brings its schema up to date
+    database, err := sql.Open("sqlite3", path)
+    if err != nil {
+        return DatabaseDAO{}, err
+    }
+    if err := database.Ping(); err != nil {
+        _ = database.Close()
+        return DatabaseDAO{}, fmt.Errorf("cannot open database %s: %s", path, err)
+    }
+    if err := Migrate(database); err != nil {
+        _ = database.Close()
+        return DatabaseDAO{}, err
+    }
 
     dao := DatabaseDAO{
-        Db: database,
+        Db:       database,
+        Keystore: &Keystore{Db: database},
+    }
+    return dao, nil
+}
+
+func now() string {
+    // Returns the current time as stored in the timestamp columns
+    return time.Now().UTC().Format(time.RFC3339)
+}
+
+func isConstraintError(err error) bool {
+    sqliteErr, ok := err.(sqlite3.Error)
+    return ok && sqliteErr.Code == sqlite3.ErrConstraint
+}
+
+func (dao *DatabaseDAO) EntryExists(kind string, uuid string) (bool, error) {
+    // Indicates if a certificate or secret entry is recorded for a UUID
+
+    table, err := entryTable(kind)
+    if err != nil {
+        return false, err
+    }
+    var count int
+    err = dao.Db.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE uuid = ?", uuid).Scan(&count)
+    return count > 0, err
+}
+
+func (dao *DatabaseDAO) CheckNewEntry(kind string, uuid string) error {
+    // Returns an error if a certificate or secret entry is already recorded for a UUID, before sending a
+    // transaction that could not be recorded
+
+    exists, err := dao.EntryExists(kind, uuid)
+    if err != nil {
+        return err
     }
-    return dao
+    if exists {
+        return fmt.Errorf("a %s for %s is already in the database", kind, uuid)
+    }
+    return nil
 }
 
-func (dao *DatabaseDAO) AddCertificateEntry(uuid string, signature string, signer string) {
+func (dao *DatabaseDAO) AddCertificateEntry(uuid string, signature string, signer string, requestDigest string, profile string) error {
     // Adds a new certificate to the DB
 
-    statement, _ := dao.Db.Prepare("INSERT INTO certificates VALUES (?, ?, ?)")
-    _, _ = statement.Exec(uuid, signature, signer)
+    _, err := dao.auditedExec("certificates", uuid, AuditInsert,
+        "INSERT INTO certificates (uuid, signature, signer, requestDigest, profile, createdAt, updatedAt) VALUES (?, ?, ?, ?, ?, ?, ?)",
+        uuid, signature, signer, requestDigest, profile, now(), now())
+    if isConstraintError(err) {
+        return fmt.Errorf("a certificate for %s is already in the database", uuid)
+    }
+    return err
 }
 
-func (dao *DatabaseDAO) AddSecretEntry(uuid string, recipientPrivateKey string) {
-    // Adds a new secret to the DB
+func (dao *DatabaseDAO) AddSecretEntry(uuid string, recipientPrivateKey string, requestDigest string, profile string) error {
+    // Adds a new secret to the DB, its recipient private key encrypted by the keystore. The key is empty for a secret
+    // sent to a contact, who holds it
 
-    statement, _ := dao.Db.Prepare("INSERT INTO secrets VALUES (?, ?)")
-    _, _ = statement.Exec(uuid, recipientPrivateKey)
+    encryptedKey := ""
+    if recipientPrivateKey != "" {
+        var err error
+        if encryptedKey, err = dao.Keystore.Encrypt([]byte(recipientPrivateKey)); err != nil {
+            return err
+        }
+    }
+    _, err := dao.auditedExec("secrets", uuid, AuditInsert,
+        "INSERT INTO secrets (uuid, recipientPrivateKey, requestDigest, profile, createdAt, updatedAt) VALUES (?, ?, ?, ?, ?, ?)",
+        uuid, encryptedKey, requestDigest, profile, now(), now())
+    if isConstraintError(err) {
+        return fmt.Errorf("a secret for %s is already in the database", uuid)
+    }
+    return err
 }
 
-func (dao *DatabaseDAO) RemoveCertificate(uuid string) {
-    // Removes a certificate with given UUID from the DB
+func (dao *DatabaseDAO) removeEntry(table string, uuid string) error {
+    // Removes the entry of a UUID from a table
 
-    statement, _ := dao.Db.Prepare("DELETE FROM certificates WHERE uuid = ?")
-    _, err := statement.Exec(uuid)
+    result, err := dao.auditedExec(table, uuid, AuditDelete, "DELETE FROM "+table+" WHERE uuid = ?", uuid)
     if err != nil {
-        fmt.Println("Error deleting from DB", err)
+        return err
+    }
+    if count, _ := result.RowsAffected(); count == 0 {
+        return ErrNotFound
     }
+    return nil
 }
 
-func (dao *DatabaseDAO) RemoveSecret(uuid string) {
-    // Removes a secret with given UUID from the DB
+func (dao *DatabaseDAO) RemoveCertificate(uuid string) error {
+    // Removes a certificate with given UUID from the DB, along with the file it certifies if any
+
+    if err := dao.removeEntry("certificates", uuid); err != nil {
+        return err
+    }
+    _, err := dao.auditedExec("certificateFiles", uuid, AuditDelete, "DELETE FROM certificateFiles WHERE uuid = ?", uuid)
+    return err
+}
+
+func (dao *DatabaseDAO) AddCertificateFile(uuid string, fileDigest *FileDigest) error {
+    // Records the file a certificate was built from
+
+    _, err := dao.auditedExec("certificateFiles", uuid, AuditInsert,
+        "INSERT OR REPLACE INTO certificateFiles (uuid, name, size, path, algorithm, digest) VALUES (?, ?, ?, ?, ?, ?)", uuid, fileDigest.Name, fileDigest.Size, fileDigest.Path, fileDigest.Algorithm, fileDigest.Digest)
+    return err
+}
 
-    statement, _ := dao.Db.Prepare("DELETE FROM secrets WHERE uuid = ?")
-    _, err := statement.Exec(uuid)
+func (dao *DatabaseDAO) GetCertificateFile(uuid string) (*FileDigest, error) {
+    // Returns the file a certificate was built from
+
+    fileDigest := &FileDigest{}
+    err := dao.Db.QueryRow("SELECT name, size, path, algorithm, digest FROM certificateFiles WHERE uuid = ?", uuid).
+        Scan(&fileDigest.Name, &fileDigest.Size, &fileDigest.Path, &fileDigest.Algorithm, &fileDigest.Digest)
+    if err == sql.ErrNoRows {
+        return nil, ErrNotFound
+    }
     if err != nil {
-        fmt.Println("Error deleting from DB", err)
+        return nil, err
     }
+    return fileDigest, nil
+}
+
+func (dao *DatabaseDAO) RemoveSecret(uuid string) error {
+    // Removes a secret with given UUID from the DB
+
+    return dao.removeEntry("secrets", uuid)
 }
 
 func (dao *DatabaseDAO) GetSignatureAndSigner(uuid string) ([]byte, []byte, error) {
     // Returns the corresponding signature and signer to a certificate UUID
-    statement, _ := dao.Db.Prepare("SELECT signature, signer FROM certificates WHERE uuid = ?")
-    rows, _ := statement.Query(uuid)
-    defer func() {
-        _ = rows.Close()
-    }()
 
-    if rows.Next() {
-        var signature string
-        var signer string
-        _ = rows.Scan(&signature, &signer)
-        return []byte(signature), []byte(signer), nil
+    var signature, signer string
+    err := dao.Db.QueryRow("SELECT signature, signer FROM certificates WHERE uuid = ?", uuid).Scan(&signature, &signer)
+    if err == sql.ErrNoRows {
+        return nil, nil, ErrNotFound
+    }
+    if err != nil {
+        return nil, nil, err
+    }
+    return []byte(signature), []byte(signer), nil
+}
+
+func (dao *DatabaseDAO) GetSecretDecryptingKey(uuid string) (string, error) {
+    // Returns the recipient private key corresponding to a secrets transaction, decrypted by the keystore
+
+    var privKey string
+    err := dao.Db.QueryRow("SELECT recipientPrivateKey FROM secrets WHERE uuid = ?", uuid).Scan(&privKey)
+    if err == sql.ErrNoRows {
+        return "", ErrNotFound
+    }
+    if err != nil {
+        return "", err
+    }
+    if !IsEncrypted(privKey) {
+        // Row not migrated yet
+        return privKey, nil
+    }
+    plainKey, err := dao.Keystore.Decrypt(privKey)
+    if err != nil {
+        return "", err
+    }
+    return string(plainKey), nil
+}
+
+func (dao *DatabaseDAO) Unlock(passphrase string) (int, error) {
+    // Unlocks the keystore for the session and encrypts the recipient keys still stored in clear
+
+    if err := dao.Keystore.Unlock(passphrase); err != nil {
+        return 0, err
+    }
+    return dao.encryptPlaintextSecrets()
+}
+
+func (dao *DatabaseDAO) encryptPlaintextSecrets() (int, error) {
+    // Re-encrypts the recipient private keys written in clear by previous versions
+
+    rows, err := dao.Db.Query("SELECT uuid, recipientPrivateKey FROM secrets")
+    if err != nil {
+        return 0, err
+    }
+    plaintextKeys := map[string]string{}
+    for rows.Next() {
+        var uuid, privKey string
+        if err := rows.Scan(&uuid, &privKey); err != nil {
+            _ = rows.Close()
+            return 0, err
+        }
+        if privKey != "" && !IsEncrypted(privKey) {
+            plaintextKeys[uuid] = privKey
+        }
+    }
+    _ = rows.Close()
+
+    auditMutex.Lock()
+    defer auditMutex.Unlock()
+    transaction, err := dao.Db.Begin()
+    if err != nil {
+        return 0, err
     }
-    return nil, nil, fmt.Errorf("no such uuid in the database")
+    for uuid, privKey := range plaintextKeys {
+        encryptedKey, err := dao.Keystore.Encrypt([]byte(privKey))
+        if err != nil {
+            _ = transaction.Rollback()
+            return 0, err
+        }
+        if _, err := transaction.Exec("UPDATE secrets SET recipientPrivateKey = ? WHERE uuid = ?", encryptedKey, uuid); err != nil {
+            _ = transaction.Rollback()
+            return 0, err
+        }
+        if err := appendAudit(transaction, "secrets", uuid, AuditUpdate); err != nil {
+            _ = transaction.Rollback()
+            return 0, err
+        }
+    }
+    return len(plaintextKeys), transaction.Commit()
 }
 
-func (dao *DatabaseDAO) GetSecretDecryptingKey(uuid string) string {
-    // Returns the recipient private key corresponding to a secrets transaction
-    statement, _ := dao.Db.Prepare("SELECT recipientPrivateKey FROM secrets WHERE uuid = ?")
-    rows, _ := statement.Query(uuid)
+func (dao *DatabaseDAO) entryOptions(table string, addOption string) ([]string, error) {
+    // Returns the UUIDs of a table, each one followed by its status badge, and the adding option last
+
+    rows, err := dao.Db.Query("SELECT uuid, status FROM " + table + " ORDER BY createdAt, uuid")
+    if err != nil {
+        return nil, err
+    }
     defer func() {
         _ = rows.Close()
     }()
 
-    if rows.Next() {
-        var privKey string
-        _ = rows.Scan(&privKey)
-        return privKey
+    var result []string
+    for rows.Next() {
+        var uuid, status string
+        if err := rows.Scan(&uuid, &status); err != nil {
+            return nil, err
+        }
+        result = append(result, OptionLabel(uuid, status))
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
     }
-    return ""
+    return append(result, addOption), nil
 }
 
-func (dao *DatabaseDAO) UpdateCertificateOptions() []string {
+func (dao *DatabaseDAO) UpdateCertificateOptions() ([]string, error) {
     // Gets and returns the []string of certificate UUIDS in the DB
 
-    // Get count of uuids
-    row := dao.Db.QueryRow("SELECT COUNT(DISTINCT uuid) FROM certificates")
-    var count int
-    _ = row.Scan(&count)
-    result := make([]string, count+1) // Count+1 to have a place for the adding option
-    // Fill the slice
-    rows, _ := dao.Db.Query("SELECT DISTINCT uuid FROM certificates")
-    var uuid string
-    i := 0
-    for rows.Next() {
-        _ = rows.Scan(&uuid)
-        result[i] = uuid
-        i++
-    }
-    // Add option last
-    result[i] = "Add certificate..."
-    return result
+    return dao.entryOptions("certificates", AddCertificateOption)
 }
 
-func (dao *DatabaseDAO) UpdateSecretOptions() []string {
+func (dao *DatabaseDAO) UpdateSecretOptions() ([]string, error) {
     // Gets and returns the []string of secrets UUIDS in the DB
 
-    // Get count of secrets
-    row := dao.Db.QueryRow("SELECT COUNT(DISTINCT uuid) FROM secrets")
-    var count int
-    _ = row.Scan(&count)
-    result := make([]string, count+1) // Count+1 to have a place for the adding option
-    // Fill the slice
-    rows, _ := dao.Db.Query("SELECT DISTINCT uuid FROM secrets")
-    var uuid string
-    i := 0
-    for rows.Next() {
-        _ = rows.Scan(&uuid)
-        result[i] = uuid
-        i++
+    return dao.entryOptions("secrets", AddSecretOption)
+}
+
+func (dao *DatabaseDAO) SetStatus(kind string, uuid string, status string) error {
+    // Records the transaction status of a certificate or secret entry
+
+    table, err := entryTable(kind)
+    if err != nil {
+        return err
+    }
+    result, err := dao.auditedExec(table, uuid, AuditUpdate, "UPDATE "+table+" SET status = ?, updatedAt = ? WHERE uuid = ?", status, now(), uuid)
+    if err != nil {
+        return err
+    }
+    if count, _ := result.RowsAffected(); count == 0 {
+        return ErrNotFound
     }
-    // Add option last
-    result[i] = "Add secret..."
-    return result
+    return nil
+}
+
+func (dao *DatabaseDAO) GetStatus(kind string, uuid string) (string, error) {
+    // Returns the transaction status of a certificate or secret entry
 
+    table, err := entryTable(kind)
+    if err != nil {
+        return "", err
+    }
+    var status string
+    err = dao.Db.QueryRow("SELECT status FROM "+table+" WHERE uuid = ?", uuid).Scan(&status)
+    if err == sql.ErrNoRows {
+        return "", ErrNotFound
+    }
+    return status, err
 }
@@ -0,0 +1,174 @@
+package libs
+
+import (
+    "crypto/sha256"
+    "crypto/sha512"
+    "encoding/hex"
+    "fmt"
+    "hash"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+
+    "github.com/google/uuid"
+    "golang.org/x/crypto/blake2b"
+)
+
+// Hash algorithms a file can be certified with, the tag prefixing the digest in the certificate signature
+const (
+    HashSHA256  = "sha256"
+    HashSHA512  = "sha512"
+    HashBLAKE2b = "blake2b"
+)
+
+var HashAlgorithms = []string{HashSHA256, HashSHA512, HashBLAKE2b}
+
+type FileDigest struct {
+    Name      string `json:"name"`
+    Size      int64  `json:"size"`
+    Path      string `json:"path"`
+    Algorithm string `json:"algorithm"`
+    Digest    string `json:"digest"`
+}
+
+type FileVerification struct {
+    Path      string `json:"path"`
+    Algorithm string `json:"algorithm"`
+    Expected  string `json:"expected"`
+    Actual    string `json:"actual"`
+    Match     bool   `json:"match"`
+}
+
+func newHash(algorithm string) (hash.Hash, error) {
+    // Returns a hash function by its tag
+
+    switch algorithm {
+    case HashSHA256:
+        return sha256.New(), nil
+    case HashSHA512:
+        return sha512.New(), nil
+    case HashBLAKE2b:
+        return blake2b.New512(nil)
+    default:
+        return nil, fmt.Errorf("unknown hash algorithm: %s", algorithm)
+    }
+}
+
+func HashFile(path string, algorithm string) (*FileDigest, error) {
+    // Hashes a file and returns its digest along with its name, size and absolute path
+
+    hashFunction, err := newHash(algorithm)
+    if err != nil {
+        return nil, err
+    }
+    absolutePath, err := filepath.Abs(path)
+    if err != nil {
+        return nil, err
+    }
+    file, err := os.Open(absolutePath)
+    if err != nil {
+        return nil, err
+    }
+    defer func() {
+        _ = file.Close()
+    }()
+
+    info, err := file.Stat()
+    if err != nil {
+        return nil, err
+    }
+    if info.IsDir() {
+        return nil, fmt.Errorf("%s is a directory", path)
+    }
+    size, err := io.Copy(hashFunction, file)
+    if err != nil {
+        return nil, err
+    }
+
+    return &FileDigest{
+        Name:      filepath.Base(absolutePath),
+        Size:      size,
+        Path:      absolutePath,
+        Algorithm: algorithm,
+        Digest:    hex.EncodeToString(hashFunction.Sum(nil)),
+    }, nil
+}
+
+func (fileDigest *FileDigest) Signature() string {
+    // Returns the certificate signature of the file : the algorithm tag and the hex digest
+
+    return fileDigest.Algorithm + ":" + fileDigest.Digest
+}
+
+func ParseFileSignature(signature string) (string, string, error) {
+    // Splits a certificate signature built by FileDigest.Signature into its algorithm and digest
+
+    parts := strings.SplitN(signature, ":", 2)
+    if len(parts) != 2 {
+        return "", "", fmt.Errorf("the certificate signature is not a file digest")
+    }
+    if _, err := newHash(parts[0]); err != nil {
+        return "", "", err
+    }
+    if _, err := hex.DecodeString(parts[1]); err != nil {
+        return "", "", fmt.Errorf("the certificate signature is not a file digest")
+    }
+    return parts[0], strings.ToLower(parts[1]), nil
+}
+
+func NewFileCertificate(config Config, path string, algorithm string, signer string) (*CertificateHandler, *FileDigest, error) {
+    // Hashes a file and builds the transaction certifying it under a new UUID
+
+    fileDigest, err := HashFile(path, algorithm)
+    if err != nil {
+        return nil, nil, err
+    }
+    certificateUuid, err := uuid.NewRandom()
+    if err != nil {
+        return nil, nil, err
+    }
+
+    certHandler := &CertificateHandler{
+        Config:        config,
+        UuidText:      certificateUuid.String(),
+        SignatureText: fileDigest.Signature(),
+        SignerText:    signer,
+    }
+    if err := certHandler.BuildTransaction(); err != nil {
+        return nil, nil, err
+    }
+    return certHandler, fileDigest, nil
+}
+
+func (certHandler *CertificateHandler) VerifyFile(path string) (*FileVerification, error) {
+    // Re-hashes a file with the algorithm of the on-chain certificate and compares the digests
+
+    transactionWrapper, err := certHandler.GetCertificate()
+    if err != nil {
+        return nil, err
+    }
+    certificate, err := CertificateOf(transactionWrapper.Transaction)
+    if err != nil {
+        return nil, err
+    }
+    if certificate.Seal == nil {
+        return nil, fmt.Errorf("the certificate has no data seal")
+    }
+    algorithm, expected, err := ParseFileSignature(string(certificate.Seal.Signature))
+    if err != nil {
+        return nil, err
+    }
+    fileDigest, err := HashFile(path, algorithm)
+    if err != nil {
+        return nil, err
+    }
+
+    return &FileVerification{
+        Path:      fileDigest.Path,
+        Algorithm: algorithm,
+        Expected:  expected,
+        Actual:    fileDigest.Digest,
+        Match:     expected == fileDigest.Digest,
+    }, nil
+}
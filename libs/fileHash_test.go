@@ -0,0 +1,84 @@
+package libs
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/katena-chain/transactor-ui/libs/testutil"
+)
+
+func TestHashEmptyFile(t *testing.T) {
+    directory, removeDirectory := testutil.TempDir(t)
+    defer removeDirectory()
+    path := filepath.Join(directory, "empty.txt")
+    if err := ioutil.WriteFile(path, nil, 0600); err != nil {
+        t.Fatal(err)
+    }
+
+    // Digests of the empty input
+    tests := []struct {
+        algorithm string
+        digest    string
+    }{
+        {HashSHA256, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+        {HashSHA512, "cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e"},
+        {HashBLAKE2b, "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce"},
+    }
+    for _, test := range tests {
+        fileDigest, err := HashFile(path, test.algorithm)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if fileDigest.Size != 0 || fileDigest.Name != "empty.txt" || fileDigest.Path != path || fileDigest.Digest != test.digest {
+            t.Errorf("unexpected %s digest: %+v", test.algorithm, fileDigest)
+        }
+        algorithm, digest, err := ParseFileSignature(fileDigest.Signature())
+        if err != nil || algorithm != test.algorithm || digest != test.digest {
+            t.Errorf("signature %s parsed as %s, %s: %v", fileDigest.Signature(), algorithm, digest, err)
+        }
+    }
+
+    if _, err := HashFile(path, "md5"); err == nil {
+        t.Fatal("unknown algorithm accepted")
+    }
+    if _, err := HashFile(directory, HashSHA256); err == nil {
+        t.Fatal("directory hashed")
+    }
+}
+
+func TestHashLargeFile(t *testing.T) {
+    // A file is hashed as a stream, its size counted while it is read
+
+    file, err := ioutil.TempFile("", "transactor-ui-test")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer func() {
+        _ = os.Remove(file.Name())
+    }()
+    chunk := bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+    expected := sha256.New()
+    for i := 0; i < 40; i++ {
+        chunk[0] = byte(i)
+        if _, err := file.Write(chunk); err != nil {
+            t.Fatal(err)
+        }
+        expected.Write(chunk)
+    }
+    if err := file.Close(); err != nil {
+        t.Fatal(err)
+    }
+
+    fileDigest, err := HashFile(file.Name(), HashSHA256)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if fileDigest.Size != 40*int64(len(chunk)) || fileDigest.Digest != hex.EncodeToString(expected.Sum(nil)) {
+        t.Fatalf("unexpected digest of %d bytes: %s", fileDigest.Size, fileDigest.Digest)
+    }
+}
@@ -0,0 +1,177 @@
+package libs
+
+import (
+    "database/sql"
+    "encoding/json"
+    "fmt"
+    "io"
+
+    "github.com/google/uuid"
+)
+
+// Identifies hand-off bundles, the version being increased whenever their layout changes
+const HandoffFormat = "transactor-ui-handoff"
+const HandoffVersion = 1
+
+// What a hand-off bundle gives its recipient, sealed under the passphrase
+type HandoffKey struct {
+    Uuid                string `json:"uuid"`
+    CompanyChainID      string `json:"company_chain_id"`
+    RecipientPrivateKey string `json:"recipient_private_key"`
+}
+
+// File handing the recipient private key generated for a secret over to its recipient. Nothing but the format is
+// readable without the passphrase, so the bundle does not tell which secret it opens.
+type HandoffBundle struct {
+    Format     string            `json:"format"`
+    Version    int               `json:"version"`
+    CreatedAt  string            `json:"created_at"`
+    Encryption *BackupEncryption `json:"encryption"`
+    Sealed     string            `json:"sealed"`
+}
+
+func (key *HandoffKey) check() error {
+    // Checks the UUID and the recipient private key of a hand-off
+
+    if _, err := uuid.Parse(key.Uuid); err != nil {
+        return fmt.Errorf("invalid UUID %s: %s", key.Uuid, err)
+    }
+    if key.CompanyChainID == "" {
+        return fmt.Errorf("missing company chain id")
+    }
+    if _, err := PublicKeyFromPrivate(KeyTypeX25519, key.RecipientPrivateKey); err != nil {
+        return fmt.Errorf("invalid recipient private key: %s", err)
+    }
+    return nil
+}
+
+func NewHandoffBundle(key HandoffKey, passphrase string) (*HandoffBundle, error) {
+    // Seals a UUID, the company it is sent from and its recipient private key under a passphrase
+
+    if err := key.check(); err != nil {
+        return nil, err
+    }
+    encryption, handoffKeystore, err := newBackupEncryption(passphrase)
+    if err != nil {
+        return nil, err
+    }
+    defer handoffKeystore.Lock()
+    plaintext, err := json.Marshal(key)
+    if err != nil {
+        return nil, err
+    }
+    sealed, err := handoffKeystore.Encrypt(plaintext)
+    if err != nil {
+        return nil, err
+    }
+    return &HandoffBundle{
+        Format:     HandoffFormat,
+        Version:    HandoffVersion,
+        CreatedAt:  now(),
+        Encryption: encryption,
+        Sealed:     sealed,
+    }, nil
+}
+
+func GenerateHandoff(uuidText string, companyChainID string, passphrase string) (string, *HandoffBundle, error) {
+    // Generates the recipient key pair of a secret, returning its public key and the bundle handing its private key
+    // over to the recipient
+
+    publicKey, privateKey, err := GenerateKeyPair(KeyTypeX25519)
+    if err != nil {
+        return "", nil, err
+    }
+    bundle, err := NewHandoffBundle(HandoffKey{uuidText, companyChainID, privateKey}, passphrase)
+    if err != nil {
+        return "", nil, err
+    }
+    return publicKey, bundle, nil
+}
+
+func (bundle *HandoffBundle) Open(passphrase string) (*HandoffKey, error) {
+    // Checks the passphrase of a bundle and returns what it hands off
+
+    if bundle.Encryption == nil {
+        return nil, fmt.Errorf("the hand-off bundle is not encrypted")
+    }
+    if err := bundle.Encryption.check(); err != nil {
+        return nil, fmt.Errorf("invalid hand-off bundle: %s", err)
+    }
+    if passphrase == "" {
+        return nil, fmt.Errorf("the hand-off bundle is sealed, its passphrase is needed")
+    }
+    handoffKeystore, err := bundle.Encryption.open(passphrase)
+    if err != nil {
+        return nil, fmt.Errorf("wrong hand-off passphrase")
+    }
+    defer handoffKeystore.Lock()
+    plaintext, err := handoffKeystore.Decrypt(bundle.Sealed)
+    if err != nil {
+        return nil, err
+    }
+    key := &HandoffKey{}
+    if err := json.Unmarshal(plaintext, key); err != nil {
+        return nil, fmt.Errorf("invalid hand-off bundle: %s", err)
+    }
+    if err := key.check(); err != nil {
+        return nil, fmt.Errorf("invalid hand-off bundle: %s", err)
+    }
+    return key, nil
+}
+
+func (bundle *HandoffBundle) Write(writer io.Writer) error {
+    // Writes the indented JSON representation of a bundle
+
+    encoder := json.NewEncoder(writer)
+    encoder.SetIndent("", "    ")
+    return encoder.Encode(bundle)
+}
+
+func ReadHandoffBundle(reader io.Reader) (*HandoffBundle, error) {
+    // Reads a bundle and checks it can be understood by this version of the application
+
+    bundle := &HandoffBundle{}
+    if err := json.NewDecoder(reader).Decode(bundle); err != nil {
+        return nil, fmt.Errorf("invalid hand-off bundle: %s", err)
+    }
+    if bundle.Format != HandoffFormat {
+        return nil, fmt.Errorf("not a transactor-ui hand-off bundle")
+    }
+    if bundle.Version < 1 || bundle.Version > HandoffVersion {
+        return nil, fmt.Errorf("hand-off bundle version %d is not supported by this version of the application (%d)", bundle.Version, HandoffVersion)
+    }
+    return bundle, nil
+}
+
+func (dao *DatabaseDAO) ImportHandoff(key *HandoffKey, configuredCompanyChainID string, profile string) error {
+    // Tracks the secret of a hand-off with its recipient private key, encrypted by the keystore. A secret already
+    // tracked without key, from a lookup, gets the key of the bundle.
+
+    companyChainID := key.CompanyChainID
+    if companyChainID == configuredCompanyChainID {
+        companyChainID = ""
+    }
+    encryptedKey, err := dao.Keystore.Encrypt([]byte(key.RecipientPrivateKey))
+    if err != nil {
+        return err
+    }
+
+    var storedKey string
+    err = dao.Db.QueryRow("SELECT recipientPrivateKey FROM secrets WHERE uuid = ?", key.Uuid).Scan(&storedKey)
+    switch {
+    case err == sql.ErrNoRows:
+        _, err = dao.auditedExec("secrets", key.Uuid, AuditInsert,
+            "INSERT INTO secrets (uuid, recipientPrivateKey, status, requestDigest, profile, companyChainID, createdAt, updatedAt) "+
+                "VALUES (?, ?, '', '', ?, ?, ?, ?)",
+            key.Uuid, encryptedKey, profile, companyChainID, now(), now())
+        return err
+    case err != nil:
+        return err
+    case storedKey != "":
+        return fmt.Errorf("a secret for %s is already in the database with its recipient key", key.Uuid)
+    default:
+        _, err = dao.auditedExec("secrets", key.Uuid, AuditUpdate,
+            "UPDATE secrets SET recipientPrivateKey = ?, updatedAt = ? WHERE uuid = ?", encryptedKey, now(), key.Uuid)
+        return err
+    }
+}
@@ -0,0 +1,42 @@
+package libs
+
+import (
+    "bytes"
+    "strings"
+    "testing"
+
+    "github.com/katena-chain/transactor-ui/libs/testutil"
+)
+
+func TestHandoffBundleBounds(t *testing.T) {
+    _, bundle, err := GenerateHandoff(testutil.Uuid, testutil.CompanyChainID, testutil.Passphrase)
+    if err != nil {
+        t.Fatal(err)
+    }
+    key, err := bundle.Open(testutil.Passphrase)
+    if err != nil {
+        t.Fatalf("valid bundle rejected: %s", err)
+    }
+    if key.Uuid != testutil.Uuid || key.CompanyChainID != testutil.CompanyChainID {
+        t.Fatalf("unexpected key %+v", key)
+    }
+
+    for _, test := range tamperedEncryptions {
+        t.Run(test.name, func(t *testing.T) {
+            tampered := *bundle.Encryption
+            test.tamper(&tampered)
+            var buffer bytes.Buffer
+            if err := (&HandoffBundle{Format: bundle.Format, Version: bundle.Version, CreatedAt: bundle.CreatedAt,
+                Encryption: &tampered, Sealed: bundle.Sealed}).Write(&buffer); err != nil {
+                t.Fatal(err)
+            }
+            read, err := ReadHandoffBundle(&buffer)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if _, err := read.Open(testutil.Passphrase); err == nil || !strings.HasPrefix(err.Error(), "invalid hand-off bundle") {
+                t.Fatalf("unexpected error: %v", err)
+            }
+        })
+    }
+}
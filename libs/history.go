@@ -0,0 +1,281 @@
+package libs
+
+import (
+    "database/sql"
+    "fmt"
+    "strings"
+    "time"
+
+    entityApi "github.com/katena-chain/sdk-go-client/entity/api"
+)
+
+// Columns the history can be sorted by, the first one being the default
+var HistorySortColumns = []string{"date", "kind", "uuid", "status", "code", "profile"}
+
+var historySortColumns = map[string]string{
+    "date":    "createdAt",
+    "kind":    "kind",
+    "uuid":    "uuid",
+    "status":  "status",
+    "code":    "code",
+    "profile": "profile",
+}
+
+// Transaction broadcast by the application, with the exact bytes sent and what the API and the chain answered
+type HistoryEntry struct {
+    Id             int64  `json:"id"`
+    Profile        string `json:"profile"`
+    ChainID        string `json:"chain_id"`
+    ApiUrl         string `json:"api_url"`
+    MessageType    string `json:"message_type"`
+    Kind           string `json:"kind"`
+    Uuid           string `json:"uuid"`
+    CompanyChainID string `json:"company_chain_id"`
+    NonceTime      string `json:"nonce_time"`
+    SignedBytes    string `json:"signed_bytes"`
+    RequestDigest  string `json:"request_digest"`
+    Code           uint32 `json:"code"`
+    Message        string `json:"message"`
+    Status         string `json:"status"`
+    CreatedAt      string `json:"created_at"`
+    UpdatedAt      string `json:"updated_at"`
+}
+
+type HistoryFilter struct {
+    // Part of the UUID, local request digest, company chain id or status message
+    Search  string
+    Kind    string
+    Status  string
+    Profile string
+    // Only the transactions sent at or after this time, when set
+    Since      time.Time
+    SortBy     string
+    Descending bool
+    // Maximum number of transactions returned, unlimited if not positive
+    Limit int
+}
+
+// Error returned along with the status of a transaction the API answered but the history could not record, the
+// entry of the transaction having to be recorded all the same
+type HistoryError struct {
+    RequestDigest string
+    Err           error
+}
+
+func (historyErr *HistoryError) Error() string {
+    return fmt.Sprintf("transaction with request digest %s sent, history not recorded: %s", historyErr.RequestDigest, historyErr.Err)
+}
+
+func (config Config) Broadcast(signed *SignedTransaction) (*entityApi.TransactionStatus, error) {
+    // Broadcasts a signed transaction to the configured API and records it in the history when the configuration
+    // has one, even if it could not be sent. A status is returned whenever the API answered, with a HistoryError
+    // if the history could not record it.
+
+    transactionStatus, err := signed.Broadcast(config.ApiUrl)
+    if config.History == nil {
+        return transactionStatus, err
+    }
+    if historyErr := config.History.RecordTransaction(config, signed, transactionStatus, err); historyErr != nil && err == nil {
+        return transactionStatus, &HistoryError{RequestDigest: signed.Digest(), Err: historyErr}
+    }
+    return transactionStatus, err
+}
+
+func (dao *DatabaseDAO) RecordTransaction(config Config, signed *SignedTransaction, transactionStatus *entityApi.TransactionStatus, sendErr error) error {
+    // Adds a broadcast transaction to the history, pending if the API accepted it and failed otherwise
+
+    entry := &TransactionEntry{}
+    if described, err := signed.Entry(); err == nil {
+        entry = described
+    }
+    var messageType, nonceTime string
+    if signed.Transaction.Message != nil {
+        messageType = signed.Transaction.Message.GetType()
+    }
+    if signed.Transaction.NonceTime != nil {
+        nonceTime = signed.Transaction.NonceTime.UTC().Format(time.RFC3339)
+    }
+    var code uint32
+    var message string
+    status := StatusPending
+    switch {
+    case sendErr != nil:
+        message, status = sendErr.Error(), StatusFailed
+    case transactionStatus != nil:
+        code, message = transactionStatus.Code, transactionStatus.Message
+        if code != 0 {
+            status = StatusFailed
+        }
+    }
+
+    timestamp := now()
+    _, err := dao.auditedExec("transactions", "", AuditInsert, "INSERT INTO transactions (profile, chainID, apiUrl, messageType, kind, "+
+        "uuid, companyChainID, nonceTime, signedBytes, requestDigest, code, message, status, createdAt, updatedAt) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+        config.Profile, config.ChainID, config.ApiUrl, messageType, entry.Kind, entry.Uuid, entry.CompanyChainID, nonceTime,
+        string(signed.Bytes), signed.Digest(), code, message, status, timestamp, timestamp)
+    return err
+}
+
+func (dao *DatabaseDAO) SetTransactionStatus(requestDigest string, status string, transactionStatus *entityApi.TransactionStatus) error {
+    // Records the status of the history transactions with a hash, along with the code and message of the chain
+    // when it answered
+
+    rows, err := dao.Db.Query("SELECT id FROM transactions WHERE requestDigest = ?", requestDigest)
+    if err != nil {
+        return err
+    }
+    var ids []string
+    for rows.Next() {
+        var id string
+        if err := rows.Scan(&id); err != nil {
+            _ = rows.Close()
+            return err
+        }
+        ids = append(ids, id)
+    }
+    _ = rows.Close()
+
+    // Each row is updated on its own to be chained in the audit log
+    for _, id := range ids {
+        if transactionStatus == nil {
+            _, err = dao.auditedExec("transactions", id, AuditUpdate, "UPDATE transactions SET status = ?, updatedAt = ? WHERE id = ?", status, now(), id)
+        } else {
+            _, err = dao.auditedExec("transactions", id, AuditUpdate, "UPDATE transactions SET status = ?, code = ?, message = ?, updatedAt = ? WHERE id = ?",
+                status, transactionStatus.Code, transactionStatus.Message, now(), id)
+        }
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func (dao *DatabaseDAO) ListTransactions(filter HistoryFilter) ([]HistoryEntry, error) {
+    // Returns the history transactions matching a filter, in the requested order
+
+    sortBy := filter.SortBy
+    if sortBy == "" {
+        sortBy = HistorySortColumns[0]
+    }
+    column, ok := historySortColumns[sortBy]
+    if !ok {
+        return nil, fmt.Errorf("cannot sort the history by %s, expected one of %s", sortBy, strings.Join(HistorySortColumns, ", "))
+    }
+    order := " ASC"
+    if filter.Descending {
+        order = " DESC"
+    }
+
+    var conditions []string
+    var args []interface{}
+    if filter.Search != "" {
+        pattern := "%" + filter.Search + "%"
+        conditions = append(conditions, "(uuid LIKE ? OR requestDigest LIKE ? OR companyChainID LIKE ? OR message LIKE ?)")
+        args = append(args, pattern, pattern, pattern, pattern)
+    }
+    if filter.Kind != "" {
+        conditions = append(conditions, "kind = ?")
+        args = append(args, filter.Kind)
+    }
+    if filter.Status != "" {
+        conditions = append(conditions, "status = ?")
+        args = append(args, filter.Status)
+    }
+    if filter.Profile != "" {
+        conditions = append(conditions, "profile = ?")
+        args = append(args, filter.Profile)
+    }
+    if !filter.Since.IsZero() {
+        conditions = append(conditions, "createdAt >= ?")
+        args = append(args, filter.Since.UTC().Format(time.RFC3339))
+    }
+
+    query := "SELECT id, profile, chainID, apiUrl, messageType, kind, uuid, companyChainID, nonceTime, signedBytes, requestDigest, " +
+        "code, message, status, createdAt, updatedAt FROM transactions"
+    if len(conditions) > 0 {
+        query += " WHERE " + strings.Join(conditions, " AND ")
+    }
+    // The id keeps the transactions sent in the same second in order
+    query += " ORDER BY " + column + order + ", id" + order
+    if filter.Limit > 0 {
+        query += " LIMIT ?"
+        args = append(args, filter.Limit)
+    }
+
+    rows, err := dao.Db.Query(query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer func() {
+        _ = rows.Close()
+    }()
+    var entries []HistoryEntry
+    for rows.Next() {
+        entry, err := scanHistoryEntry(rows)
+        if err != nil {
+            return nil, err
+        }
+        entries = append(entries, *entry)
+    }
+    return entries, rows.Err()
+}
+
+func (dao *DatabaseDAO) GetTransaction(id int64) (*HistoryEntry, error) {
+    // Returns a history transaction by its id
+
+    row := dao.Db.QueryRow("SELECT id, profile, chainID, apiUrl, messageType, kind, uuid, companyChainID, nonceTime, "+
+        "signedBytes, requestDigest, code, message, status, createdAt, updatedAt FROM transactions WHERE id = ?", id)
+    entry, err := scanHistoryEntry(row)
+    if err == sql.ErrNoRows {
+        return nil, fmt.Errorf("no transaction %d in the history", id)
+    }
+    return entry, err
+}
+
+func scanHistoryEntry(row interface{ Scan(...interface{}) error }) (*HistoryEntry, error) {
+    // Reads a transactions row, the columns added by later versions being possibly NULL
+
+    var profile, chainID, apiUrl, messageType, kind, uuid, companyChainID, nonceTime, signedBytes, requestDigest, message sql.NullString
+    var code sql.NullInt64
+    entry := &HistoryEntry{}
+    err := row.Scan(&entry.Id, &profile, &chainID, &apiUrl, &messageType, &kind, &uuid, &companyChainID, &nonceTime,
+        &signedBytes, &requestDigest, &code, &message, &entry.Status, &entry.CreatedAt, &entry.UpdatedAt)
+    if err != nil {
+        return nil, err
+    }
+    entry.Profile, entry.ChainID, entry.ApiUrl, entry.MessageType = profile.String, chainID.String, apiUrl.String, messageType.String
+    entry.Kind, entry.Uuid, entry.CompanyChainID, entry.NonceTime = kind.String, uuid.String, companyChainID.String, nonceTime.String
+    entry.SignedBytes, entry.RequestDigest, entry.Code, entry.Message = signedBytes.String, requestDigest.String, uint32(code.Int64), message.String
+    return entry, nil
+}
+
+func LocalTime(timestamp string) string {
+    // Returns a stored timestamp in local time for display, or the timestamp itself if it cannot be parsed
+
+    parsed, err := time.Parse(time.RFC3339, timestamp)
+    if err != nil {
+        return timestamp
+    }
+    return parsed.Local().Format(DisplayTimeFormat)
+}
+
+func (entry *HistoryEntry) Fields() []SummaryField {
+    // Returns the recorded values of a history transaction, in the order they are shown
+
+    return []SummaryField{
+        {"Id", fmt.Sprint(entry.Id)},
+        {"Sent at", LocalTime(entry.CreatedAt)},
+        {"Updated at", LocalTime(entry.UpdatedAt)},
+        {"Profile", entry.Profile},
+        {"Chain id", entry.ChainID},
+        {"API URL", entry.ApiUrl},
+        {"Type", entry.MessageType},
+        {"UUID", entry.Uuid},
+        {"Company chain id", entry.CompanyChainID},
+        {"Nonce time", LocalTime(entry.NonceTime)},
+        {"Request digest (local, not on chain)", entry.RequestDigest},
+        {"Status", entry.Status},
+        {"Code", fmt.Sprint(entry.Code)},
+        {"Message", entry.Message},
+    }
+}
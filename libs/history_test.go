@@ -0,0 +1,35 @@
+package libs
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/katena-chain/transactor-ui/libs/testutil"
+)
+
+func TestBroadcastWithoutHistory(t *testing.T) {
+    // A transaction the API accepted comes back with its status when the history cannot record it
+
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        writer.WriteHeader(http.StatusAccepted)
+        _, _ = writer.Write([]byte(`{"code":0,"message":"accepted"}`))
+    }))
+    defer server.Close()
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+    if _, err := dao.Db.Exec("DROP TABLE transactions"); err != nil {
+        t.Fatal(err)
+    }
+
+    config := Config{ChainID: testutil.ChainID, CompanyChainID: testutil.CompanyChainID, ApiUrl: server.URL, History: dao}
+    signed := newTestSignedCertificate(t)
+    transactionStatus, err := config.Broadcast(signed)
+    if transactionStatus == nil || transactionStatus.Message != "accepted" {
+        t.Fatalf("status lost: %v, %v", transactionStatus, err)
+    }
+    historyErr, ok := err.(*HistoryError)
+    if !ok || historyErr.RequestDigest != signed.Digest() {
+        t.Fatalf("unexpected error: %v", err)
+    }
+}
@@ -0,0 +1,353 @@
+package libs
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/katena-chain/sdk-go-client/crypto/X25519"
+    entityApi "github.com/katena-chain/sdk-go-client/entity/api"
+    "github.com/katena-chain/sdk-go-client/utils"
+)
+
+// Length of the content previews of the inbox
+const InboxPreviewLength = 60
+
+// Where the inbox looks for secrets : a UUID of the configured company, a UUID of another company, or a company
+// whose tracked UUIDs are all looked at, the API having no route listing the UUIDs of a company
+type InboxSource struct {
+    Source         string `json:"source"`
+    CompanyChainID string `json:"company_chain_id"`
+    Uuid           string `json:"uuid"`
+    CreatedAt      string `json:"created_at,omitempty"`
+}
+
+// Secret of the inbox, opened with one of the keystore keys
+type InboxSecret struct {
+    Uuid           string                       `json:"uuid"`
+    CompanyChainID string                       `json:"company_chain_id"`
+    NonceTime      time.Time                    `json:"nonce_time"`
+    Status         *entityApi.TransactionStatus `json:"status"`
+    KeyName        string                       `json:"key_name"`
+    // Name of the contact holding the sender key, if it is in the address book
+    SenderContact     string      `json:"sender_contact,omitempty"`
+    SenderFingerprint string      `json:"sender_fingerprint"`
+    Text              string      `json:"text"`
+    File              *SecretFile `json:"file,omitempty"`
+    Error             string      `json:"error,omitempty"`
+}
+
+// Secrets found for the inbox sources
+type Inbox struct {
+    Secrets []InboxSecret `json:"secrets"`
+    Uuids   int           `json:"uuids"`
+    // Retrieved secrets none of the keys opens, sealed to other recipients
+    Unopened int `json:"unopened"`
+    // Older secrets of the UUIDs, past the last ones the API returns
+    NotRetrieved int      `json:"not_retrieved"`
+    Errors       []string `json:"errors"`
+}
+
+type inboxKey struct {
+    name       string
+    privateKey *X25519.PrivateKey
+}
+
+type inboxTarget struct {
+    companyChainID string
+    uuid           string
+}
+
+func ParseInboxSource(text string) (InboxSource, error) {
+    // Reads an inbox source written UUID, COMPANY/UUID or COMPANY
+
+    text = strings.TrimSpace(text)
+    if text == "" {
+        return InboxSource{}, fmt.Errorf("missing inbox source")
+    }
+    source := InboxSource{CompanyChainID: text}
+    if separator := strings.LastIndex(text, "/"); separator >= 0 {
+        source.CompanyChainID, source.Uuid = strings.TrimSpace(text[:separator]), strings.TrimSpace(text[separator+1:])
+        if source.CompanyChainID == "" {
+            return InboxSource{}, fmt.Errorf("invalid inbox source %s: missing company chain id", text)
+        }
+        parsed, err := uuid.Parse(source.Uuid)
+        if err != nil {
+            return InboxSource{}, fmt.Errorf("invalid inbox source %s: %s", text, err)
+        }
+        source.Uuid = parsed.String()
+    } else if parsed, err := uuid.Parse(text); err == nil {
+        source.CompanyChainID, source.Uuid = "", parsed.String()
+    }
+
+    switch {
+    case source.CompanyChainID == "":
+        source.Source = source.Uuid
+    case source.Uuid == "":
+        source.Source = source.CompanyChainID
+    default:
+        source.Source = source.CompanyChainID + "/" + source.Uuid
+    }
+    return source, nil
+}
+
+func (source *InboxSource) Describe() string {
+    // Returns what the inbox looks at for the source
+
+    switch {
+    case source.Uuid == "":
+        return "the tracked UUIDs of company " + source.CompanyChainID
+    case source.CompanyChainID == "":
+        return source.Uuid + " of the configured company"
+    default:
+        return source.Uuid + " of company " + source.CompanyChainID
+    }
+}
+
+func (dao *DatabaseDAO) AddInboxSource(text string) (*InboxSource, error) {
+    // Adds a UUID or a company to the ones the inbox looks at
+
+    source, err := ParseInboxSource(text)
+    if err != nil {
+        return nil, err
+    }
+    source.CreatedAt = now()
+    _, err = dao.auditedExec("inboxSources", source.Source, AuditInsert,
+        "INSERT INTO inboxSources (source, companyChainID, uuid, createdAt) VALUES (?, ?, ?, ?)",
+        source.Source, source.CompanyChainID, source.Uuid, source.CreatedAt)
+    if isConstraintError(err) {
+        return nil, fmt.Errorf("%s is already an inbox source", source.Source)
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &source, nil
+}
+
+func (dao *DatabaseDAO) RemoveInboxSource(text string) error {
+    // Removes a UUID or a company from the ones the inbox looks at
+
+    source, err := ParseInboxSource(text)
+    if err != nil {
+        return err
+    }
+    result, err := dao.auditedExec("inboxSources", source.Source, AuditDelete, "DELETE FROM inboxSources WHERE source = ?", source.Source)
+    if err != nil {
+        return err
+    }
+    if count, _ := result.RowsAffected(); count == 0 {
+        return fmt.Errorf("%s is not an inbox source", source.Source)
+    }
+    return nil
+}
+
+func (dao *DatabaseDAO) ListInboxSources() ([]InboxSource, error) {
+    // Returns the UUIDs and companies the inbox looks at
+
+    rows, err := dao.Db.Query("SELECT source, companyChainID, uuid, createdAt FROM inboxSources ORDER BY source")
+    if err != nil {
+        return nil, err
+    }
+    defer func() {
+        _ = rows.Close()
+    }()
+
+    var sources []InboxSource
+    for rows.Next() {
+        var source InboxSource
+        if err := rows.Scan(&source.Source, &source.CompanyChainID, &source.Uuid, &source.CreatedAt); err != nil {
+            return nil, err
+        }
+        sources = append(sources, source)
+    }
+    return sources, rows.Err()
+}
+
+func (dao *DatabaseDAO) inboxKeys() ([]inboxKey, error) {
+    // Returns the X25519 keys of the keystore, every one of them being tried on each secret
+
+    if !dao.Keystore.Unlocked() {
+        return nil, fmt.Errorf("unlock the keystore first, the inbox secrets are opened with its keys")
+    }
+    entries, err := dao.Keystore.ListKeys(KeyTypeX25519)
+    if err != nil {
+        return nil, err
+    }
+    if len(entries) == 0 {
+        return nil, fmt.Errorf("no %s key in the keystore to open the inbox secrets with", KeyTypeX25519)
+    }
+    keys := make([]inboxKey, len(entries))
+    for i, entry := range entries {
+        _, privateKey, err := dao.Keystore.GetKey(entry.Name)
+        if err != nil {
+            return nil, err
+        }
+        keys[i].name = entry.Name
+        if keys[i].privateKey, err = utils.CreatePrivateKeyX25519FromBase64(privateKey); err != nil {
+            return nil, fmt.Errorf("key %s: %s", entry.Name, err)
+        }
+    }
+    return keys, nil
+}
+
+func (dao *DatabaseDAO) inboxTargets(sources []InboxSource, configuredCompanyChainID string) ([]inboxTarget, error) {
+    // Returns the company and UUID pairs whose secrets are retrieved, a company standing for the certificates and
+    // secrets tracked from it
+
+    seen := map[inboxTarget]bool{}
+    var targets []inboxTarget
+    add := func(target inboxTarget) {
+        if !seen[target] {
+            seen[target] = true
+            targets = append(targets, target)
+        }
+    }
+
+    for _, source := range sources {
+        if source.Uuid != "" {
+            target := inboxTarget{companyChainID: source.CompanyChainID, uuid: source.Uuid}
+            if target.companyChainID == "" {
+                target.companyChainID = configuredCompanyChainID
+            }
+            add(target)
+            continue
+        }
+        // The entries of the configured company are recorded without it
+        trackedCompanyChainID := source.CompanyChainID
+        if trackedCompanyChainID == configuredCompanyChainID {
+            trackedCompanyChainID = ""
+        }
+        rows, err := dao.Db.Query("SELECT uuid FROM certificates WHERE companyChainID = ? UNION SELECT uuid FROM secrets WHERE companyChainID = ? "+
+            "ORDER BY uuid", trackedCompanyChainID, trackedCompanyChainID)
+        if err != nil {
+            return nil, err
+        }
+        var uuids []string
+        for rows.Next() {
+            var uuidText string
+            if err := rows.Scan(&uuidText); err != nil {
+                _ = rows.Close()
+                return nil, err
+            }
+            uuids = append(uuids, uuidText)
+        }
+        _ = rows.Close()
+        if err := rows.Err(); err != nil {
+            return nil, err
+        }
+        for _, uuidText := range uuids {
+            add(inboxTarget{companyChainID: source.CompanyChainID, uuid: uuidText})
+        }
+    }
+    return targets, nil
+}
+
+func (dao *DatabaseDAO) ReadInbox(config Config, sources []InboxSource) (*Inbox, error) {
+    // Retrieves the secrets of the sources and opens those sealed to one of the keystore keys, the newest first. A
+    // UUID whose secrets cannot be retrieved is reported in the inbox errors.
+
+    keys, err := dao.inboxKeys()
+    if err != nil {
+        return nil, err
+    }
+    targets, err := dao.inboxTargets(sources, config.CompanyChainID)
+    if err != nil {
+        return nil, err
+    }
+    contacts, err := dao.ListContacts("")
+    if err != nil {
+        return nil, err
+    }
+    senders := map[string]string{}
+    for _, contact := range contacts {
+        senders[contact.Fingerprint] = contact.Name
+    }
+
+    inbox := &Inbox{Secrets: []InboxSecret{}, Uuids: len(targets), Errors: []string{}}
+    for _, target := range targets {
+        if target.companyChainID == "" {
+            inbox.Errors = append(inbox.Errors, target.uuid+": missing company chain id")
+            continue
+        }
+        secretData := SecretHandler{Config: config, UuidText: target.uuid}
+        secretData.Config.CompanyChainID = target.companyChainID
+        transactionWrappers, err := secretData.GetSecrets()
+        if err != nil {
+            inbox.Errors = append(inbox.Errors, target.companyChainID+"/"+target.uuid+": "+err.Error())
+            continue
+        }
+        if missing := transactionWrappers.Total - len(transactionWrappers.Transactions); missing > 0 {
+            inbox.NotRetrieved += missing
+        }
+
+        for _, transactionWrapper := range transactionWrappers.Transactions {
+            opened := false
+            for _, key := range keys {
+                decrypted := openSecret(transactionWrapper, key.privateKey)
+                if decrypted.Content == nil {
+                    continue
+                }
+                secret := InboxSecret{
+                    Uuid:              decrypted.Uuid,
+                    CompanyChainID:    decrypted.CompanyChainID,
+                    NonceTime:         decrypted.NonceTime,
+                    Status:            decrypted.Status,
+                    KeyName:           key.name,
+                    SenderContact:     senders[decrypted.SenderFingerprint],
+                    SenderFingerprint: decrypted.SenderFingerprint,
+                    Text:              DisplayBytes(decrypted.Content),
+                    File:              decrypted.File,
+                }
+                if decrypted.File != nil {
+                    secret.Text = decrypted.Text()
+                }
+                if decrypted.Err != nil {
+                    secret.Error = decrypted.Err.Error()
+                }
+                inbox.Secrets = append(inbox.Secrets, secret)
+                opened = true
+                break
+            }
+            if !opened {
+                inbox.Unopened++
+            }
+        }
+    }
+
+    sort.SliceStable(inbox.Secrets, func(i, j int) bool { return inbox.Secrets[i].NonceTime.After(inbox.Secrets[j].NonceTime) })
+    return inbox, nil
+}
+
+func (inbox *Inbox) Summary() string {
+    // Returns the counts of the opened and other secrets
+
+    summary := fmt.Sprintf("%d secrets opened out of %d retrieved for %d UUIDs", len(inbox.Secrets), len(inbox.Secrets)+inbox.Unopened, inbox.Uuids)
+    if inbox.NotRetrieved > 0 {
+        summary += fmt.Sprintf(", %d older ones not retrieved", inbox.NotRetrieved)
+    }
+    if len(inbox.Errors) > 0 {
+        summary += fmt.Sprintf(", %d UUIDs could not be retrieved", len(inbox.Errors))
+    }
+    return summary
+}
+
+func (secret *InboxSecret) Sender() string {
+    // Returns the sender fingerprint, along with the contact holding its key if there is one
+
+    if secret.SenderContact != "" {
+        return secret.SenderFingerprint + " (" + secret.SenderContact + ")"
+    }
+    return secret.SenderFingerprint
+}
+
+func (secret *InboxSecret) Preview(length int) string {
+    // Returns the start of the content on a single line
+
+    preview := strings.Join(strings.Fields(secret.Text), " ")
+    if runes := []rune(preview); len(runes) > length {
+        preview = string(runes[:length]) + "..."
+    }
+    return preview
+}
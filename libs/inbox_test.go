@@ -0,0 +1,85 @@
+package libs
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/katena-chain/transactor-ui/libs/testutil"
+)
+
+func newTestInboxSecret(t *testing.T, config Config, recipientPublic string, content string) string {
+    // Returns a signed secret sealed to a recipient key by a new sender, as the API returns it
+
+    senderPublic, senderPrivate, err := GenerateKeyPair(KeyTypeX25519)
+    if err != nil {
+        t.Fatal(err)
+    }
+    recipientPublicKey, senderPublicKey, senderPrivateKey, err := ConvertKeys(recipientPublic, senderPublic, senderPrivate)
+    if err != nil {
+        t.Fatal(err)
+    }
+    secHandler := SecretHandler{Config: config, UuidText: testutil.Uuid, Content: []byte(content), RecipientPubKey: recipientPublicKey,
+        SenderPubKey: senderPublicKey, SenderPrivKey: senderPrivateKey}
+    if err := secHandler.BuildTransaction(); err != nil {
+        t.Fatal(err)
+    }
+    return `{"transaction":` + string(secHandler.Signed.Bytes) + `,"status":{"code":0,"message":"committed"}}`
+}
+
+func TestReadInboxWrongKey(t *testing.T) {
+    // A secret is opened by the keystore key it was sealed to only, the others failing to decrypt it
+
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+    config := Config{PrivKey: testutil.PrivateKey(t), ChainID: testutil.ChainID, CompanyChainID: testutil.CompanyChainID}
+
+    var recipientPublic string
+    for _, name := range []string{"a wrong key", "recipient"} {
+        publicKey, privateKey, err := GenerateKeyPair(KeyTypeX25519)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if err := dao.Keystore.AddKey(name, KeyTypeX25519, privateKey); err != nil {
+            t.Fatal(err)
+        }
+        recipientPublic = publicKey
+    }
+    strangerPublic, _, err := GenerateKeyPair(KeyTypeX25519)
+    if err != nil {
+        t.Fatal(err)
+    }
+    secrets := []string{
+        newTestInboxSecret(t, config, recipientPublic, "for the recipient"),
+        newTestInboxSecret(t, config, strangerPublic, "for a stranger"),
+    }
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        _, _ = writer.Write([]byte(`{"transactions":[` + strings.Join(secrets, ",") + `],"total":2}`))
+    }))
+    defer server.Close()
+    config.ApiUrl = server.URL
+
+    inbox, err := dao.ReadInbox(config, []InboxSource{{CompanyChainID: testutil.CompanyChainID, Uuid: testutil.Uuid}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(inbox.Secrets) != 1 || inbox.Unopened != 1 || len(inbox.Errors) != 0 {
+        t.Fatalf("unexpected inbox: %s", inbox.Summary())
+    }
+    if secret := inbox.Secrets[0]; secret.KeyName != "recipient" || secret.Text != "for the recipient" || secret.Error != "" {
+        t.Fatalf("unexpected secret: %+v", secret)
+    }
+
+    // Without the recipient key, nothing is opened and no error is reported for the secrets
+    if err := dao.Keystore.RemoveKey("recipient"); err != nil {
+        t.Fatal(err)
+    }
+    inbox, err = dao.ReadInbox(config, []InboxSource{{CompanyChainID: testutil.CompanyChainID, Uuid: testutil.Uuid}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(inbox.Secrets) != 0 || inbox.Unopened != 2 {
+        t.Fatalf("secret opened with a wrong key: %s", inbox.Summary())
+    }
+}
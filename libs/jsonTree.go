@@ -0,0 +1,336 @@
+package libs
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "io"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+// Kinds of JSON tree nodes
+const (
+    JSONObject = "object"
+    JSONArray  = "array"
+    JSONString = "string"
+    JSONNumber = "number"
+    JSONBool   = "bool"
+    JSONNull   = "null"
+)
+
+// Value of a JSON document, its object keys kept in the order of the document
+type JSONNode struct {
+    // Key in the parent object, empty for the root and the array items
+    Key string
+    // Index in the parent array, -1 otherwise
+    Index int
+    Kind  string
+    // JSON text of a scalar value
+    Value    string
+    Children []*JSONNode
+    Parent   *JSONNode
+    Depth    int
+}
+
+// Object keys written without brackets in a path
+var jsonPathIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func ParseJSONTree(data []byte) (*JSONNode, error) {
+    // Decodes a JSON document into a tree, keeping the order of the object keys
+
+    decoder := json.NewDecoder(bytes.NewReader(data))
+    decoder.UseNumber()
+    root, err := decodeJSONNode(decoder, nil, "", -1)
+    if err != nil {
+        return nil, err
+    }
+    if _, err := decoder.Token(); err != io.EOF {
+        return nil, fmt.Errorf("unexpected data after the JSON document")
+    }
+    return root, nil
+}
+
+func decodeJSONNode(decoder *json.Decoder, parent *JSONNode, key string, index int) (*JSONNode, error) {
+    // Decodes the next value of a document and its children
+
+    token, err := decoder.Token()
+    if err != nil {
+        return nil, err
+    }
+    node := &JSONNode{Key: key, Index: index, Parent: parent}
+    if parent != nil {
+        node.Depth = parent.Depth + 1
+    }
+
+    switch value := token.(type) {
+    case json.Delim:
+        if value == '{' {
+            node.Kind = JSONObject
+            for decoder.More() {
+                keyToken, err := decoder.Token()
+                if err != nil {
+                    return nil, err
+                }
+                child, err := decodeJSONNode(decoder, node, keyToken.(string), -1)
+                if err != nil {
+                    return nil, err
+                }
+                node.Children = append(node.Children, child)
+            }
+        } else {
+            node.Kind = JSONArray
+            for i := 0; decoder.More(); i++ {
+                child, err := decodeJSONNode(decoder, node, "", i)
+                if err != nil {
+                    return nil, err
+                }
+                node.Children = append(node.Children, child)
+            }
+        }
+        // Closing delimiter
+        if _, err := decoder.Token(); err != nil {
+            return nil, err
+        }
+    case string:
+        node.Kind = JSONString
+        data, _ := json.Marshal(value)
+        node.Value = string(data)
+    case json.Number:
+        node.Kind, node.Value = JSONNumber, value.String()
+    case bool:
+        node.Kind, node.Value = JSONBool, strconv.FormatBool(value)
+    default:
+        node.Kind, node.Value = JSONNull, "null"
+    }
+    return node, nil
+}
+
+func (node *JSONNode) Container() bool {
+    return node.Kind == JSONObject || node.Kind == JSONArray
+}
+
+func (node *JSONNode) Path() string {
+    // Returns the JSONPath of a node from the document root
+
+    if node.Parent == nil {
+        return "$"
+    }
+    if node.Index >= 0 {
+        return node.Parent.Path() + "[" + strconv.Itoa(node.Index) + "]"
+    }
+    if jsonPathIdentifier.MatchString(node.Key) {
+        return node.Parent.Path() + "." + node.Key
+    }
+    return node.Parent.Path() + "['" + strings.Replace(node.Key, "'", "\\'", -1) + "']"
+}
+
+func (node *JSONNode) Name() string {
+    // Returns the key or index of a node as shown before its value
+
+    switch {
+    case node.Parent == nil:
+        return "$"
+    case node.Index >= 0:
+        return "[" + strconv.Itoa(node.Index) + "]"
+    default:
+        return node.Key
+    }
+}
+
+func (node *JSONNode) Preview() string {
+    // Returns the value of a scalar, or the size of a container
+
+    switch node.Kind {
+    case JSONObject:
+        return "{" + strconv.Itoa(len(node.Children)) + " keys}"
+    case JSONArray:
+        return "[" + strconv.Itoa(len(node.Children)) + " items]"
+    default:
+        return node.Value
+    }
+}
+
+func (node *JSONNode) JSON() string {
+    // Returns the compact JSON text of a node and its children
+
+    var builder strings.Builder
+    node.writeJSON(&builder)
+    return builder.String()
+}
+
+func (node *JSONNode) writeJSON(builder *strings.Builder) {
+    switch node.Kind {
+    case JSONObject, JSONArray:
+        open, close := "{", "}"
+        if node.Kind == JSONArray {
+            open, close = "[", "]"
+        }
+        builder.WriteString(open)
+        for i, child := range node.Children {
+            if i > 0 {
+                builder.WriteString(",")
+            }
+            if node.Kind == JSONObject {
+                key, _ := json.Marshal(child.Key)
+                builder.Write(key)
+                builder.WriteString(":")
+            }
+            child.writeJSON(builder)
+        }
+        builder.WriteString(close)
+    default:
+        builder.WriteString(node.Value)
+    }
+}
+
+func (node *JSONNode) Walk(visit func(node *JSONNode)) {
+    // Visits a node and its descendants in document order
+
+    visit(node)
+    for _, child := range node.Children {
+        child.Walk(visit)
+    }
+}
+
+func SearchJSON(root *JSONNode, text string) []*JSONNode {
+    // Returns the nodes whose key or scalar value contains a text, ignoring the case, in document order
+
+    text = strings.ToLower(text)
+    var matches []*JSONNode
+    if text == "" {
+        return matches
+    }
+    root.Walk(func(node *JSONNode) {
+        if strings.Contains(strings.ToLower(node.Key), text) || (!node.Container() && strings.Contains(strings.ToLower(node.Value), text)) {
+            matches = append(matches, node)
+        }
+    })
+    return matches
+}
+
+// Step of a JSONPath expression : a key or index, a wildcard, or a recursive descent to a key or wildcard
+type jsonPathStep struct {
+    key       string
+    index     int
+    byIndex   bool
+    wildcard  bool
+    recursive bool
+}
+
+var jsonPathSteps = regexp.MustCompile(`^(?:(\.\.|\.)(\*|[A-Za-z_][A-Za-z0-9_-]*)|(\.\.)?\[(\*|-?[0-9]+|'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*")\])`)
+
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+    // Splits a JSONPath expression into steps, the supported syntax being $, .key, ['key'], [n], [*], .* and ..key
+
+    path = strings.TrimSpace(path)
+    if !strings.HasPrefix(path, "$") {
+        return nil, fmt.Errorf("a JSONPath starts with $")
+    }
+    rest := path[1:]
+    var steps []jsonPathStep
+    for rest != "" {
+        match := jsonPathSteps.FindStringSubmatch(rest)
+        if match == nil {
+            return nil, fmt.Errorf("invalid JSONPath at %s", rest)
+        }
+        rest = rest[len(match[0]):]
+        var step jsonPathStep
+        if match[1] != "" {
+            step.recursive = match[1] == ".."
+            step.wildcard = match[2] == "*"
+            if !step.wildcard {
+                step.key = match[2]
+            }
+        } else {
+            step.recursive = match[3] == ".."
+            selector := match[4]
+            switch {
+            case selector == "*":
+                step.wildcard = true
+            case selector[0] == '\'' || selector[0] == '"':
+                step.key = strings.Replace(strings.Replace(selector[1:len(selector)-1], "\\'", "'", -1), "\\\"", "\"", -1)
+            default:
+                step.byIndex = true
+                step.index, _ = strconv.Atoi(selector)
+            }
+        }
+        steps = append(steps, step)
+    }
+    return steps, nil
+}
+
+func (step jsonPathStep) matches(node *JSONNode) bool {
+    // Indicates if a child node is selected by a step, negative indexes counting from the end of the array
+
+    switch {
+    case step.wildcard:
+        return true
+    case step.byIndex:
+        index := step.index
+        if index < 0 {
+            index += len(node.Parent.Children)
+        }
+        return node.Index >= 0 && node.Index == index
+    default:
+        return node.Index < 0 && node.Key == step.key
+    }
+}
+
+func SelectJSONPath(root *JSONNode, path string) ([]*JSONNode, error) {
+    // Returns the nodes selected by a JSONPath expression, in the order they were reached
+
+    steps, err := parseJSONPath(path)
+    if err != nil {
+        return nil, err
+    }
+    current := []*JSONNode{root}
+    for _, step := range steps {
+        var next []*JSONNode
+        seen := map[*JSONNode]bool{}
+        add := func(node *JSONNode) {
+            if !seen[node] {
+                seen[node] = true
+                next = append(next, node)
+            }
+        }
+        for _, node := range current {
+            candidates := []*JSONNode{node}
+            if step.recursive {
+                candidates = nil
+                node.Walk(func(descendant *JSONNode) {
+                    candidates = append(candidates, descendant)
+                })
+            }
+            for _, candidate := range candidates {
+                for _, child := range candidate.Children {
+                    if step.matches(child) {
+                        add(child)
+                    }
+                }
+            }
+        }
+        current = next
+    }
+    return current, nil
+}
+
+func VisibleJSONRows(roots []*JSONNode, expanded map[*JSONNode]bool) []*JSONNode {
+    // Returns the nodes shown by a tree view, the children of a container only when it is expanded
+
+    var rows []*JSONNode
+    var add func(node *JSONNode)
+    add = func(node *JSONNode) {
+        rows = append(rows, node)
+        if expanded[node] {
+            for _, child := range node.Children {
+                add(child)
+            }
+        }
+    }
+    for _, root := range roots {
+        add(root)
+    }
+    return rows
+}
@@ -0,0 +1,100 @@
+package libs
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+const testJSONDocument = `{"z": 1, "a": {"list": [true, null, {"x.y": "it's"}], "empty": {}}, "n": -1.50e3}`
+
+func flattenJSON(root *JSONNode) []string {
+    // Returns the path and preview of every node in document order
+
+    var rows []string
+    root.Walk(func(node *JSONNode) {
+        rows = append(rows, node.Path()+" "+node.Preview())
+    })
+    return rows
+}
+
+func TestParseJSONTree(t *testing.T) {
+    // Nested objects and arrays are flattened in document order, the object keys keeping their order
+
+    root, err := ParseJSONTree([]byte(testJSONDocument))
+    if err != nil {
+        t.Fatal(err)
+    }
+    expected := []string{
+        "$ {3 keys}",
+        "$.z 1",
+        "$.a {2 keys}",
+        "$.a.list [3 items]",
+        "$.a.list[0] true",
+        "$.a.list[1] null",
+        "$.a.list[2] {1 keys}",
+        `$.a.list[2]['x.y'] "it's"`,
+        "$.a.empty {0 keys}",
+        "$.n -1.50e3",
+    }
+    if rows := flattenJSON(root); !reflect.DeepEqual(rows, expected) {
+        t.Fatalf("unexpected tree:\n%s", strings.Join(rows, "\n"))
+    }
+    item := root.Children[1].Children[0].Children[2]
+    if item.Depth != 3 || item.Name() != "[2]" || item.Children[0].Name() != "x.y" || !item.Container() {
+        t.Fatalf("unexpected item: %+v", item)
+    }
+    if compact := root.JSON(); compact != `{"z":1,"a":{"list":[true,null,{"x.y":"it's"}],"empty":{}},"n":-1.50e3}` {
+        t.Fatalf("unexpected JSON: %s", compact)
+    }
+    if rows := VisibleJSONRows([]*JSONNode{root}, map[*JSONNode]bool{root: true, item: true}); len(rows) != 4 || rows[2] != root.Children[1] {
+        t.Fatalf("children of a collapsed container shown: %d rows", len(rows))
+    }
+
+    for _, document := range []string{"", "{", `{"a":1}{}`, `[1,]`} {
+        if _, err := ParseJSONTree([]byte(document)); err == nil {
+            t.Errorf("document %q accepted", document)
+        }
+    }
+}
+
+func TestSelectJSONPath(t *testing.T) {
+    root, err := ParseJSONTree([]byte(testJSONDocument))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        path  string
+        paths []string
+        err   bool
+    }{
+        {"$", []string{"$"}, false},
+        {"$.a.list[0]", []string{"$.a.list[0]"}, false},
+        {"$.a.list[-1]['x.y']", []string{`$.a.list[2]['x.y']`}, false},
+        {"$.a.list[*]", []string{"$.a.list[0]", "$.a.list[1]", "$.a.list[2]"}, false},
+        {`$["a"].*`, []string{"$.a.list", "$.a.empty"}, false},
+        {"$..['x.y']", []string{`$.a.list[2]['x.y']`}, false},
+        {"$.missing", nil, false},
+        {"a.list", nil, true},
+        {"$.a[", nil, true},
+    }
+    for _, test := range tests {
+        nodes, err := SelectJSONPath(root, test.path)
+        if (err != nil) != test.err {
+            t.Errorf("%s: unexpected error %v", test.path, err)
+            continue
+        }
+        var paths []string
+        for _, node := range nodes {
+            paths = append(paths, node.Path())
+        }
+        if !reflect.DeepEqual(paths, test.paths) {
+            t.Errorf("%s selected %q", test.path, paths)
+        }
+    }
+
+    if matches := SearchJSON(root, "IT'S"); len(matches) != 1 || matches[0].Key != "x.y" {
+        t.Fatalf("unexpected search matches: %v", matches)
+    }
+}
@@ -0,0 +1,351 @@
+package libs
+
+import (
+    "crypto/rand"
+    "crypto/subtle"
+    "database/sql"
+    "encoding/base64"
+    "fmt"
+    "io"
+    "strings"
+
+    "github.com/katena-chain/sdk-go-client/crypto/ED25519"
+    "github.com/katena-chain/sdk-go-client/crypto/X25519"
+    "github.com/katena-chain/sdk-go-client/utils"
+    "golang.org/x/crypto/argon2"
+    "golang.org/x/crypto/chacha20poly1305"
+    "golang.org/x/crypto/curve25519"
+    "golang.org/x/crypto/ed25519"
+)
+
+// Key types held by the keystore
+const (
+    KeyTypeED25519 = "ED25519"
+    KeyTypeX25519  = "X25519"
+)
+
+// Argon2id parameters used to derive the master key from the passphrase
+const (
+    kdfTime    = 3
+    kdfMemory  = 64 * 1024
+    kdfThreads = 4
+    kdfSaltLen = 16
+)
+
+// Values encrypted by the keystore are prefixed so legacy plaintext rows can be told apart
+const encryptedPrefix = "enc:v1:"
+
+// Known value encrypted at initialization to check the passphrase on unlock
+const checkValue = "transactor-ui keystore"
+
+type Keystore struct {
+    Db  *sql.DB
+    key []byte
+}
+
+type KeyEntry struct {
+    Name      string `json:"name"`
+    Type      string `json:"type"`
+    PublicKey string `json:"public_key"`
+}
+
+func deriveKey(passphrase string, salt []byte) []byte {
+    // Derives the master key with the memory-hard Argon2id KDF
+
+    return argon2.IDKey([]byte(passphrase), salt, kdfTime, kdfMemory, kdfThreads, chacha20poly1305.KeySize)
+}
+
+func (keystore *Keystore) Initialized() (bool, error) {
+    // Indicates if a master passphrase was already set
+
+    var count int
+    if err := keystore.Db.QueryRow("SELECT COUNT(*) FROM keystore").Scan(&count); err != nil {
+        return false, err
+    }
+    return count > 0, nil
+}
+
+func (keystore *Keystore) Unlocked() bool {
+    return keystore.key != nil
+}
+
+func (keystore *Keystore) Lock() {
+    // Forgets the master key
+
+    for i := range keystore.key {
+        keystore.key[i] = 0
+    }
+    keystore.key = nil
+}
+
+func (keystore *Keystore) Unlock(passphrase string) error {
+    // Derives the master key for the session, setting the passphrase on first use
+
+    if passphrase == "" {
+        return fmt.Errorf("empty passphrase")
+    }
+    initialized, err := keystore.Initialized()
+    if err != nil {
+        return err
+    }
+
+    if !initialized {
+        salt := make([]byte, kdfSaltLen)
+        if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+            return err
+        }
+        keystore.key = deriveKey(passphrase, salt)
+        check, err := keystore.Encrypt([]byte(checkValue))
+        if err != nil {
+            keystore.Lock()
+            return err
+        }
+        _, err = keystore.Db.Exec("INSERT INTO keystore VALUES (1, ?, ?)", base64.StdEncoding.EncodeToString(salt), check)
+        if err != nil {
+            keystore.Lock()
+        }
+        return err
+    }
+
+    var saltBase64, check string
+    if err := keystore.Db.QueryRow("SELECT salt, checkValue FROM keystore WHERE id = 1").Scan(&saltBase64, &check); err != nil {
+        return err
+    }
+    salt, err := base64.StdEncoding.DecodeString(saltBase64)
+    if err != nil {
+        return err
+    }
+    keystore.key = deriveKey(passphrase, salt)
+    plainCheck, err := keystore.Decrypt(check)
+    if err != nil || subtle.ConstantTimeCompare(plainCheck, []byte(checkValue)) != 1 {
+        keystore.Lock()
+        return fmt.Errorf("wrong passphrase")
+    }
+    return nil
+}
+
+func IsEncrypted(value string) bool {
+    return strings.HasPrefix(value, encryptedPrefix)
+}
+
+func (keystore *Keystore) Encrypt(plaintext []byte) (string, error) {
+    // Encrypts a value with XChaCha20-Poly1305 under the master key
+
+    if !keystore.Unlocked() {
+        return "", fmt.Errorf("keystore is locked")
+    }
+    aead, err := chacha20poly1305.NewX(keystore.key)
+    if err != nil {
+        return "", err
+    }
+    nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+    if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+        return "", err
+    }
+    sealed := aead.Seal(nonce, nonce, plaintext, nil)
+    return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (keystore *Keystore) Decrypt(value string) ([]byte, error) {
+    // Decrypts a value produced by Encrypt
+
+    if !keystore.Unlocked() {
+        return nil, fmt.Errorf("keystore is locked")
+    }
+    if !IsEncrypted(value) {
+        return nil, fmt.Errorf("value is not encrypted")
+    }
+    sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+    if err != nil {
+        return nil, err
+    }
+    aead, err := chacha20poly1305.NewX(keystore.key)
+    if err != nil {
+        return nil, err
+    }
+    if len(sealed) < aead.NonceSize() {
+        return nil, fmt.Errorf("encrypted value too short")
+    }
+    plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+    if err != nil {
+        return nil, fmt.Errorf("cannot decrypt value: wrong key or altered data")
+    }
+    return plaintext, nil
+}
+
+func PublicKeyFromPrivate(keyType string, privateKeyBase64 string) (string, error) {
+    // Returns the base64 public key matching a base64 private key of the given type
+
+    switch keyType {
+    case KeyTypeED25519:
+        privateKey, err := utils.CreatePrivateKeyED25519FromBase64(privateKeyBase64)
+        if err != nil {
+            return "", err
+        }
+        if len(privateKeyBase64) != base64.StdEncoding.EncodedLen(len(privateKey)) {
+            return "", fmt.Errorf("an ED25519 private key is %d bytes long", len(privateKey))
+        }
+        return base64.StdEncoding.EncodeToString(privateKey.GetPublicKey()[:]), nil
+    case KeyTypeX25519:
+        privateKey, err := utils.CreatePrivateKeyX25519FromBase64(privateKeyBase64)
+        if err != nil {
+            return "", err
+        }
+        if len(privateKeyBase64) != base64.StdEncoding.EncodedLen(len(privateKey)) {
+            return "", fmt.Errorf("an X25519 private key is %d bytes long", len(privateKey))
+        }
+        var publicKey [32]byte
+        curve25519.ScalarBaseMult(&publicKey, (*[32]byte)(privateKey))
+        return base64.StdEncoding.EncodeToString(publicKey[:]), nil
+    default:
+        return "", fmt.Errorf("unknown key type: %s", keyType)
+    }
+}
+
+func GenerateKeyPair(keyType string) (string, string, error) {
+    // Generates a key pair of the given type and returns its base64 public and private keys
+
+    switch keyType {
+    case KeyTypeED25519:
+        _, privateKeyBytes, err := ed25519.GenerateKey(rand.Reader)
+        if err != nil {
+            return "", "", err
+        }
+        privateKey := ED25519.NewPrivateKey(privateKeyBytes)
+        return base64.StdEncoding.EncodeToString(privateKey.GetPublicKey()[:]), base64.StdEncoding.EncodeToString(privateKey[:]), nil
+    case KeyTypeX25519:
+        publicKey, privateKey, err := utils.CreateNewKeysX25519()
+        if err != nil {
+            return "", "", err
+        }
+        return base64.StdEncoding.EncodeToString(publicKey[:]), base64.StdEncoding.EncodeToString(privateKey[:]), nil
+    default:
+        return "", "", fmt.Errorf("unknown key type: %s", keyType)
+    }
+}
+
+func (keystore *Keystore) AddKey(name string, keyType string, privateKeyBase64 string) error {
+    // Stores a named private key encrypted under the master key
+
+    if name == "" {
+        return fmt.Errorf("missing key name")
+    }
+    publicKey, err := PublicKeyFromPrivate(keyType, privateKeyBase64)
+    if err != nil {
+        return err
+    }
+    encrypted, err := keystore.Encrypt([]byte(privateKeyBase64))
+    if err != nil {
+        return err
+    }
+    _, err = auditedDbExec(keystore.Db, "keys", name, AuditInsert, "INSERT INTO keys VALUES (?, ?, ?, ?)", name, keyType, publicKey, encrypted)
+    if err != nil {
+        return fmt.Errorf("cannot store key %s: %s", name, err)
+    }
+    return nil
+}
+
+func (keystore *Keystore) GetKey(name string) (string, string, error) {
+    // Returns the type and decrypted base64 private key of a named key
+
+    var keyType, encrypted string
+    err := keystore.Db.QueryRow("SELECT type, privateKey FROM keys WHERE name = ?", name).Scan(&keyType, &encrypted)
+    if err == sql.ErrNoRows {
+        return "", "", fmt.Errorf("no such key in the keystore: %s", name)
+    }
+    if err != nil {
+        return "", "", err
+    }
+    privateKey, err := keystore.Decrypt(encrypted)
+    if err != nil {
+        return "", "", err
+    }
+    return keyType, string(privateKey), nil
+}
+
+func (keystore *Keystore) GetED25519Key(name string) (*ED25519.PrivateKey, error) {
+    // Returns a named transactor key
+
+    keyType, privateKey, err := keystore.GetKey(name)
+    if err != nil {
+        return nil, err
+    }
+    if keyType != KeyTypeED25519 {
+        return nil, fmt.Errorf("key %s is not an %s key", name, KeyTypeED25519)
+    }
+    return utils.CreatePrivateKeyED25519FromBase64(privateKey)
+}
+
+func (keystore *Keystore) GetX25519Key(name string) (*X25519.PublicKey, *X25519.PrivateKey, error) {
+    // Returns a named X25519 key pair
+
+    keyType, privateKey, err := keystore.GetKey(name)
+    if err != nil {
+        return nil, nil, err
+    }
+    if keyType != KeyTypeX25519 {
+        return nil, nil, fmt.Errorf("key %s is not an %s key", name, KeyTypeX25519)
+    }
+    publicKey, err := PublicKeyFromPrivate(keyType, privateKey)
+    if err != nil {
+        return nil, nil, err
+    }
+    x25519PublicKey, err := utils.CreatePublicKeyX25519FromBase64(publicKey)
+    if err != nil {
+        return nil, nil, err
+    }
+    x25519PrivateKey, err := utils.CreatePrivateKeyX25519FromBase64(privateKey)
+    if err != nil {
+        return nil, nil, err
+    }
+    return x25519PublicKey, x25519PrivateKey, nil
+}
+
+func (keystore *Keystore) ListKeys(keyType string) ([]KeyEntry, error) {
+    // Returns the stored keys of a type, or of every type if empty, without their private part
+
+    rows, err := keystore.Db.Query("SELECT name, type, publicKey FROM keys WHERE ? = '' OR type = ? ORDER BY name", keyType, keyType)
+    if err != nil {
+        return nil, err
+    }
+    defer func() {
+        _ = rows.Close()
+    }()
+
+    var entries []KeyEntry
+    for rows.Next() {
+        var entry KeyEntry
+        if err := rows.Scan(&entry.Name, &entry.Type, &entry.PublicKey); err != nil {
+            return nil, err
+        }
+        entries = append(entries, entry)
+    }
+    return entries, rows.Err()
+}
+
+func (keystore *Keystore) KeyNames(keyType string) []string {
+    // Returns the names of the stored keys of a type, for the select widgets
+
+    entries, err := keystore.ListKeys(keyType)
+    if err != nil {
+        return nil
+    }
+    names := make([]string, len(entries))
+    for i, entry := range entries {
+        names[i] = entry.Name
+    }
+    return names
+}
+
+func (keystore *Keystore) RemoveKey(name string) error {
+    // Removes a named key
+
+    result, err := auditedDbExec(keystore.Db, "keys", name, AuditDelete, "DELETE FROM keys WHERE name = ?", name)
+    if err != nil {
+        return err
+    }
+    if count, _ := result.RowsAffected(); count == 0 {
+        return fmt.Errorf("no such key in the keystore: %s", name)
+    }
+    return nil
+}
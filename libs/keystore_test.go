@@ -0,0 +1,140 @@
+package libs
+
+import (
+    "encoding/base64"
+    "strings"
+    "testing"
+
+    "github.com/katena-chain/transactor-ui/libs/testutil"
+)
+
+func TestKeystoreRoundTrip(t *testing.T) {
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+
+    encrypted, err := dao.Keystore.Encrypt([]byte("secret value"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    again, err := dao.Keystore.Encrypt([]byte("secret value"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !IsEncrypted(encrypted) || strings.Contains(encrypted, "secret value") || again == encrypted {
+        t.Fatalf("unexpected encryption: %s, %s", encrypted, again)
+    }
+    _, privateKey, err := GenerateKeyPair(KeyTypeED25519)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := dao.Keystore.AddKey("transactor", KeyTypeED25519, privateKey); err != nil {
+        t.Fatal(err)
+    }
+
+    // The passphrase set on first use unlocks the same values in a later session
+    dao.Keystore.Lock()
+    if _, err := dao.Keystore.Decrypt(encrypted); err == nil {
+        t.Fatal("locked keystore decrypted a value")
+    }
+    if err := dao.Keystore.Unlock(testutil.Passphrase); err != nil {
+        t.Fatal(err)
+    }
+    if plaintext, err := dao.Keystore.Decrypt(encrypted); err != nil || string(plaintext) != "secret value" {
+        t.Fatalf("unexpected decryption: %q, %v", plaintext, err)
+    }
+    if keyType, stored, err := dao.Keystore.GetKey("transactor"); err != nil || keyType != KeyTypeED25519 || stored != privateKey {
+        t.Fatalf("unexpected key: %s, %v", keyType, err)
+    }
+    var row string
+    if err := dao.Db.QueryRow("SELECT privateKey FROM keys WHERE name = 'transactor'").Scan(&row); err != nil || !IsEncrypted(row) {
+        t.Fatalf("key stored in clear: %v", err)
+    }
+}
+
+func TestKeystoreWrongPassphrase(t *testing.T) {
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+    dao.Keystore.Lock()
+
+    for _, passphrase := range []string{"", "wrong", testutil.Passphrase + " "} {
+        if err := dao.Keystore.Unlock(passphrase); err == nil || dao.Keystore.Unlocked() {
+            t.Fatalf("passphrase %q accepted", passphrase)
+        }
+    }
+    if err := dao.Keystore.Unlock(testutil.Passphrase); err != nil {
+        t.Fatalf("right passphrase rejected after wrong ones: %s", err)
+    }
+}
+
+func TestKeystoreTamperedCiphertext(t *testing.T) {
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+    encrypted, err := dao.Keystore.Encrypt([]byte("secret value"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, encryptedPrefix))
+    if err != nil {
+        t.Fatal(err)
+    }
+    flipped := func(index int) string {
+        altered := append([]byte{}, sealed...)
+        altered[index] ^= 1
+        return encryptedPrefix + base64.StdEncoding.EncodeToString(altered)
+    }
+
+    tests := []struct {
+        name  string
+        value string
+    }{
+        {"nonce", flipped(0)},
+        {"ciphertext", flipped(len(sealed) / 2)},
+        {"tag", flipped(len(sealed) - 1)},
+        {"truncated", encryptedPrefix + base64.StdEncoding.EncodeToString(sealed[:len(sealed)-1])},
+        {"shorter than the nonce", encryptedPrefix + base64.StdEncoding.EncodeToString(sealed[:8])},
+        {"not base64", encryptedPrefix + "!!!"},
+        {"without prefix", strings.TrimPrefix(encrypted, encryptedPrefix)},
+        {"other version", strings.Replace(encrypted, encryptedPrefix, "enc:v2:", 1)},
+    }
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            if plaintext, err := dao.Keystore.Decrypt(test.value); err == nil {
+                t.Fatalf("tampered value decrypted as %q", plaintext)
+            }
+        })
+    }
+}
+
+func TestUnlockEncryptsPlaintextSecrets(t *testing.T) {
+    // Recipient keys written in clear by the versions without keystore are encrypted on the first unlock
+
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+    dao.Keystore.Lock()
+    if _, err := dao.Db.Exec("INSERT INTO secrets (uuid, recipientPrivateKey, status, requestDigest, profile, createdAt, updatedAt) "+
+        "VALUES (?, 'plaintext key', '', '', 'default', '', '')", testutil.Uuid); err != nil {
+        t.Fatal(err)
+    }
+    if err := dao.AddSecretEntry(testutil.OtherUuid, "", "", "default"); err != nil {
+        t.Fatal(err)
+    }
+    if key, err := dao.GetSecretDecryptingKey(testutil.Uuid); err != nil || key != "plaintext key" {
+        t.Fatalf("row not migrated yet unreadable: %q, %v", key, err)
+    }
+
+    migrated, err := dao.Unlock(testutil.Passphrase)
+    if err != nil || migrated != 1 {
+        t.Fatalf("unexpected migration: %d, %v", migrated, err)
+    }
+    var stored string
+    err = dao.Db.QueryRow("SELECT recipientPrivateKey FROM secrets WHERE uuid = ?", testutil.Uuid).Scan(&stored)
+    if err != nil || !IsEncrypted(stored) {
+        t.Fatalf("key left in clear: %q, %v", stored, err)
+    }
+    if key, err := dao.GetSecretDecryptingKey(testutil.Uuid); err != nil || key != "plaintext key" {
+        t.Fatalf("unexpected key once migrated: %q, %v", key, err)
+    }
+    if migrated, err := dao.Unlock(testutil.Passphrase); err != nil || migrated != 0 {
+        t.Fatalf("rows migrated again: %d, %v", migrated, err)
+    }
+}
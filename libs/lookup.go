@@ -0,0 +1,200 @@
+package libs
+
+import (
+    "database/sql"
+    "fmt"
+
+    "github.com/google/uuid"
+    entityApi "github.com/katena-chain/sdk-go-client/entity/api"
+)
+
+// Certificate and secrets retrieved for a UUID, whether or not it is recorded in the database
+type LookupResult struct {
+    Uuid           string `json:"uuid"`
+    CompanyChainID string `json:"company_chain_id"`
+    // Nil when no certificate could be retrieved, the reason being in CertificateError
+    Certificate      *entityApi.TransactionWrapper  `json:"certificate"`
+    CertificateError string                         `json:"certificate_error,omitempty"`
+    Secrets          *entityApi.TransactionWrappers `json:"secrets"`
+    SecretsError     string                         `json:"secrets_error,omitempty"`
+}
+
+func Lookup(config Config, uuidText string, companyChainID string) (*LookupResult, error) {
+    // Retrieves the certificate and the secrets of a UUID, from another company if a company chain id is given. A
+    // missing certificate or secret list is reported in the result, an error being returned only if neither could
+    // be retrieved.
+
+    if _, err := uuid.Parse(uuidText); err != nil {
+        return nil, fmt.Errorf("invalid UUID %s: %s", uuidText, err)
+    }
+    if companyChainID != "" {
+        config.CompanyChainID = companyChainID
+    }
+    if config.CompanyChainID == "" {
+        return nil, fmt.Errorf("missing company chain id")
+    }
+
+    result := &LookupResult{Uuid: uuidText, CompanyChainID: config.CompanyChainID}
+    certificateData := CertificateHandler{Config: config, UuidText: uuidText}
+    certificate, certificateErr := certificateData.GetCertificate()
+    if certificateErr != nil {
+        result.CertificateError = certificateErr.Error()
+    } else {
+        result.Certificate = certificate
+    }
+    secretData := SecretHandler{Config: config, UuidText: uuidText}
+    secrets, secretsErr := secretData.GetSecrets()
+    if secretsErr != nil {
+        result.SecretsError = secretsErr.Error()
+    } else {
+        result.Secrets = secrets
+    }
+
+    if certificateErr != nil && secretsErr != nil {
+        return nil, certificateErr
+    }
+    return result, nil
+}
+
+func (result *LookupResult) SecretCount() int {
+    if result.Secrets == nil {
+        return 0
+    }
+    return len(result.Secrets.Transactions)
+}
+
+func (result *LookupResult) Describe(recipientPrivKey string, chainID string) ([]*TransactionSummary, string, error) {
+    // Returns the decoded fields of the certificate and the secrets, opened with the recipient private key if there
+    // is one, and the indented JSON of the result
+
+    var summaries []*TransactionSummary
+    if result.Certificate != nil {
+        summary, err := DescribeTransactionWrapper(result.Certificate, chainID)
+        if err != nil {
+            return nil, "", err
+        }
+        summaries = append(summaries, summary)
+    }
+    if result.SecretCount() > 0 {
+        secrets, err := DescribeSecrets(result.Secrets, recipientPrivKey, chainID)
+        if err != nil {
+            return nil, "", err
+        }
+        summaries = append(summaries, secrets...)
+    }
+    raw, err := RawJSON(result)
+    if err != nil {
+        return nil, "", err
+    }
+    return summaries, raw, nil
+}
+
+func (result *LookupResult) Status(kind string) string {
+    // Returns the status an entry tracked from the result is recorded with, committed if the chain accepted every
+    // transaction
+
+    var statuses []*entityApi.TransactionStatus
+    if kind == EntryCertificate && result.Certificate != nil {
+        statuses = append(statuses, result.Certificate.Status)
+    }
+    if kind == EntrySecret && result.Secrets != nil {
+        for _, transactionWrapper := range result.Secrets.Transactions {
+            statuses = append(statuses, transactionWrapper.Status)
+        }
+    }
+    if len(statuses) == 0 {
+        return ""
+    }
+    for _, status := range statuses {
+        if status == nil || status.Code != 0 {
+            return StatusFailed
+        }
+    }
+    return StatusCommitted
+}
+
+func (dao *DatabaseDAO) TrackLookup(result *LookupResult, kind string, recipientPrivateKey string, configuredCompanyChainID string,
+    profile string) error {
+    // Records a looked up certificate or secrets in the database, with their company when it is not the configured
+    // one, so that they are listed and retrieved like the sent ones. A tracked certificate keeps the signature and
+    // signer retrieved from the chain for later verifications.
+
+    companyChainID := result.CompanyChainID
+    if companyChainID == configuredCompanyChainID {
+        companyChainID = ""
+    }
+
+    switch kind {
+    case EntryCertificate:
+        if result.Certificate == nil {
+            return fmt.Errorf("no certificate was retrieved for %s", result.Uuid)
+        }
+        certificate, err := CertificateOf(result.Certificate.Transaction)
+        if err != nil {
+            return err
+        }
+        var signature, signer string
+        if certificate.Seal != nil {
+            signature, signer = string(certificate.Seal.Signature), string(certificate.Seal.Signer)
+        }
+        _, err = dao.auditedExec("certificates", result.Uuid, AuditInsert,
+            "INSERT INTO certificates (uuid, signature, signer, status, requestDigest, profile, companyChainID, createdAt, updatedAt) "+
+                "VALUES (?, ?, ?, ?, '', ?, ?, ?, ?)",
+            result.Uuid, signature, signer, result.Status(kind), profile, companyChainID, now(), now())
+        if isConstraintError(err) {
+            return fmt.Errorf("a certificate for %s is already in the database", result.Uuid)
+        }
+        return err
+    case EntrySecret:
+        if result.SecretCount() == 0 {
+            return fmt.Errorf("no secret was retrieved for %s", result.Uuid)
+        }
+        // Without recipient key, the secrets are listed but cannot be opened
+        storedKey := ""
+        if recipientPrivateKey != "" {
+            if _, err := OpenSecrets(result.Secrets, recipientPrivateKey); err != nil {
+                return err
+            }
+            encryptedKey, err := dao.Keystore.Encrypt([]byte(recipientPrivateKey))
+            if err != nil {
+                return err
+            }
+            storedKey = encryptedKey
+        }
+        _, err := dao.auditedExec("secrets", result.Uuid, AuditInsert,
+            "INSERT INTO secrets (uuid, recipientPrivateKey, status, requestDigest, profile, companyChainID, createdAt, updatedAt) "+
+                "VALUES (?, ?, ?, '', ?, ?, ?, ?)",
+            result.Uuid, storedKey, result.Status(kind), profile, companyChainID, now(), now())
+        if isConstraintError(err) {
+            return fmt.Errorf("a secret for %s is already in the database", result.Uuid)
+        }
+        return err
+    default:
+        return fmt.Errorf("unknown entry kind: %s", kind)
+    }
+}
+
+func (dao *DatabaseDAO) GetEntryCompanyChainID(kind string, uuid string) (string, error) {
+    // Returns the company of a tracked entry, empty for the entries of the configured company
+
+    table, err := entryTable(kind)
+    if err != nil {
+        return "", err
+    }
+    var companyChainID string
+    err = dao.Db.QueryRow("SELECT companyChainID FROM "+table+" WHERE uuid = ?", uuid).Scan(&companyChainID)
+    if err == sql.ErrNoRows {
+        return "", ErrNotFound
+    }
+    return companyChainID, err
+}
+
+func (dao *DatabaseDAO) EntryConfig(kind string, uuid string, config Config) Config {
+    // Returns the configuration retrieving an entry, with the company it was tracked from if it is not the
+    // configured one
+
+    if companyChainID, err := dao.GetEntryCompanyChainID(kind, uuid); err == nil && companyChainID != "" {
+        config.CompanyChainID = companyChainID
+    }
+    return config
+}
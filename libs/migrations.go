@@ -0,0 +1,201 @@
+package libs
+
+import (
+    "database/sql"
+    "fmt"
+    "time"
+)
+
+// Schema changes applied in order to the database, the last applied version being recorded in the schema_version
+// table. A migration is never modified once released : changes go into a new one appended to the list.
+type migration struct {
+    version     int
+    description string
+    apply       func(transaction *sql.Tx) error
+}
+
+var migrations = []migration{
+    {1, "certificates and secrets tables", func(transaction *sql.Tx) error {
+        return execAll(transaction,
+            "CREATE TABLE IF NOT EXISTS certificates (UUID string primary key, SIGNATURE string, SIGNER string)",
+            "CREATE TABLE IF NOT EXISTS secrets (UUID string primary key, recipientPrivateKey string)",
+        )
+    }},
+    {2, "keystore tables", func(transaction *sql.Tx) error {
+        return execAll(transaction,
+            "CREATE TABLE IF NOT EXISTS keystore (id integer primary key, salt string, checkValue string)",
+            "CREATE TABLE IF NOT EXISTS keys (name string primary key, type string, publicKey string, privateKey string)",
+        )
+    }},
+    {3, "transaction status", func(transaction *sql.Tx) error {
+        // The column may have been added before migrations were tracked
+        if err := addColumn(transaction, "certificates", "status", "string NOT NULL DEFAULT ''"); err != nil {
+            return err
+        }
+        return addColumn(transaction, "secrets", "status", "string NOT NULL DEFAULT ''")
+    }},
+    {4, "transaction hash, profile and timestamps", func(transaction *sql.Tx) error {
+        for _, table := range []string{"certificates", "secrets"} {
+            for _, column := range []string{"txHash", "profile", "createdAt", "updatedAt"} {
+                if err := addColumn(transaction, table, column, "string NOT NULL DEFAULT ''"); err != nil {
+                    return err
+                }
+            }
+        }
+        return nil
+    }},
+    {5, "certified files", func(transaction *sql.Tx) error {
+        return execAll(transaction,
+            "CREATE TABLE IF NOT EXISTS certificateFiles (uuid string primary key, name string, size integer, path string, algorithm string, digest string)",
+        )
+    }},
+    {6, "certificate import results", func(transaction *sql.Tx) error {
+        return execAll(transaction,
+            "CREATE TABLE IF NOT EXISTS importResults (id integer primary key autoincrement, file string, line integer, uuid string, "+
+                "result string, reason string, code integer, message string, txHash string, createdAt string)",
+        )
+    }},
+    {7, "transaction history", func(transaction *sql.Tx) error {
+        return execAll(transaction,
+            "CREATE TABLE IF NOT EXISTS transactions (id integer primary key autoincrement, profile string, chainID string, "+
+                "apiUrl string, messageType string, kind string, uuid string, companyChainID string, nonceTime string, "+
+                "signedBytes string, txHash string, code integer, message string, status string NOT NULL DEFAULT '', "+
+                "createdAt string, updatedAt string)",
+            "CREATE INDEX IF NOT EXISTS transactionsTxHash ON transactions (txHash)",
+            "CREATE INDEX IF NOT EXISTS transactionsUuid ON transactions (uuid)",
+        )
+    }},
+    {8, "audit log", func(transaction *sql.Tx) error {
+        err := execAll(transaction,
+            "CREATE TABLE IF NOT EXISTS auditLog (seq integer primary key, tableName string, rowKey string, action string, "+
+                "rowHash string, prevHash string, hash string, createdAt string)",
+            "CREATE TABLE IF NOT EXISTS auditAnchors (seq integer, hash string, uuid string primary key, companyChainID string, "+
+                "txHash string, profile string, createdAt string)",
+        )
+        if err != nil {
+            return err
+        }
+        return auditExistingRows(transaction, 1)
+    }},
+    {9, "company of tracked entries", func(transaction *sql.Tx) error {
+        // Empty for the entries sent by the configured company
+        if err := addColumn(transaction, "certificates", "companyChainID", "string NOT NULL DEFAULT ''"); err != nil {
+            return err
+        }
+        return addColumn(transaction, "secrets", "companyChainID", "string NOT NULL DEFAULT ''")
+    }},
+    {10, "contacts", func(transaction *sql.Tx) error {
+        return execAll(transaction,
+            "CREATE TABLE IF NOT EXISTS contacts (name string primary key, publicKey string, fingerprint string, "+
+                "companyChainID string, notes string, createdAt string, updatedAt string)",
+        )
+    }},
+    {11, "secret groups", func(transaction *sql.Tx) error {
+        return execAll(transaction,
+            "CREATE TABLE IF NOT EXISTS secretGroups (uuid string primary key, profile string, createdAt string)",
+            "CREATE TABLE IF NOT EXISTS secretGroupMembers (secretUuid string primary key, groupUuid string, contactName string, "+
+                "fingerprint string, result string, reason string, createdAt string, updatedAt string)",
+            "CREATE INDEX IF NOT EXISTS secretGroupMembersGroup ON secretGroupMembers (groupUuid)",
+        )
+    }},
+    {12, "inbox sources", func(transaction *sql.Tx) error {
+        return execAll(transaction,
+            "CREATE TABLE IF NOT EXISTS inboxSources (source string primary key, companyChainID string, uuid string, createdAt string)",
+        )
+    }},
+    {13, "company of tracked entries in the audit log", func(transaction *sql.Tx) error {
+        return auditLayoutChange(transaction, 2)
+    }},
+    {14, "certificate files, import results, keys and anchors in the audit log", func(transaction *sql.Tx) error {
+        return auditLayoutChange(transaction, 3)
+    }},
+    {15, "request digest instead of transaction hash", func(transaction *sql.Tx) error {
+        // The SHA-256 of the signed request is not the hash the chain gives the transaction
+        for _, table := range []string{"certificates", "secrets", "transactions", "importResults", "auditAnchors"} {
+            if _, err := transaction.Exec("ALTER TABLE " + table + " RENAME COLUMN txHash TO requestDigest"); err != nil {
+                return err
+            }
+        }
+        err := execAll(transaction,
+            "DROP INDEX IF EXISTS transactionsTxHash",
+            "CREATE INDEX IF NOT EXISTS transactionsRequestDigest ON transactions (requestDigest)",
+        )
+        if err != nil {
+            return err
+        }
+        return auditLayoutChange(transaction, 4)
+    }},
+}
+
+func execAll(transaction *sql.Tx, statements ...string) error {
+    // Runs statements in order, stopping at the first error
+
+    for _, statement := range statements {
+        if _, err := transaction.Exec(statement); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func addColumn(transaction *sql.Tx, table string, column string, definition string) error {
+    // Adds a column to a table unless it already has it
+
+    var count int
+    err := transaction.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&count)
+    if err != nil || count > 0 {
+        return err
+    }
+    _, err = transaction.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition)
+    return err
+}
+
+func SchemaVersion(database *sql.DB) (int, error) {
+    // Returns the last migration applied to the database, 0 if none was
+
+    if _, err := database.Exec("CREATE TABLE IF NOT EXISTS schema_version (version integer primary key, description string, appliedAt string)"); err != nil {
+        return 0, err
+    }
+    var version sql.NullInt64
+    if err := database.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&version); err != nil {
+        return 0, err
+    }
+    return int(version.Int64), nil
+}
+
+func Migrate(database *sql.DB) error {
+    // Applies the migrations the database is missing, each one in its own transaction
+
+    current, err := SchemaVersion(database)
+    if err != nil {
+        return err
+    }
+    latest := migrations[len(migrations)-1].version
+    if current > latest {
+        return fmt.Errorf("database schema version %d is newer than this version of the application (%d)", current, latest)
+    }
+
+    for _, migration := range migrations {
+        if migration.version <= current {
+            continue
+        }
+        transaction, err := database.Begin()
+        if err != nil {
+            return err
+        }
+        if err := migration.apply(transaction); err != nil {
+            _ = transaction.Rollback()
+            return fmt.Errorf("database migration %d (%s): %s", migration.version, migration.description, err)
+        }
+        _, err = transaction.Exec("INSERT INTO schema_version VALUES (?, ?, ?)",
+            migration.version, migration.description, time.Now().UTC().Format(time.RFC3339))
+        if err != nil {
+            _ = transaction.Rollback()
+            return err
+        }
+        if err := transaction.Commit(); err != nil {
+            return err
+        }
+    }
+    return nil
+}
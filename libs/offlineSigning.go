@@ -0,0 +1,278 @@
+package libs
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "io"
+    "io/ioutil"
+
+    "github.com/katena-chain/sdk-go-client/crypto/ED25519"
+    "github.com/katena-chain/sdk-go-client/entity"
+    entityApi "github.com/katena-chain/sdk-go-client/entity/api"
+    "github.com/katena-chain/sdk-go-client/entity/certify"
+    "github.com/katena-chain/sdk-go-client/utils"
+)
+
+// Identifies the unsigned transaction requests, the version being increased whenever their layout changes
+const UnsignedFormat = "transactor-ui-unsigned-transaction"
+const UnsignedVersion = 1
+
+// Message built on a networked machine, to be signed where the transactor key is kept and broadcast later
+type UnsignedTransaction struct {
+    Format    string         `json:"format"`
+    Version   int            `json:"version"`
+    ChainID   string         `json:"chain_id"`
+    CreatedAt string         `json:"created_at"`
+    Message   entity.Message `json:"message"`
+}
+
+// What a transaction message creates
+type TransactionEntry struct {
+    Kind           string
+    Uuid           string
+    CompanyChainID string
+}
+
+func NewUnsignedTransaction(message entity.Message, chainID string) *UnsignedTransaction {
+    return &UnsignedTransaction{
+        Format:    UnsignedFormat,
+        Version:   UnsignedVersion,
+        ChainID:   chainID,
+        CreatedAt: now(),
+        Message:   message,
+    }
+}
+
+func (certHandler *CertificateHandler) UnsignedTransaction() *UnsignedTransaction {
+    // Builds the certificate message without signing it
+
+    return NewUnsignedTransaction(certHandler.Message(), certHandler.Config.ChainID)
+}
+
+func (secHandler *SecretHandler) UnsignedTransaction() (*UnsignedTransaction, error) {
+    // Seals the content and builds the secret message without signing it
+
+    message, err := secHandler.Message()
+    if err != nil {
+        return nil, err
+    }
+    return NewUnsignedTransaction(message, secHandler.Config.ChainID), nil
+}
+
+func (unsigned *UnsignedTransaction) UnmarshalJSON(data []byte) error {
+    // Decodes a request, the message being wrapped with its type like in a transaction
+
+    type jsonAlias UnsignedTransaction
+    var jsonUnsigned struct {
+        *jsonAlias
+        Message *utils.JSONWrapper `json:"message"`
+    }
+    jsonUnsigned.jsonAlias = (*jsonAlias)(unsigned)
+    if err := json.Unmarshal(data, &jsonUnsigned); err != nil {
+        return err
+    }
+    if jsonUnsigned.Message == nil {
+        return fmt.Errorf("missing message")
+    }
+    if err := checkMessageValue(jsonUnsigned.Message); err != nil {
+        return err
+    }
+
+    var message entity.Message
+    switch jsonUnsigned.Message.Type {
+    case certify.MsgCreateCertificateType:
+        message = &certify.MsgCreateCertificate{}
+    case certify.MsgCreateSecretType:
+        message = &certify.MsgCreateSecret{}
+    default:
+        return fmt.Errorf("unknown message type: %s", jsonUnsigned.Message.Type)
+    }
+    if err := json.Unmarshal(jsonUnsigned.Message.Value, message); err != nil {
+        return err
+    }
+    unsigned.Message = message
+    return nil
+}
+
+func (unsigned *UnsignedTransaction) Write(writer io.Writer) error {
+    // Writes the indented JSON representation of a request
+
+    encoder := json.NewEncoder(writer)
+    encoder.SetIndent("", "    ")
+    return encoder.Encode(unsigned)
+}
+
+func ReadUnsignedTransaction(reader io.Reader) (*UnsignedTransaction, error) {
+    // Reads a request and checks it can be understood by this version of the application
+
+    unsigned := &UnsignedTransaction{}
+    if err := json.NewDecoder(reader).Decode(unsigned); err != nil {
+        return nil, fmt.Errorf("invalid unsigned transaction: %s", err)
+    }
+    if unsigned.Format != UnsignedFormat {
+        return nil, fmt.Errorf("not an unsigned transaction request")
+    }
+    if unsigned.Version < 1 || unsigned.Version > UnsignedVersion {
+        return nil, fmt.Errorf("unsigned transaction version %d is not supported by this version of the application (%d)", unsigned.Version, UnsignedVersion)
+    }
+    if unsigned.ChainID == "" {
+        return nil, fmt.Errorf("missing chain id in the unsigned transaction")
+    }
+    return unsigned, nil
+}
+
+func (unsigned *UnsignedTransaction) Sign(privateKey *ED25519.PrivateKey) (*SignedTransaction, error) {
+    // Seals the message of a request for its chain at the current time
+
+    return SignMessage(unsigned.Message, unsigned.ChainID, privateKey)
+}
+
+func DescribeMessage(message entity.Message) (*TransactionEntry, error) {
+    // Returns the kind, UUID and company of the entry a message creates
+
+    switch message := message.(type) {
+    case *certify.MsgCreateCertificate:
+        if message.Certificate == nil {
+            return nil, fmt.Errorf("missing certificate in the message")
+        }
+        return &TransactionEntry{EntryCertificate, message.Certificate.GetUuid(), message.Certificate.GetCompanyChainID()}, nil
+    case *certify.MsgCreateSecret:
+        if message.Secret == nil {
+            return nil, fmt.Errorf("missing secret in the message")
+        }
+        return &TransactionEntry{EntrySecret, message.Secret.GetCertificateUuid(), message.Secret.GetCompanyChainID()}, nil
+    case nil:
+        return nil, fmt.Errorf("missing message")
+    default:
+        return nil, fmt.Errorf("bad message type: %s", message.GetType())
+    }
+}
+
+func (signed *SignedTransaction) Entry() (*TransactionEntry, error) {
+    // Returns the kind, UUID and company of the entry the transaction creates
+
+    return DescribeMessage(signed.Transaction.Message)
+}
+
+func checkMessageValue(message *utils.JSONWrapper) error {
+    // Checks that a message wraps the certificate or secret of its type. The SDK decoders dereference that wrapper
+    // without checking it, a transaction read from a file or the network being able to crash the application
+    // otherwise.
+
+    var field, wrappedType string
+    switch message.Type {
+    case certify.MsgCreateCertificateType:
+        field, wrappedType = "certificate", certify.CertificateV1Type
+    case certify.MsgCreateSecretType:
+        field, wrappedType = "secret", certify.SecretV1Type
+    default:
+        return fmt.Errorf("unknown message type: %s", message.Type)
+    }
+    if len(message.Value) == 0 || string(message.Value) == "null" {
+        return fmt.Errorf("missing message value")
+    }
+    var value struct {
+        Certificate *utils.JSONWrapper `json:"certificate"`
+        Secret      *utils.JSONWrapper `json:"secret"`
+    }
+    if err := json.Unmarshal(message.Value, &value); err != nil {
+        return fmt.Errorf("invalid message value: %s", err)
+    }
+    wrapper := value.Certificate
+    if field == "secret" {
+        wrapper = value.Secret
+    }
+    if wrapper == nil {
+        return fmt.Errorf("missing %s in the message", field)
+    }
+    if wrapper.Type != wrappedType {
+        return fmt.Errorf("unexpected %s type in the message: %s", field, wrapper.Type)
+    }
+    if len(wrapper.Value) == 0 || string(wrapper.Value) == "null" {
+        return fmt.Errorf("missing %s value in the message", field)
+    }
+    return nil
+}
+
+func DecodeTransaction(data []byte) (*entityApi.Transaction, error) {
+    // Decodes a transaction, checking first that it has every part the SDK decoder expects as it does not
+    // handle missing ones
+
+    var parts struct {
+        Message   json.RawMessage `json:"message"`
+        Seal      json.RawMessage `json:"seal"`
+        NonceTime json.RawMessage `json:"nonce_time"`
+    }
+    if err := json.Unmarshal(data, &parts); err != nil {
+        return nil, err
+    }
+    names := []string{"message", "seal", "nonce_time"}
+    for i, part := range []json.RawMessage{parts.Message, parts.Seal, parts.NonceTime} {
+        if len(part) == 0 || string(part) == "null" {
+            return nil, fmt.Errorf("missing %s", names[i])
+        }
+    }
+    message := &utils.JSONWrapper{}
+    if err := json.Unmarshal(parts.Message, message); err != nil {
+        return nil, fmt.Errorf("invalid message: %s", err)
+    }
+    if err := checkMessageValue(message); err != nil {
+        return nil, err
+    }
+    transaction := &entityApi.Transaction{}
+    if err := json.Unmarshal(data, transaction); err != nil {
+        return nil, err
+    }
+    return transaction, nil
+}
+
+func ReadSignedTransaction(reader io.Reader) (*SignedTransaction, error) {
+    // Reads a signed transaction, keeping its bytes to broadcast them unchanged
+
+    data, err := ioutil.ReadAll(reader)
+    if err != nil {
+        return nil, err
+    }
+    data = bytes.TrimSpace(data)
+    transaction, err := DecodeTransaction(data)
+    if err != nil {
+        return nil, fmt.Errorf("invalid signed transaction: %s", err)
+    }
+    return &SignedTransaction{
+        Transaction: transaction,
+        Bytes:       data,
+    }, nil
+}
+
+func (signed *SignedTransaction) Write(writer io.Writer) error {
+    // Writes the exact bytes that will be broadcast
+
+    if _, err := writer.Write(signed.Bytes); err != nil {
+        return err
+    }
+    _, err := io.WriteString(writer, "\n")
+    return err
+}
+
+func (dao *DatabaseDAO) RecordBroadcast(signed *SignedTransaction, profile string, recipientPrivateKey string) error {
+    // Records the entry of a transaction broadcast from a file, a secret being decryptable later only if its
+    // recipient private key is given
+
+    entry, err := signed.Entry()
+    if err != nil {
+        return err
+    }
+    if entry.Kind == EntrySecret {
+        return dao.AddSecretEntry(entry.Uuid, recipientPrivateKey, signed.Digest(), profile)
+    }
+    certificate, err := CertificateOf(signed.Transaction)
+    if err != nil {
+        return err
+    }
+    var signature, signer []byte
+    if certificate.Seal != nil {
+        signature, signer = certificate.Seal.Signature, certificate.Seal.Signer
+    }
+    return dao.AddCertificateEntry(entry.Uuid, string(signature), string(signer), signed.Digest(), profile)
+}
@@ -0,0 +1,123 @@
+package libs
+
+import (
+    "bytes"
+    "encoding/json"
+    "strings"
+    "testing"
+
+    "github.com/katena-chain/sdk-go-client/entity/certify"
+
+    "github.com/katena-chain/transactor-ui/libs/testutil"
+)
+
+func newTestSignedCertificate(t *testing.T) *SignedTransaction {
+    certHandler := CertificateHandler{
+        Config:        Config{ChainID: testutil.ChainID, CompanyChainID: testutil.CompanyChainID},
+        UuidText:      testutil.Uuid,
+        SignatureText: "signature",
+        SignerText:    "signer",
+    }
+    signed, err := SignMessage(certHandler.Message(), testutil.ChainID, testutil.TransactorKey(t))
+    if err != nil {
+        t.Fatal(err)
+    }
+    return signed
+}
+
+func withMessage(t *testing.T, data []byte, message string) []byte {
+    // Returns a JSON object with its message replaced
+
+    var parts map[string]json.RawMessage
+    if err := json.Unmarshal(data, &parts); err != nil {
+        t.Fatal(err)
+    }
+    parts["message"] = json.RawMessage(message)
+    result, err := json.Marshal(parts)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return result
+}
+
+// Messages the SDK decoders used to dereference without checking
+var malformedMessages = []struct {
+    name    string
+    message string
+}{
+    {"missing value", `{"type":"` + certify.MsgCreateCertificateType + `"}`},
+    {"null value", `{"type":"` + certify.MsgCreateCertificateType + `","value":null}`},
+    {"empty certificate value", `{"type":"` + certify.MsgCreateCertificateType + `","value":{}}`},
+    {"empty secret value", `{"type":"` + certify.MsgCreateSecretType + `","value":{}}`},
+    {"null certificate", `{"type":"` + certify.MsgCreateCertificateType + `","value":{"certificate":null}}`},
+    {"secret in a certificate message", `{"type":"` + certify.MsgCreateCertificateType + `","value":{"secret":{"type":"` +
+        certify.SecretV1Type + `","value":{}}}}`},
+    {"wrong certificate wrapper type", `{"type":"` + certify.MsgCreateCertificateType + `","value":{"certificate":{"type":"` +
+        certify.SecretV1Type + `","value":{}}}}`},
+    {"wrong secret wrapper type", `{"type":"` + certify.MsgCreateSecretType + `","value":{"secret":{"type":"` +
+        certify.CertificateV1Type + `","value":{}}}}`},
+    {"missing certificate value", `{"type":"` + certify.MsgCreateCertificateType + `","value":{"certificate":{"type":"` +
+        certify.CertificateV1Type + `"}}}`},
+    {"value not an object", `{"type":"` + certify.MsgCreateCertificateType + `","value":[]}`},
+    {"unknown type", `{"type":"certify/MsgUnknown","value":{}}`},
+}
+
+func TestDecodeTransaction(t *testing.T) {
+    signed := newTestSignedCertificate(t)
+    transaction, err := DecodeTransaction(signed.Bytes)
+    if err != nil {
+        t.Fatalf("valid transaction rejected: %s", err)
+    }
+    entry, err := DescribeMessage(transaction.Message)
+    if err != nil || entry.Kind != EntryCertificate || entry.Uuid != testutil.Uuid || entry.CompanyChainID != testutil.CompanyChainID {
+        t.Fatalf("unexpected entry %+v, %v", entry, err)
+    }
+
+    for _, test := range malformedMessages {
+        t.Run(test.name, func(t *testing.T) {
+            data := withMessage(t, signed.Bytes, test.message)
+            if _, err := DecodeTransaction(data); err == nil {
+                t.Fatal("malformed transaction accepted")
+            }
+            if _, err := ReadSignedTransaction(bytes.NewReader(data)); err == nil || !strings.HasPrefix(err.Error(), "invalid signed transaction") {
+                t.Fatalf("unexpected error: %v", err)
+            }
+        })
+    }
+}
+
+func TestReadUnsignedTransaction(t *testing.T) {
+    certHandler := CertificateHandler{Config: Config{ChainID: testutil.ChainID, CompanyChainID: testutil.CompanyChainID}, UuidText: testutil.Uuid}
+    var buffer bytes.Buffer
+    if err := certHandler.UnsignedTransaction().Write(&buffer); err != nil {
+        t.Fatal(err)
+    }
+    unsigned, err := ReadUnsignedTransaction(bytes.NewReader(buffer.Bytes()))
+    if err != nil {
+        t.Fatalf("valid request rejected: %s", err)
+    }
+    if entry, err := DescribeMessage(unsigned.Message); err != nil || entry.Uuid != testutil.Uuid {
+        t.Fatalf("unexpected entry %+v, %v", entry, err)
+    }
+
+    for _, test := range malformedMessages {
+        t.Run(test.name, func(t *testing.T) {
+            data := withMessage(t, buffer.Bytes(), test.message)
+            if _, err := ReadUnsignedTransaction(bytes.NewReader(data)); err == nil {
+                t.Fatal("malformed request accepted")
+            }
+        })
+    }
+}
+
+func TestDescribeMessageWithoutContent(t *testing.T) {
+    if _, err := DescribeMessage(&certify.MsgCreateCertificate{}); err == nil {
+        t.Error("certificate message without certificate accepted")
+    }
+    if _, err := DescribeMessage(&certify.MsgCreateSecret{}); err == nil {
+        t.Error("secret message without secret accepted")
+    }
+    if _, err := DescribeMessage(nil); err == nil {
+        t.Error("missing message accepted")
+    }
+}
@@ -0,0 +1,196 @@
+package libs
+
+import (
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "runtime"
+    "sort"
+)
+
+// Profiles are stored as JSON in the user config directory, the private key is never written there,
+// only the name of its entry in the keystore
+const configDirName = "transactor-ui"
+const profilesFileName = "profiles.json"
+
+type Profile struct {
+    Name           string `json:"name"`
+    KeyName        string `json:"key_name,omitempty"`
+    CompanyChainID string `json:"company_chain_id"`
+    ChainID        string `json:"chain_id"`
+    ApiUrl         string `json:"api_url"`
+}
+
+type ProfileStore struct {
+    Active   string              `json:"active"`
+    Profiles map[string]*Profile `json:"profiles"`
+    path     string
+}
+
+func userConfigDir() (string, error) {
+    // Returns the configuration directory of the user, resolved like os.UserConfigDir which needs Go 1.13 while
+    // the releases are built with Go 1.12
+
+    switch runtime.GOOS {
+    case "windows":
+        if dir := os.Getenv("AppData"); dir != "" {
+            return dir, nil
+        }
+        return "", fmt.Errorf("%%AppData%% is not defined")
+    case "darwin":
+        if home := os.Getenv("HOME"); home != "" {
+            return filepath.Join(home, "Library", "Application Support"), nil
+        }
+        return "", fmt.Errorf("$HOME is not defined")
+    default:
+        if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+            if !filepath.IsAbs(dir) {
+                return "", fmt.Errorf("path in $XDG_CONFIG_HOME is relative")
+            }
+            return dir, nil
+        }
+        if home := os.Getenv("HOME"); home != "" {
+            return filepath.Join(home, ".config"), nil
+        }
+        return "", fmt.Errorf("neither $XDG_CONFIG_HOME nor $HOME are defined")
+    }
+}
+
+func ConfigDir() (string, error) {
+    // Returns the directory holding the application configuration files, creating it if needed
+
+    baseDir, err := userConfigDir()
+    if err != nil {
+        return "", err
+    }
+    dir := filepath.Join(baseDir, configDirName)
+    if err := os.MkdirAll(dir, 0700); err != nil {
+        return "", err
+    }
+    return dir, nil
+}
+
+func LoadProfiles() (*ProfileStore, error) {
+    // Reads the profiles file, an absent file giving an empty store
+
+    dir, err := ConfigDir()
+    if err != nil {
+        return nil, err
+    }
+    store := &ProfileStore{
+        Profiles: map[string]*Profile{},
+        path:     filepath.Join(dir, profilesFileName),
+    }
+
+    data, err := ioutil.ReadFile(store.path)
+    if os.IsNotExist(err) {
+        return store, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    if err := json.Unmarshal(data, store); err != nil {
+        return nil, fmt.Errorf("invalid profiles file %s: %s", store.path, err)
+    }
+    if store.Profiles == nil {
+        store.Profiles = map[string]*Profile{}
+    }
+    return store, nil
+}
+
+func (store *ProfileStore) Save() error {
+    // Writes the profiles file
+
+    data, err := json.MarshalIndent(store, "", "    ")
+    if err != nil {
+        return err
+    }
+    return ioutil.WriteFile(store.path, data, 0600)
+}
+
+func (store *ProfileStore) Names() []string {
+    // Returns the sorted profile names
+
+    names := make([]string, 0, len(store.Profiles))
+    for name := range store.Profiles {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+func (store *ProfileStore) Get(name string) (*Profile, error) {
+    // Returns the profile with the given name
+
+    profile, ok := store.Profiles[name]
+    if !ok {
+        return nil, fmt.Errorf("no such profile: %s", name)
+    }
+    return profile, nil
+}
+
+func (store *ProfileStore) GetActive() *Profile {
+    // Returns the profile used last time, or nil if there is none
+
+    return store.Profiles[store.Active]
+}
+
+func (store *ProfileStore) Set(profile *Profile) error {
+    // Adds or replaces a profile
+
+    if profile.Name == "" {
+        return fmt.Errorf("missing profile name")
+    }
+    store.Profiles[profile.Name] = profile
+    return nil
+}
+
+func (store *ProfileStore) Use(name string) error {
+    // Marks a profile as the active one
+
+    if _, err := store.Get(name); err != nil {
+        return err
+    }
+    store.Active = name
+    return nil
+}
+
+func (store *ProfileStore) Remove(name string) error {
+    // Removes a profile, clearing the active one if needed
+
+    if _, err := store.Get(name); err != nil {
+        return err
+    }
+    delete(store.Profiles, name)
+    if store.Active == name {
+        store.Active = ""
+    }
+    return nil
+}
+
+func NewProfile(name string, config Config) *Profile {
+    // Builds a profile out of a configuration, leaving its private key aside
+
+    return &Profile{
+        Name:           name,
+        KeyName:        config.KeyName,
+        CompanyChainID: config.CompanyChainID,
+        ChainID:        config.ChainID,
+        ApiUrl:         config.ApiUrl,
+    }
+}
+
+func (profile *Profile) Config(privKey string) Config {
+    // Builds the configuration of a profile with the given private key
+
+    return Config{
+        Profile:        profile.Name,
+        PrivKey:        privKey,
+        KeyName:        profile.KeyName,
+        CompanyChainID: profile.CompanyChainID,
+        ChainID:        profile.ChainID,
+        ApiUrl:         profile.ApiUrl,
+    }
+}
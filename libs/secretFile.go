@@ -0,0 +1,211 @@
+package libs
+
+import (
+    "bytes"
+    "compress/gzip"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "io"
+    "io/ioutil"
+    "mime"
+    "net/http"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+
+    "golang.org/x/crypto/nacl/box"
+)
+
+// Largest transaction the chain accepts, a secret being rejected before it is signed if its transaction would be
+// larger
+const MaxTransactionSize = 1024 * 1024
+
+// Room taken in a secret transaction by everything but the encoded content : UUID, company, keys, nonce and seal
+const secretTransactionOverhead = 2048
+
+// Largest file restored from an envelope, so that a small compressed payload cannot expand without bound
+const maxSecretFileSize = 256 * 1024 * 1024
+
+// Starts the content of the secrets carrying a file, followed by a JSON header line and the file data. Any other
+// content is plain text.
+const secretEnvelopeMagic = "transactor-ui-file/1\n"
+
+// Compressions of the data of an envelope
+const (
+    SecretCompressionNone = ""
+    SecretCompressionGzip = "gzip"
+)
+
+// File carried by a secret. The header is sealed with the data, so nothing about the file is readable on chain.
+type SecretFile struct {
+    Name        string `json:"name"`
+    MimeType    string `json:"mime_type"`
+    Size        int64  `json:"size"`
+    Compression string `json:"compression,omitempty"`
+    Sha256      string `json:"sha256"`
+    Data        []byte `json:"-"`
+}
+
+func SecretTransactionSize(contentSize int) int {
+    // Returns the size of the transaction of a secret whose content has the given size : the sealed content is
+    // larger by the box overhead and base64 encoded in the JSON transaction
+
+    return base64.StdEncoding.EncodedLen(contentSize+box.Overhead) + secretTransactionOverhead
+}
+
+func CheckSecretContentSize(contentSize int) error {
+    // Returns an error if the transaction of a secret content would be larger than what the chain accepts
+
+    if size := SecretTransactionSize(contentSize); size > MaxTransactionSize {
+        return fmt.Errorf("the secret content is %d bytes, its transaction would be %d bytes once sealed and encoded, over the %d bytes "+
+            "accepted by the chain", contentSize, size, MaxTransactionSize)
+    }
+    return nil
+}
+
+func NewSecretFile(name string, data []byte) *SecretFile {
+    // Describes a file to be sent in a secret, its MIME type guessed from its extension or else from its content
+
+    mimeType := mime.TypeByExtension(filepath.Ext(name))
+    if mimeType == "" {
+        mimeType = http.DetectContentType(data)
+    }
+    digest := sha256.Sum256(data)
+    return &SecretFile{
+        Name:     filepath.Base(name),
+        MimeType: mimeType,
+        Size:     int64(len(data)),
+        Sha256:   hex.EncodeToString(digest[:]),
+        Data:     data,
+    }
+}
+
+func ReadSecretFile(path string) (*SecretFile, error) {
+    // Reads a file to be sent in a secret
+
+    info, err := os.Stat(path)
+    if err != nil {
+        return nil, err
+    }
+    if info.IsDir() {
+        return nil, fmt.Errorf("%s is a directory", path)
+    }
+    if info.Size() > maxSecretFileSize {
+        return nil, fmt.Errorf("%s is %d bytes, a secret file is at most %d bytes", path, info.Size(), maxSecretFileSize)
+    }
+    data, err := ioutil.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    return NewSecretFile(path, data), nil
+}
+
+func (file *SecretFile) Envelope(compress bool) ([]byte, error) {
+    // Returns the secret content carrying the file, its data compressed with gzip if asked and if it gets smaller
+
+    header := *file
+    data := file.Data
+    if compress {
+        var compressed bytes.Buffer
+        writer, err := gzip.NewWriterLevel(&compressed, gzip.BestCompression)
+        if err != nil {
+            return nil, err
+        }
+        if _, err := writer.Write(file.Data); err != nil {
+            return nil, err
+        }
+        if err := writer.Close(); err != nil {
+            return nil, err
+        }
+        if compressed.Len() < len(file.Data) {
+            header.Compression, data = SecretCompressionGzip, compressed.Bytes()
+        }
+    }
+    headerBytes, err := json.Marshal(header)
+    if err != nil {
+        return nil, err
+    }
+
+    var envelope bytes.Buffer
+    envelope.WriteString(secretEnvelopeMagic)
+    envelope.Write(headerBytes)
+    envelope.WriteByte('\n')
+    envelope.Write(data)
+    return envelope.Bytes(), nil
+}
+
+func OpenSecretEnvelope(content []byte) (*SecretFile, error) {
+    // Returns the file carried by a secret content, nil if the content is plain text
+
+    if !bytes.HasPrefix(content, []byte(secretEnvelopeMagic)) {
+        return nil, nil
+    }
+    content = content[len(secretEnvelopeMagic):]
+    end := bytes.IndexByte(content, '\n')
+    if end < 0 {
+        return nil, fmt.Errorf("invalid file envelope: missing header")
+    }
+    file := &SecretFile{}
+    if err := json.Unmarshal(content[:end], file); err != nil {
+        return nil, fmt.Errorf("invalid file envelope: %s", err)
+    }
+    if file.Size < 0 || file.Size > maxSecretFileSize {
+        return nil, fmt.Errorf("invalid file envelope: size %d", file.Size)
+    }
+    data := content[end+1:]
+
+    switch file.Compression {
+    case SecretCompressionNone:
+    case SecretCompressionGzip:
+        reader, err := gzip.NewReader(bytes.NewReader(data))
+        if err != nil {
+            return nil, fmt.Errorf("invalid file envelope: %s", err)
+        }
+        // One byte more than announced is read to tell a longer stream apart
+        if data, err = ioutil.ReadAll(io.LimitReader(reader, file.Size+1)); err != nil {
+            return nil, fmt.Errorf("invalid file envelope: %s", err)
+        }
+    default:
+        return nil, fmt.Errorf("invalid file envelope: unknown compression %s", file.Compression)
+    }
+    digest := sha256.Sum256(data)
+    if int64(len(data)) != file.Size || hex.EncodeToString(digest[:]) != file.Sha256 {
+        return nil, fmt.Errorf("invalid file envelope: the data of %s does not match its size and digest", file.Name)
+    }
+    file.Data = data
+    return file, nil
+}
+
+func (file *SecretFile) String() string {
+    // Returns a readable description of the file
+
+    description := file.Name + " (" + file.MimeType + ", " + strconv.FormatInt(file.Size, 10) + " bytes"
+    if file.Compression != SecretCompressionNone {
+        description += ", sent " + file.Compression + " compressed"
+    }
+    return description + ")"
+}
+
+func (file *SecretFile) Save(directory string) (string, error) {
+    // Writes the file under its original name in a directory, without replacing an existing file, and returns its
+    // path
+
+    name := filepath.Base(filepath.Clean("/" + strings.Replace(file.Name, "\\", "/", -1)))
+    if name == "/" || name == "." || name == ".." {
+        return "", fmt.Errorf("invalid file name: %q", file.Name)
+    }
+    path := filepath.Join(directory, name)
+    output, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+    if err != nil {
+        return "", err
+    }
+    if _, err := output.Write(file.Data); err != nil {
+        _ = output.Close()
+        return "", err
+    }
+    return path, output.Close()
+}
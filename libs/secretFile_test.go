@@ -0,0 +1,142 @@
+package libs
+
+import (
+    "bytes"
+    "compress/gzip"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "io/ioutil"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/katena-chain/transactor-ui/libs/testutil"
+)
+
+func testEnvelope(t *testing.T, header SecretFile, data []byte) []byte {
+    // Returns an envelope with a given header, whatever its data
+
+    headerBytes, err := json.Marshal(header)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return append([]byte(secretEnvelopeMagic+string(headerBytes)+"\n"), data...)
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+    var compressed bytes.Buffer
+    writer := gzip.NewWriter(&compressed)
+    if _, err := writer.Write(data); err != nil {
+        t.Fatal(err)
+    }
+    if err := writer.Close(); err != nil {
+        t.Fatal(err)
+    }
+    return compressed.Bytes()
+}
+
+func TestSecretEnvelopeRoundTrip(t *testing.T) {
+    file := NewSecretFile("report.txt", bytes.Repeat([]byte("compressible "), 100))
+    for _, compress := range []bool{false, true} {
+        envelope, err := file.Envelope(compress)
+        if err != nil {
+            t.Fatal(err)
+        }
+        opened, err := OpenSecretEnvelope(envelope)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if opened.Name != "report.txt" || !bytes.Equal(opened.Data, file.Data) || (opened.Compression == SecretCompressionGzip) != compress {
+            t.Fatalf("unexpected file with compression %v: %s", compress, opened)
+        }
+    }
+}
+
+func TestOpenSecretEnvelope(t *testing.T) {
+    data := []byte("file content")
+    digest := sha256.Sum256(data)
+    valid := SecretFile{Name: "file.txt", MimeType: "text/plain", Size: int64(len(data)), Sha256: hex.EncodeToString(digest[:])}
+    with := func(change func(header *SecretFile)) SecretFile {
+        header := valid
+        change(&header)
+        return header
+    }
+    // A small stream expanding far beyond the size it announces
+    bomb := gzipped(t, make([]byte, 1024*1024))
+
+    tests := []struct {
+        name    string
+        content []byte
+        err     string
+    }{
+        {"plain text", []byte("transactor-ui-file is not a header"), ""},
+        {"other envelope version", []byte("transactor-ui-file/2\n{}\n"), ""},
+        {"missing header", []byte(secretEnvelopeMagic + `{"name":"file.txt"}`), "missing header"},
+        {"garbled header", []byte(secretEnvelopeMagic + "{name:\n" + string(data)), "invalid file envelope"},
+        {"negative size", testEnvelope(t, with(func(header *SecretFile) { header.Size = -1 }), data), "size -1"},
+        {"size over the bound", testEnvelope(t, with(func(header *SecretFile) { header.Size = maxSecretFileSize + 1 }), data), "size"},
+        {"size not matching", testEnvelope(t, with(func(header *SecretFile) { header.Size++ }), data), "does not match"},
+        {"digest not matching", testEnvelope(t, valid, []byte("file CONTENT")), "does not match"},
+        {"unknown compression", testEnvelope(t, with(func(header *SecretFile) { header.Compression = "zstd" }), data), "unknown compression"},
+        {"invalid gzip", testEnvelope(t, with(func(header *SecretFile) { header.Compression = SecretCompressionGzip }), data), "invalid file envelope"},
+        {"gzip bomb", testEnvelope(t, with(func(header *SecretFile) { header.Compression = SecretCompressionGzip }), bomb), "does not match"},
+    }
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            file, err := OpenSecretEnvelope(test.content)
+            if test.err == "" {
+                if file != nil || err != nil {
+                    t.Fatalf("plain text read as a file: %v, %v", file, err)
+                }
+                return
+            }
+            if err == nil || !strings.Contains(err.Error(), test.err) {
+                t.Fatalf("unexpected error: %v", err)
+            }
+        })
+    }
+
+    if file, err := OpenSecretEnvelope(testEnvelope(t, valid, data)); err != nil || !bytes.Equal(file.Data, data) {
+        t.Fatalf("valid envelope rejected: %v", err)
+    }
+}
+
+func TestSecretFileSave(t *testing.T) {
+    directory, removeDirectory := testutil.TempDir(t)
+    defer removeDirectory()
+
+    tests := []struct {
+        name  string
+        saved string
+    }{
+        {"file.txt", "file.txt"},
+        {"../../escaped.txt", "escaped.txt"},
+        {"/absolute/path.txt", "path.txt"},
+        {`..\windows\name.txt`, "name.txt"},
+        {"", ""},
+        {"..", ""},
+        {"/", ""},
+    }
+    for _, test := range tests {
+        file := &SecretFile{Name: test.name, Data: []byte("content")}
+        path, err := file.Save(directory)
+        if test.saved == "" {
+            if err == nil {
+                t.Errorf("%q saved as %s", test.name, path)
+            }
+            continue
+        }
+        if err != nil || path != filepath.Join(directory, test.saved) {
+            t.Errorf("%q saved as %s: %v", test.name, path, err)
+        }
+    }
+
+    // An existing file is never replaced
+    if _, err := (&SecretFile{Name: "file.txt", Data: []byte("other")}).Save(directory); err == nil {
+        t.Fatal("existing file replaced")
+    }
+    if content, err := ioutil.ReadFile(filepath.Join(directory, "file.txt")); err != nil || string(content) != "content" {
+        t.Fatalf("existing file changed: %q, %v", content, err)
+    }
+}
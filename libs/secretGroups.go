@@ -0,0 +1,348 @@
+package libs
+
+import (
+    "database/sql"
+    "fmt"
+    "strconv"
+    "strings"
+
+    "github.com/google/uuid"
+    "github.com/katena-chain/sdk-go-client/crypto/X25519"
+    entityApi "github.com/katena-chain/sdk-go-client/entity/api"
+    "github.com/katena-chain/sdk-go-client/utils"
+)
+
+// Recipient of a secret sent to a group of contacts, each one getting its own secret transaction
+type SecretGroupMember struct {
+    GroupUuid   string `json:"group_uuid"`
+    SecretUuid  string `json:"secret_uuid"`
+    ContactName string `json:"contact_name"`
+    Fingerprint string `json:"fingerprint"`
+    // Outcome of the last send to the recipient, among the import outcomes
+    Result string `json:"result"`
+    Reason string `json:"reason,omitempty"`
+    // Status of the secret entry of the recipient, empty if none was recorded
+    Status        string `json:"status"`
+    RequestDigest string `json:"request_digest,omitempty"`
+    UpdatedAt     string `json:"updated_at"`
+}
+
+type SecretGroup struct {
+    Uuid      string              `json:"uuid"`
+    Profile   string              `json:"profile"`
+    CreatedAt string              `json:"created_at"`
+    Members   []SecretGroupMember `json:"members"`
+}
+
+// Send of a group secret to one recipient
+type SecretGroupSend struct {
+    SecretGroupMember
+    PublicKey string `json:"-"`
+    Code      uint32 `json:"code"`
+    Message   string `json:"message,omitempty"`
+    // What was broadcast and answered, to follow the transaction
+    Signed     *SignedTransaction           `json:"-"`
+    SendStatus *entityApi.TransactionStatus `json:"-"`
+}
+
+type SecretGroupPlan struct {
+    GroupUuid string
+    Sends     []*SecretGroupSend
+}
+
+type SecretGroupRunner struct {
+    Dao           *DatabaseDAO
+    Config        Config
+    Content       []byte
+    SenderPubKey  *X25519.PublicKey
+    SenderPrivKey *X25519.PrivateKey
+    // Called for each recipient sent to, from the goroutine running the sends
+    OnResult func(send *SecretGroupSend)
+}
+
+func GroupSecretUuid(groupUuid string, publicKey string) (string, error) {
+    // Returns the UUID of the secret of a recipient, derived from the group UUID and its public key so that sending
+    // the group again gives the same UUIDs and skips the recipients already sent to
+
+    group, err := uuid.Parse(groupUuid)
+    if err != nil {
+        return "", fmt.Errorf("invalid group UUID %s: %s", groupUuid, err)
+    }
+    return uuid.NewSHA1(group, []byte(publicKey)).String(), nil
+}
+
+func (member *SecretGroupMember) Delivery() string {
+    // Returns the status of the secret of a recipient once recorded, the outcome of its send otherwise
+
+    if member.Status != "" {
+        return member.Status
+    }
+    return member.Result
+}
+
+func (group *SecretGroup) Summary() string {
+    // Returns a one line count of the recipients of a group by delivery status
+
+    var order []string
+    counts := map[string]int{}
+    for i := range group.Members {
+        delivery := group.Members[i].Delivery()
+        if counts[delivery] == 0 {
+            order = append(order, delivery)
+        }
+        counts[delivery]++
+    }
+    parts := []string{strconv.Itoa(len(group.Members)) + " recipients"}
+    for _, delivery := range order {
+        parts = append(parts, strconv.Itoa(counts[delivery])+" "+delivery)
+    }
+    return strings.Join(parts, ", ")
+}
+
+func PlanSecretGroup(dao *DatabaseDAO, groupUuid string, contacts []Contact) (*SecretGroupPlan, error) {
+    // Derives the secret UUID of every recipient and decides which ones to send : recipients whose secret is already
+    // in the database are skipped
+
+    if len(contacts) == 0 {
+        return nil, fmt.Errorf("no recipient chosen")
+    }
+    plan := &SecretGroupPlan{GroupUuid: groupUuid}
+    seen := map[string]string{}
+    for _, contact := range contacts {
+        secretUuid, err := GroupSecretUuid(groupUuid, contact.PublicKey)
+        if err != nil {
+            return nil, err
+        }
+        send := &SecretGroupSend{
+            SecretGroupMember: SecretGroupMember{
+                GroupUuid:   groupUuid,
+                SecretUuid:  secretUuid,
+                ContactName: contact.Name,
+                Fingerprint: contact.Fingerprint,
+                Result:      ImportSend,
+            },
+            PublicKey: contact.PublicKey,
+        }
+        plan.Sends = append(plan.Sends, send)
+
+        if name, ok := seen[secretUuid]; ok {
+            send.Result, send.Reason = ImportInvalid, "same public key as "+name
+            continue
+        }
+        seen[secretUuid] = contact.Name
+        exists, err := dao.EntryExists(EntrySecret, secretUuid)
+        if err != nil {
+            return nil, err
+        }
+        if exists {
+            send.Result, send.Reason = ImportSkipped, "already sent"
+        }
+    }
+    return plan, nil
+}
+
+func (plan *SecretGroupPlan) Count(result string) int {
+    // Returns the number of recipients with a given outcome
+
+    count := 0
+    for _, send := range plan.Sends {
+        if send.Result == result {
+            count++
+        }
+    }
+    return count
+}
+
+func (plan *SecretGroupPlan) Summary() string {
+    // Returns a one line summary of the plan or of its results
+
+    labels := []struct{ result, label string }{
+        {ImportSend, "to send"},
+        {ImportAccepted, "accepted"},
+        {ImportRejected, "rejected"},
+        {ImportError, "in error"},
+        {ImportSkipped, "already sent"},
+        {ImportInvalid, "invalid"},
+    }
+    parts := []string{strconv.Itoa(len(plan.Sends)) + " recipients"}
+    for _, label := range labels {
+        if count := plan.Count(label.result); count > 0 {
+            parts = append(parts, strconv.Itoa(count)+" "+label.label)
+        }
+    }
+    return strings.Join(parts, ", ")
+}
+
+func (plan *SecretGroupPlan) Failed() bool {
+    // Indicates if a recipient was invalid or could not be sent to
+
+    return plan.Count(ImportInvalid)+plan.Count(ImportRejected)+plan.Count(ImportError) > 0
+}
+
+func (runner *SecretGroupRunner) send(send *SecretGroupSend) {
+    // Seals the content for one recipient and sends it as its own secret transaction
+
+    recipientPublicKey, err := utils.CreatePublicKeyX25519FromBase64(send.PublicKey)
+    if err != nil {
+        send.Result, send.Reason = ImportError, err.Error()
+        return
+    }
+    secretData := SecretHandler{
+        Config:          runner.Config,
+        UuidText:        send.SecretUuid,
+        Content:         runner.Content,
+        RecipientPubKey: recipientPublicKey,
+        SenderPubKey:    runner.SenderPubKey,
+        SenderPrivKey:   runner.SenderPrivKey,
+    }
+    transactionStatus, err := secretData.SendSecret()
+    send.Signed, send.SendStatus = secretData.Signed, transactionStatus
+    if secretData.Signed != nil {
+        send.RequestDigest = secretData.Signed.Digest()
+    }
+    if transactionStatus == nil {
+        send.Result, send.Reason = ImportError, err.Error()
+        return
+    }
+    send.Code, send.Message = transactionStatus.Code, transactionStatus.Message
+    send.Result = ImportAccepted
+    if transactionStatus.Code != 0 {
+        send.Result, send.Reason = ImportRejected, transactionStatus.Message
+    } else if err != nil {
+        // Sent but missing from the history, the secret is recorded all the same
+        send.Reason = err.Error()
+    }
+}
+
+func (runner *SecretGroupRunner) record(send *SecretGroupSend) {
+    // Records the outcome of a send on the group, and an accepted secret as a pending entry whose recipient holds
+    // the private key
+
+    if send.Result == ImportAccepted {
+        err := runner.Dao.AddSecretEntry(send.SecretUuid, "", send.RequestDigest, runner.Config.Profile)
+        if err == nil {
+            err = runner.Dao.SetStatus(EntrySecret, send.SecretUuid, StatusPending)
+        }
+        if err != nil {
+            send.Reason = "sent but not recorded: " + err.Error()
+        } else {
+            send.Status = StatusPending
+        }
+    }
+    if err := runner.Dao.recordGroupMember(&send.SecretGroupMember); err != nil && send.Reason == "" {
+        send.Reason = "not recorded in the group: " + err.Error()
+    }
+}
+
+func (runner *SecretGroupRunner) Run(plan *SecretGroupPlan) error {
+    // Records the group and sends its secrets one recipient after the other
+
+    _, err := runner.Dao.auditedExec("secretGroups", plan.GroupUuid, AuditInsert,
+        "INSERT OR IGNORE INTO secretGroups (uuid, profile, createdAt) VALUES (?, ?, ?)", plan.GroupUuid, runner.Config.Profile, now())
+    if err != nil {
+        return err
+    }
+    for _, send := range plan.Sends {
+        if send.Result != ImportSend {
+            continue
+        }
+        runner.send(send)
+        runner.record(send)
+        if runner.OnResult != nil {
+            runner.OnResult(send)
+        }
+    }
+    return nil
+}
+
+func (dao *DatabaseDAO) recordGroupMember(member *SecretGroupMember) error {
+    // Adds a recipient to its group, or updates the outcome of the previous send to it
+
+    var count int
+    if err := dao.Db.QueryRow("SELECT COUNT(*) FROM secretGroupMembers WHERE secretUuid = ?", member.SecretUuid).Scan(&count); err != nil {
+        return err
+    }
+    member.UpdatedAt = now()
+    if count > 0 {
+        _, err := dao.auditedExec("secretGroupMembers", member.SecretUuid, AuditUpdate,
+            "UPDATE secretGroupMembers SET result = ?, reason = ?, updatedAt = ? WHERE secretUuid = ?",
+            member.Result, member.Reason, member.UpdatedAt, member.SecretUuid)
+        return err
+    }
+    _, err := dao.auditedExec("secretGroupMembers", member.SecretUuid, AuditInsert,
+        "INSERT INTO secretGroupMembers (secretUuid, groupUuid, contactName, fingerprint, result, reason, createdAt, updatedAt) "+
+            "VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+        member.SecretUuid, member.GroupUuid, member.ContactName, member.Fingerprint, member.Result, member.Reason, member.UpdatedAt, member.UpdatedAt)
+    return err
+}
+
+func (dao *DatabaseDAO) ListSecretGroups() ([]SecretGroup, error) {
+    // Returns the secret groups, the latest first, with the delivery of each recipient
+
+    rows, err := dao.Db.Query("SELECT uuid, profile, createdAt FROM secretGroups ORDER BY createdAt DESC, uuid")
+    if err != nil {
+        return nil, err
+    }
+    groups := []SecretGroup{}
+    for rows.Next() {
+        group := SecretGroup{}
+        if err := rows.Scan(&group.Uuid, &group.Profile, &group.CreatedAt); err != nil {
+            _ = rows.Close()
+            return nil, err
+        }
+        groups = append(groups, group)
+    }
+    _ = rows.Close()
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+    for i := range groups {
+        if groups[i].Members, err = dao.secretGroupMembers(groups[i].Uuid); err != nil {
+            return nil, err
+        }
+    }
+    return groups, nil
+}
+
+func (dao *DatabaseDAO) GetSecretGroup(groupUuid string) (*SecretGroup, error) {
+    // Returns a secret group with the delivery of each recipient
+
+    group := &SecretGroup{}
+    err := dao.Db.QueryRow("SELECT uuid, profile, createdAt FROM secretGroups WHERE uuid = ?", groupUuid).
+        Scan(&group.Uuid, &group.Profile, &group.CreatedAt)
+    if err == sql.ErrNoRows {
+        return nil, ErrNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    if group.Members, err = dao.secretGroupMembers(groupUuid); err != nil {
+        return nil, err
+    }
+    return group, nil
+}
+
+func (dao *DatabaseDAO) secretGroupMembers(groupUuid string) ([]SecretGroupMember, error) {
+    // Returns the recipients of a group with the status of their secret entry, if it is still in the database
+
+    rows, err := dao.Db.Query("SELECT member.secretUuid, member.contactName, member.fingerprint, member.result, member.reason, "+
+        "COALESCE(secrets.status, ''), COALESCE(secrets.requestDigest, ''), member.updatedAt FROM secretGroupMembers member "+
+        "LEFT JOIN secrets ON secrets.uuid = member.secretUuid WHERE member.groupUuid = ? ORDER BY member.contactName COLLATE NOCASE", groupUuid)
+    if err != nil {
+        return nil, err
+    }
+    defer func() {
+        _ = rows.Close()
+    }()
+    members := []SecretGroupMember{}
+    for rows.Next() {
+        member := SecretGroupMember{GroupUuid: groupUuid}
+        err := rows.Scan(&member.SecretUuid, &member.ContactName, &member.Fingerprint, &member.Result, &member.Reason,
+            &member.Status, &member.RequestDigest, &member.UpdatedAt)
+        if err != nil {
+            return nil, err
+        }
+        members = append(members, member)
+    }
+    return members, rows.Err()
+}
@@ -0,0 +1,218 @@
+package libs
+
+import (
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/katena-chain/sdk-go-client/api"
+    entityApi "github.com/katena-chain/sdk-go-client/entity/api"
+)
+
+// Statuses of a transaction recorded in the database
+const (
+    StatusPending   = "pending"
+    StatusCommitted = "committed"
+    StatusFailed    = "failed"
+    // Not found on chain before the tracker gave up, the transaction may still be committed later
+    StatusTimedOut = "timed-out"
+)
+
+// Kinds of database entries a transaction can be tracked for
+const (
+    EntryCertificate = "certificate"
+    EntrySecret      = "secret"
+)
+
+// Default polling parameters of a status tracker
+const DefaultPollInterval = time.Second
+const DefaultPollTimeout = time.Minute
+
+type StatusTracker struct {
+    Dao      *DatabaseDAO
+    Config   Config
+    Interval time.Duration
+    Timeout  time.Duration
+    OnChange func(kind string, uuid string, status string)
+}
+
+func entryTable(kind string) (string, error) {
+    // Returns the table holding the entries of a kind
+
+    switch kind {
+    case EntryCertificate:
+        return "certificates", nil
+    case EntrySecret:
+        return "secrets", nil
+    default:
+        return "", fmt.Errorf("unknown entry kind: %s", kind)
+    }
+}
+
+func OptionLabel(uuid string, status string) string {
+    // Returns the select option of an entry, its status shown as a badge after the uuid
+
+    if status == "" {
+        return uuid
+    }
+    return uuid + " [" + status + "]"
+}
+
+func UuidFromOption(option string) string {
+    // Returns the uuid of a select option built by OptionLabel
+
+    if index := strings.Index(option, " ["); index >= 0 {
+        return option[:index]
+    }
+    return option
+}
+
+func OptionForUuid(options []string, uuid string) string {
+    // Returns the option of a uuid among the options of a select, or the uuid itself if it is absent
+
+    for _, option := range options {
+        if UuidFromOption(option) == uuid {
+            return option
+        }
+    }
+    return uuid
+}
+
+func NewStatusTracker(dao *DatabaseDAO, config Config) *StatusTracker {
+    return &StatusTracker{
+        Dao:      dao,
+        Config:   config,
+        Interval: DefaultPollInterval,
+        Timeout:  DefaultPollTimeout,
+    }
+}
+
+func sameSeal(transaction *entityApi.Transaction, other *entityApi.Transaction) bool {
+    // Indicates if two transactions carry the same seal signature
+
+    if transaction == nil || other == nil || transaction.Seal == nil || other.Seal == nil ||
+        transaction.Seal.Signature == nil || other.Seal.Signature == nil {
+        return false
+    }
+    return *transaction.Seal.Signature == *other.Seal.Signature
+}
+
+func (tracker *StatusTracker) Poll(kind string, uuid string, signed *SignedTransaction) (*entityApi.TransactionStatus, error) {
+    // Looks for a signed transaction on chain, returning its status or nil if it is not there yet
+
+    apiHandler := api.NewHandler(tracker.Config.ApiUrl)
+    switch kind {
+    case EntryCertificate:
+        transactionWrapper, err := apiHandler.RetrieveCertificate(tracker.Config.CompanyChainID, uuid)
+        if err != nil {
+            return nil, err
+        }
+        if sameSeal(transactionWrapper.Transaction, signed.Transaction) {
+            return transactionWrapper.Status, nil
+        }
+        return nil, nil
+    case EntrySecret:
+        transactionWrappers, err := apiHandler.RetrieveSecrets(tracker.Config.CompanyChainID, uuid)
+        if err != nil {
+            return nil, err
+        }
+        for _, transactionWrapper := range transactionWrappers.Transactions {
+            if sameSeal(transactionWrapper.Transaction, signed.Transaction) {
+                return transactionWrapper.Status, nil
+            }
+        }
+        return nil, nil
+    default:
+        return nil, fmt.Errorf("unknown entry kind: %s", kind)
+    }
+}
+
+func (tracker *StatusTracker) setStatus(kind string, uuid string, signed *SignedTransaction, status string,
+    transactionStatus *entityApi.TransactionStatus) {
+    // Records a status on the entry and in the history, with the answer of the chain if any, and notifies the caller
+
+    _ = tracker.Dao.SetStatus(kind, uuid, status)
+    _ = tracker.Dao.SetTransactionStatus(signed.Digest(), status, transactionStatus)
+    if tracker.OnChange != nil {
+        tracker.OnChange(kind, uuid, status)
+    }
+}
+
+func (tracker *StatusTracker) Track(kind string, uuid string, signed *SignedTransaction, sendStatus *entityApi.TransactionStatus) (string, string) {
+    // Follows a broadcast transaction until it is committed, rejected or the timeout expires, and returns its
+    // final status with an explanation. Only a rejection code of the chain makes it failed.
+
+    if sendStatus != nil && sendStatus.Code != 0 {
+        tracker.setStatus(kind, uuid, signed, StatusFailed, sendStatus)
+        return StatusFailed, sendStatus.Message
+    }
+    tracker.setStatus(kind, uuid, signed, StatusPending, nil)
+
+    deadline := time.Now().Add(tracker.Timeout)
+    var lastErr error
+    for {
+        status, err := tracker.Poll(kind, uuid, signed)
+        lastErr = err
+        if status != nil {
+            if status.Code != 0 {
+                tracker.setStatus(kind, uuid, signed, StatusFailed, status)
+                return StatusFailed, status.Message
+            }
+            tracker.setStatus(kind, uuid, signed, StatusCommitted, status)
+            return StatusCommitted, status.Message
+        }
+        if time.Now().Add(tracker.Interval).After(deadline) {
+            break
+        }
+        time.Sleep(tracker.Interval)
+    }
+
+    tracker.setStatus(kind, uuid, signed, StatusTimedOut, nil)
+    if lastErr != nil {
+        return StatusTimedOut, "not committed after " + tracker.Timeout.String() + ": " + lastErr.Error()
+    }
+    return StatusTimedOut, "not committed after " + tracker.Timeout.String()
+}
+
+func (tracker *StatusTracker) Refresh() ([]HistoryEntry, error) {
+    // Polls once the history transactions still pending or timed out, against the API and company they were sent
+    // to, and records the status of the ones the chain returned. The transactions whose status changed are
+    // returned with it.
+
+    var waiting []HistoryEntry
+    for _, status := range []string{StatusPending, StatusTimedOut} {
+        entries, err := tracker.Dao.ListTransactions(HistoryFilter{Status: status})
+        if err != nil {
+            return nil, err
+        }
+        waiting = append(waiting, entries...)
+    }
+
+    var changed []HistoryEntry
+    polled := map[string]bool{}
+    for _, entry := range waiting {
+        // A transaction sent again is polled once, its history rows sharing the same digest
+        if entry.Kind == "" || polled[entry.RequestDigest] {
+            continue
+        }
+        polled[entry.RequestDigest] = true
+        transaction, err := DecodeTransaction([]byte(entry.SignedBytes))
+        if err != nil {
+            continue
+        }
+        signed := &SignedTransaction{Transaction: transaction, Bytes: []byte(entry.SignedBytes)}
+        entryTracker := *tracker
+        entryTracker.Config.ApiUrl, entryTracker.Config.CompanyChainID = entry.ApiUrl, entry.CompanyChainID
+        transactionStatus, _ := entryTracker.Poll(entry.Kind, entry.Uuid, signed)
+        if transactionStatus == nil {
+            continue
+        }
+        entry.Status, entry.Code, entry.Message = StatusCommitted, transactionStatus.Code, transactionStatus.Message
+        if transactionStatus.Code != 0 {
+            entry.Status = StatusFailed
+        }
+        entryTracker.setStatus(entry.Kind, entry.Uuid, signed, entry.Status, transactionStatus)
+        changed = append(changed, entry)
+    }
+    return changed, nil
+}
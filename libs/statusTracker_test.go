@@ -0,0 +1,93 @@
+package libs
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/katena-chain/transactor-ui/libs/testutil"
+)
+
+func trackTestCertificate(t *testing.T, answer func(signed *SignedTransaction) http.HandlerFunc) string {
+    // Tracks a certificate against an API answering with a handler and returns the status recorded on its entry
+
+    signed := newTestSignedCertificate(t)
+    server := httptest.NewServer(answer(signed))
+    defer server.Close()
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+    if err := dao.AddCertificateEntry(testutil.Uuid, "signature", "signer", signed.Digest(), "default"); err != nil {
+        t.Fatal(err)
+    }
+
+    tracker := NewStatusTracker(dao, Config{ChainID: testutil.ChainID, CompanyChainID: testutil.CompanyChainID, ApiUrl: server.URL})
+    tracker.Interval, tracker.Timeout = 10*time.Millisecond, 50*time.Millisecond
+    status, _ := tracker.Track(EntryCertificate, testutil.Uuid, signed, nil)
+    recorded, err := dao.GetStatus(EntryCertificate, testutil.Uuid)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if recorded != status {
+        t.Fatalf("status %s recorded as %s", status, recorded)
+    }
+    return status
+}
+
+func TestTrackTimeout(t *testing.T) {
+    // A transaction the chain does not return yet is not failed, it may still be committed
+
+    status := trackTestCertificate(t, func(signed *SignedTransaction) http.HandlerFunc {
+        return http.NotFound
+    })
+    if status != StatusTimedOut {
+        t.Fatalf("unexpected status %s", status)
+    }
+}
+
+func TestTrackRejection(t *testing.T) {
+    status := trackTestCertificate(t, func(signed *SignedTransaction) http.HandlerFunc {
+        return func(writer http.ResponseWriter, request *http.Request) {
+            _, _ = writer.Write([]byte(`{"transaction":` + string(signed.Bytes) + `,"status":{"code":1,"message":"rejected"}}`))
+        }
+    })
+    if status != StatusFailed {
+        t.Fatalf("unexpected status %s", status)
+    }
+}
+
+func TestRefreshPending(t *testing.T) {
+    // A transaction left pending by a send without tracking is followed up by a later refresh
+
+    signed := newTestSignedCertificate(t)
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        if request.Method == http.MethodPost {
+            writer.WriteHeader(http.StatusAccepted)
+            _, _ = writer.Write([]byte(`{"code":0,"message":"accepted"}`))
+            return
+        }
+        _, _ = writer.Write([]byte(`{"transaction":` + string(signed.Bytes) + `,"status":{"code":0,"message":"committed"}}`))
+    }))
+    defer server.Close()
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+
+    config := Config{ChainID: testutil.ChainID, CompanyChainID: testutil.CompanyChainID, ApiUrl: server.URL, History: dao}
+    if _, err := config.Broadcast(signed); err != nil {
+        t.Fatal(err)
+    }
+    if err := dao.AddCertificateEntry(testutil.Uuid, "signature", "signer", signed.Digest(), "default"); err != nil {
+        t.Fatal(err)
+    }
+
+    changed, err := NewStatusTracker(dao, Config{}).Refresh()
+    if err != nil || len(changed) != 1 || changed[0].Status != StatusCommitted {
+        t.Fatalf("unexpected refresh: %+v, %v", changed, err)
+    }
+    if status, err := dao.GetStatus(EntryCertificate, testutil.Uuid); err != nil || status != StatusCommitted {
+        t.Fatalf("entry recorded as %s: %v", status, err)
+    }
+    if changed, err := NewStatusTracker(dao, Config{}).Refresh(); err != nil || len(changed) != 0 {
+        t.Fatalf("committed transaction polled again: %+v, %v", changed, err)
+    }
+}
@@ -0,0 +1,54 @@
+package testutil
+
+import (
+    "encoding/base64"
+    "io/ioutil"
+    "os"
+    "testing"
+
+    "github.com/katena-chain/sdk-go-client/crypto/ED25519"
+    "github.com/katena-chain/sdk-go-client/utils"
+    "golang.org/x/crypto/ed25519"
+)
+
+// Values shared by the tests of the libs and mockapi packages. This package cannot import libs, whose own tests
+// use it, so each package opens its databases itself in a directory from TempDir.
+const (
+    ChainID        = "test-chain"
+    CompanyChainID = "test-company"
+    Uuid           = "2075c941-6876-405b-87d5-13791c0dc53a"
+    OtherUuid      = "7f0bbd3b-0d57-4f7e-a3f4-6bd0b0a3d2a8"
+    Passphrase     = "correct horse battery staple"
+)
+
+func PrivateKey(t *testing.T) string {
+    // Returns a new base64 ED25519 transactor private key, as set in a profile
+
+    _, privateKey, err := ed25519.GenerateKey(nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return base64.StdEncoding.EncodeToString(privateKey)
+}
+
+func TransactorKey(t *testing.T) *ED25519.PrivateKey {
+    // Returns a new transactor private key to sign transactions with
+
+    transactorKey, err := utils.CreatePrivateKeyED25519FromBase64(PrivateKey(t))
+    if err != nil {
+        t.Fatal(err)
+    }
+    return transactorKey
+}
+
+func TempDir(t *testing.T) (string, func()) {
+    // Creates a temporary directory, returning the function removing it
+
+    directory, err := ioutil.TempDir("", "transactor-ui-test")
+    if err != nil {
+        t.Fatal(err)
+    }
+    return directory, func() {
+        _ = os.RemoveAll(directory)
+    }
+}
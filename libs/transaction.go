@@ -0,0 +1,107 @@
+package libs
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/katena-chain/sdk-go-client/api"
+    "github.com/katena-chain/sdk-go-client/crypto/ED25519"
+    "github.com/katena-chain/sdk-go-client/entity"
+    entityApi "github.com/katena-chain/sdk-go-client/entity/api"
+    "github.com/katena-chain/sdk-go-client/entity/certify"
+)
+
+// API routes the signed transactions are posted to
+const certificateCertifyRoute = "certificates/certify"
+const secretCertifyRoute = "certificates/%s-%s/secrets/certify"
+
+type SignedTransaction struct {
+    Transaction *entityApi.Transaction
+    Bytes       []byte
+}
+
+func NewSealState(message entity.Message, chainID string, nonceTime *entity.Time) ([]byte, error) {
+    // Returns the sorted bytes a transactor signs for a message on a chain at a given nonce time
+
+    sealState := &entity.SealState{
+        Message:   message,
+        ChainID:   chainID,
+        NonceTime: nonceTime,
+    }
+    return sealState.GetSignBytes()
+}
+
+func SignMessage(message entity.Message, chainID string, privateKey *ED25519.PrivateKey) (*SignedTransaction, error) {
+    // Seals a message with the transactor key and keeps the exact bytes that will be broadcast
+
+    nonceTime := entity.Time{
+        Time: time.Now(),
+    }
+    sealStateBytes, err := NewSealState(message, chainID, &nonceTime)
+    if err != nil {
+        return nil, err
+    }
+
+    msgSignature := privateKey.Sign(sealStateBytes)
+    transaction := entityApi.NewTransaction(message, msgSignature, privateKey.GetPublicKey(), &nonceTime)
+    return NewSignedTransaction(transaction)
+}
+
+func NewSignedTransaction(transaction *entityApi.Transaction) (*SignedTransaction, error) {
+    // Freezes a transaction into the bytes that will be broadcast
+
+    data, err := json.Marshal(transaction)
+    if err != nil {
+        return nil, err
+    }
+    return &SignedTransaction{
+        Transaction: transaction,
+        Bytes:       data,
+    }, nil
+}
+
+func (signed *SignedTransaction) Digest() string {
+    // Returns the request digest, the uppercase hex encoded SHA-256 of the signed request sent to the API. It
+    // identifies the request in the history and is not the hash the chain gives the transaction.
+
+    hash := sha256.Sum256(signed.Bytes)
+    return strings.ToUpper(hex.EncodeToString(hash[:]))
+}
+
+func (signed *SignedTransaction) Preview() (string, error) {
+    // Returns the indented JSON of the transaction
+
+    data, err := json.MarshalIndent(signed.Transaction, " ", "    ")
+    if err != nil {
+        return "", err
+    }
+    return string(data), nil
+}
+
+func (signed *SignedTransaction) Route() (string, error) {
+    // Returns the API route matching the message of the transaction
+
+    switch message := signed.Transaction.Message.(type) {
+    case *certify.MsgCreateCertificate:
+        return certificateCertifyRoute, nil
+    case *certify.MsgCreateSecret:
+        return fmt.Sprintf(secretCertifyRoute, message.Secret.GetCompanyChainID(), message.Secret.GetCertificateUuid()), nil
+    default:
+        return "", fmt.Errorf("bad message type: %s", signed.Transaction.Message.GetType())
+    }
+}
+
+func (signed *SignedTransaction) Broadcast(apiUrl string) (*entityApi.TransactionStatus, error) {
+    // Posts the frozen bytes, unchanged, to the API
+
+    route, err := signed.Route()
+    if err != nil {
+        return nil, err
+    }
+    apiHandler := api.NewHandler(apiUrl)
+    return apiHandler.SendTransaction(route, signed.Bytes)
+}
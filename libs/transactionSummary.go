@@ -0,0 +1,221 @@
+package libs
+
+import (
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "strconv"
+    "strings"
+    "unicode"
+    "unicode/utf8"
+
+    entityApi "github.com/katena-chain/sdk-go-client/entity/api"
+    "github.com/katena-chain/sdk-go-client/entity/certify"
+)
+
+// Format of the nonce times shown to the user, in local time
+const DisplayTimeFormat = "2006-01-02 15:04:05"
+
+type SummaryField struct {
+    Label string `json:"label"`
+    Value string `json:"value"`
+}
+
+// Decoded content of a transaction, for a user to check it before broadcasting it
+type TransactionSummary struct {
+    Entry     *TransactionEntry
+    ChainID   string
+    SealValid bool
+    Fields    []SummaryField
+}
+
+func SummarizeTransaction(transaction *entityApi.Transaction, chainID string) (*TransactionSummary, error) {
+    // Decodes the message of a transaction and checks its seal against the embedded public key and a chain id
+
+    entry, err := DescribeMessage(transaction.Message)
+    if err != nil {
+        return nil, err
+    }
+    sealValid, err := VerifyTransactionSeal(transaction, chainID)
+    if err != nil {
+        return nil, err
+    }
+    summary := &TransactionSummary{
+        Entry:     entry,
+        ChainID:   chainID,
+        SealValid: sealValid,
+    }
+    add := func(label string, value string) {
+        summary.Fields = append(summary.Fields, SummaryField{label, value})
+    }
+
+    add("Type", transaction.Message.GetType())
+    add("UUID", entry.Uuid)
+    add("Company chain id", entry.CompanyChainID)
+    add("Chain id", chainID)
+    if transaction.NonceTime != nil {
+        add("Nonce time", transaction.NonceTime.Local().Format(DisplayTimeFormat))
+    }
+    if transaction.Seal != nil && transaction.Seal.Signer != nil {
+        add("Transactor public key", base64.StdEncoding.EncodeToString(transaction.Seal.Signer[:]))
+        add("Transactor key fingerprint", Fingerprint(transaction.Seal.Signer[:]))
+    }
+    if sealValid {
+        add("Seal", "valid for chain "+chainID)
+    } else {
+        add("Seal", "NOT valid for chain "+chainID)
+    }
+
+    switch message := transaction.Message.(type) {
+    case *certify.MsgCreateCertificate:
+        if certificate, ok := message.Certificate.(*certify.CertificateV1); ok && certificate.Seal != nil {
+            add("Data signature", DisplayBytes(certificate.Seal.Signature))
+            add("Data signer", DisplayBytes(certificate.Seal.Signer))
+        }
+    case *certify.MsgCreateSecret:
+        if secret, ok := message.Secret.(*certify.SecretV1); ok && secret.Lock != nil {
+            if secret.Lock.Encryptor != nil {
+                add("Sender public key", base64.StdEncoding.EncodeToString(secret.Lock.Encryptor[:]))
+                add("Sender key fingerprint", Fingerprint(secret.Lock.Encryptor[:]))
+            }
+            add("Encrypted content", strconv.Itoa(len(secret.Lock.Content))+" bytes")
+        }
+    }
+    return summary, nil
+}
+
+func (signed *SignedTransaction) Summary(chainID string) (*TransactionSummary, error) {
+    // Decodes a signed transaction along with the hash of the bytes that will be broadcast
+
+    summary, err := SummarizeTransaction(signed.Transaction, chainID)
+    if err != nil {
+        return nil, err
+    }
+    summary.Fields = append(summary.Fields, SummaryField{"Request digest", signed.Digest()})
+    return summary, nil
+}
+
+func (summary *TransactionSummary) String() string {
+    // Returns one "label : value" line per field
+
+    lines := make([]string, len(summary.Fields))
+    for i, field := range summary.Fields {
+        lines[i] = field.Label + " : " + field.Value
+    }
+    return strings.Join(lines, "\n")
+}
+
+func DisplayBytes(data []byte) string {
+    // Returns bytes as text when they are printable UTF-8, hex encoded with a prefix otherwise
+
+    if utf8.Valid(data) {
+        printable := true
+        for _, character := range string(data) {
+            if !unicode.IsPrint(character) && !unicode.IsSpace(character) {
+                printable = false
+                break
+            }
+        }
+        if printable {
+            return string(data)
+        }
+    }
+    return "hex:" + hex.EncodeToString(data)
+}
+
+func Fingerprint(publicKey []byte) string {
+    // Returns the first 16 bytes of the SHA-256 of a public key, as colon separated hex pairs easy to compare by eye
+
+    hash := sha256.Sum256(publicKey)
+    pairs := make([]string, 16)
+    for i := range pairs {
+        pairs[i] = strings.ToUpper(hex.EncodeToString(hash[i : i+1]))
+    }
+    return strings.Join(pairs, ":")
+}
+
+func StatusExplanation(status *entityApi.TransactionStatus) string {
+    // Explains a transaction status returned by the API in plain words
+
+    if status == nil {
+        return "No status returned by the API"
+    }
+    if status.Code != 0 {
+        return fmt.Sprintf("Rejected with code %d: %s. The entry was not created on chain, it has to be sent again "+
+            "once the cause is fixed.", status.Code, status.Message)
+    }
+    message := strings.ToLower(status.Message)
+    switch {
+    case strings.Contains(message, "commit"):
+        return "Committed: the transaction is part of a block and can no longer be altered."
+    case strings.Contains(message, "accept"), strings.Contains(message, "pending"):
+        return "Accepted: the API validated the transaction, it is waiting to be committed in a block."
+    default:
+        return "Successful: " + status.Message
+    }
+}
+
+func DescribeTransactionWrapper(transactionWrapper *entityApi.TransactionWrapper, chainID string) (*TransactionSummary, error) {
+    // Decodes a transaction retrieved from the API along with its status
+
+    if transactionWrapper.Transaction == nil {
+        return nil, fmt.Errorf("no transaction returned by the API")
+    }
+    summary, err := SummarizeTransaction(transactionWrapper.Transaction, chainID)
+    if err != nil {
+        return nil, err
+    }
+    code, message := "", ""
+    if transactionWrapper.Status != nil {
+        code, message = strconv.FormatUint(uint64(transactionWrapper.Status.Code), 10), transactionWrapper.Status.Message
+    }
+    summary.Fields = append(summary.Fields,
+        SummaryField{"Status code", code},
+        SummaryField{"Status message", message},
+        SummaryField{"Status", StatusExplanation(transactionWrapper.Status)},
+    )
+    return summary, nil
+}
+
+func DescribeSecrets(transactionWrappers *entityApi.TransactionWrappers, recipientPrivKey string, chainID string) ([]*TransactionSummary, error) {
+    // Decodes the secrets retrieved from the API, with their content when the recipient private key opens them
+
+    var decryptedSecrets []DecryptedSecret
+    if recipientPrivKey != "" {
+        var err error
+        if decryptedSecrets, err = OpenSecrets(transactionWrappers, recipientPrivKey); err != nil {
+            return nil, err
+        }
+    }
+    summaries := make([]*TransactionSummary, len(transactionWrappers.Transactions))
+    for i, transactionWrapper := range transactionWrappers.Transactions {
+        summary, err := DescribeTransactionWrapper(transactionWrapper, chainID)
+        if err != nil {
+            return nil, err
+        }
+        switch {
+        case decryptedSecrets == nil:
+            summary.Fields = append(summary.Fields, SummaryField{"Content", "no recipient private key stored for this secret"})
+        case decryptedSecrets[i].Err != nil:
+            summary.Fields = append(summary.Fields, SummaryField{"Content", "cannot be decrypted: " + decryptedSecrets[i].Err.Error()})
+        case decryptedSecrets[i].File != nil:
+            summary.Fields = append(summary.Fields, SummaryField{"File", decryptedSecrets[i].File.String()})
+        default:
+            summary.Fields = append(summary.Fields, SummaryField{"Content", string(decryptedSecrets[i].Content)})
+        }
+        summaries[i] = summary
+    }
+    return summaries, nil
+}
+
+func RawJSON(value interface{}) (string, error) {
+    // Returns the indented JSON returned by the API, for the raw view
+
+    data, err := json.MarshalIndent(value, "", "    ")
+    if err != nil {
+        return "", err
+    }
+    return string(data), nil
+}
@@ -0,0 +1,148 @@
+package libs
+
+import (
+    "bytes"
+    "fmt"
+    "strings"
+
+    entityApi "github.com/katena-chain/sdk-go-client/entity/api"
+    "github.com/katena-chain/sdk-go-client/entity/certify"
+)
+
+// Possible results of a certificate verification
+const (
+    VerificationMatch      = "match"
+    VerificationMismatch   = "mismatch"
+    VerificationNotTracked = "not tracked locally"
+    VerificationInvalid    = "invalid on chain"
+)
+
+// Invalid lists what is wrong with the certificate on chain whatever the database holds, Mismatches what differs
+// from the local record
+type CertificateVerification struct {
+    Result     string
+    SealValid  bool
+    Invalid    []string
+    Mismatches []string
+}
+
+func (verification *CertificateVerification) String() string {
+    // Returns a one line summary of the verification
+
+    seal := "valid transaction seal"
+    if !verification.SealValid {
+        seal = "invalid transaction seal"
+    }
+    summary := strings.ToUpper(verification.Result[:1]) + verification.Result[1:] + " (" + seal + ")"
+    if len(verification.Invalid) > 0 {
+        summary += " : " + strings.Join(verification.Invalid, ", ")
+    }
+    if len(verification.Mismatches) > 0 {
+        summary += " : differs from the local record in " + strings.Join(verification.Mismatches, ", ")
+    }
+    return summary
+}
+
+func VerifyTransactionSeal(transaction *entityApi.Transaction, chainID string) (bool, error) {
+    // Checks the ED25519 seal signature of a transaction against the chain id it should have been signed for
+
+    if transaction.Seal == nil || transaction.Seal.Signature == nil || transaction.Seal.Signer == nil || transaction.NonceTime == nil {
+        return false, nil
+    }
+    sealStateBytes, err := NewSealState(transaction.Message, chainID, transaction.NonceTime)
+    if err != nil {
+        return false, err
+    }
+    return transaction.Seal.Signer.Verify(sealStateBytes, transaction.Seal.Signature), nil
+}
+
+func CertificateOf(transaction *entityApi.Transaction) (*certify.CertificateV1, error) {
+    // Returns the certificate carried by a transaction
+
+    message, ok := transaction.Message.(*certify.MsgCreateCertificate)
+    if !ok {
+        return nil, fmt.Errorf("bad message type: %s", transaction.Message.GetType())
+    }
+    if message.Certificate == nil {
+        return nil, fmt.Errorf("missing certificate in the message")
+    }
+    certificate, ok := message.Certificate.(*certify.CertificateV1)
+    if !ok {
+        return nil, fmt.Errorf("bad certificate type: %s", message.Certificate.GetType())
+    }
+    return certificate, nil
+}
+
+func (certHandler *CertificateHandler) VerifyCertificate(transactionWrapper *entityApi.TransactionWrapper, dao *DatabaseDAO) (*CertificateVerification, error) {
+    // Checks the seal and company of an on-chain certificate, then compares it with what was recorded locally when
+    // it was sent
+
+    certificate, err := CertificateOf(transactionWrapper.Transaction)
+    if err != nil {
+        return nil, err
+    }
+
+    verification := &CertificateVerification{}
+    verification.SealValid, _ = VerifyTransactionSeal(transactionWrapper.Transaction, certHandler.Config.ChainID)
+    if !verification.SealValid {
+        verification.Invalid = append(verification.Invalid, "seal signature")
+    }
+    if certificate.Uuid != certHandler.UuidText {
+        verification.Invalid = append(verification.Invalid, "UUID")
+    }
+    if certificate.CompanyChainID != certHandler.Config.CompanyChainID {
+        verification.Invalid = append(verification.Invalid, "company chain id")
+    }
+
+    signature, signer, err := dao.GetSignatureAndSigner(certHandler.UuidText)
+    if err == ErrNotFound {
+        verification.Result = VerificationNotTracked
+        if len(verification.Invalid) > 0 {
+            verification.Result = VerificationInvalid
+        }
+        return verification, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    if certificate.Seal == nil || !bytes.Equal(certificate.Seal.Signature, signature) {
+        verification.Mismatches = append(verification.Mismatches, "signature")
+    }
+    if certificate.Seal == nil || !bytes.Equal(certificate.Seal.Signer, signer) {
+        verification.Mismatches = append(verification.Mismatches, "signer")
+    }
+
+    verification.Result = VerificationMatch
+    if len(verification.Invalid) > 0 {
+        verification.Result = VerificationInvalid
+    } else if len(verification.Mismatches) > 0 {
+        verification.Result = VerificationMismatch
+    }
+    return verification, nil
+}
+
+func (certHandler *CertificateHandler) RetrieveAndVerifyCertificate(dao *DatabaseDAO) (*TransactionSummary, string, *CertificateVerification, error) {
+    // Retrieves the certificate, verifies it against the database and returns its decoded fields and indented
+    // JSON with the verification
+
+    transactionWrapper, err := certHandler.GetCertificate()
+    if err != nil {
+        return nil, "", nil, err
+    }
+
+    verification, err := certHandler.VerifyCertificate(transactionWrapper, dao)
+    if err != nil {
+        return nil, "", nil, err
+    }
+
+    summary, err := DescribeTransactionWrapper(transactionWrapper, certHandler.Config.ChainID)
+    if err != nil {
+        return nil, "", nil, err
+    }
+    raw, err := RawJSON(transactionWrapper)
+    if err != nil {
+        return nil, "", nil, err
+    }
+
+    return summary, raw, verification, nil
+}
@@ -0,0 +1,67 @@
+package libs
+
+import (
+    "reflect"
+    "testing"
+
+    entityApi "github.com/katena-chain/sdk-go-client/entity/api"
+
+    "github.com/katena-chain/transactor-ui/libs/testutil"
+)
+
+func TestVerifyCertificate(t *testing.T) {
+    // Problems with the certificate on chain are reported apart from the comparison with the local record, even
+    // for a certificate that is not tracked
+
+    transactionWrapper := &entityApi.TransactionWrapper{Transaction: newTestSignedCertificate(t).Transaction}
+
+    tests := []struct {
+        name           string
+        chainID        string
+        companyChainID string
+        recorded       string
+        result         string
+        invalid        []string
+        mismatches     []string
+    }{
+        {"match", testutil.ChainID, testutil.CompanyChainID, "signature", VerificationMatch, nil, nil},
+        {"local mismatch", testutil.ChainID, testutil.CompanyChainID, "other signature", VerificationMismatch, nil, []string{"signature"}},
+        {"not tracked", testutil.ChainID, testutil.CompanyChainID, "", VerificationNotTracked, nil, nil},
+        {"invalid seal", "other-chain", testutil.CompanyChainID, "signature", VerificationInvalid, []string{"seal signature"}, nil},
+        {"other company", testutil.ChainID, "other-company", "other signature", VerificationInvalid, []string{"company chain id"},
+            []string{"signature"}},
+        {"invalid seal not tracked", "other-chain", testutil.CompanyChainID, "", VerificationInvalid, []string{"seal signature"}, nil},
+    }
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            dao, cleanup := newTestDatabase(t)
+            defer cleanup()
+            if test.recorded != "" {
+                if err := dao.AddCertificateEntry(testutil.Uuid, test.recorded, "signer", "", "default"); err != nil {
+                    t.Fatal(err)
+                }
+            }
+
+            certHandler := CertificateHandler{Config: Config{ChainID: test.chainID, CompanyChainID: test.companyChainID}, UuidText: testutil.Uuid}
+            verification, err := certHandler.VerifyCertificate(transactionWrapper, dao)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if verification.Result != test.result || !reflect.DeepEqual(verification.Invalid, test.invalid) ||
+                !reflect.DeepEqual(verification.Mismatches, test.mismatches) {
+                t.Fatalf("unexpected verification: %s", verification)
+            }
+        })
+    }
+
+    certHandler := CertificateHandler{Config: Config{ChainID: "other-chain", CompanyChainID: testutil.CompanyChainID}, UuidText: testutil.OtherUuid}
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+    verification, err := certHandler.VerifyCertificate(transactionWrapper, dao)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if summary := verification.String(); summary != "Invalid on chain (invalid transaction seal) : seal signature, UUID" {
+        t.Fatalf("unexpected summary: %s", summary)
+    }
+}
@@ -0,0 +1,252 @@
+// Package mockapi is a stand-in for the Katena API, serving the certificate and secret routes used by the SDK so the
+// application can run without network access. Transactions are checked like the chain would (size, seal signature,
+// route, duplicate certificates) and become visible once the configured commit latency has elapsed.
+package mockapi
+
+import (
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+    "math/rand"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+
+    entityApi "github.com/katena-chain/sdk-go-client/entity/api"
+    "github.com/katena-chain/sdk-go-client/entity/certify"
+    "github.com/katena-chain/sdk-go-client/errors"
+
+    "github.com/katena-chain/transactor-ui/libs"
+)
+
+// Path prefix of the routes, matching the one of the real API URL
+const ApiPrefix = "/api/v1/"
+
+// Status codes returned by the mock, they only need to be non zero to be told apart from a success
+const (
+    CodeOk          = 0
+    CodeBadRequest  = 1
+    CodeInvalidSeal = 2
+    CodeDuplicate   = 3
+    CodeNotFound    = 4
+    CodeRejected    = 5
+    CodeUnavailable = 6
+    CodeBadRoute    = 7
+    CodeInternal    = 8
+    CodeTooLarge    = 9
+)
+
+// Number of secrets returned by a retrieval, the total being given aside
+const secretsPageLimit = 10
+
+type Server struct {
+    ChainID string
+    Store   Store
+    // Delay before a sent transaction can be retrieved
+    Latency time.Duration
+    // Fraction of the accepted transactions committed with a failure code
+    FailureRate float64
+    // Fraction of the requests answered by an HTTP error, as an unavailable API would
+    ErrorRate float64
+
+    mutex  sync.Mutex
+    random *rand.Rand
+    // Serializes the duplicate check and the insert of the transactions, two identical certificates sent at once
+    // being both accepted otherwise
+    certifyMutex sync.Mutex
+}
+
+func NewServer(chainID string, store Store) *Server {
+    return &Server{
+        ChainID: chainID,
+        Store:   store,
+        random:  rand.New(rand.NewSource(time.Now().UnixNano())),
+    }
+}
+
+func (server *Server) draw(rate float64) bool {
+    // Indicates if a random event of the given probability happens
+
+    if rate <= 0 {
+        return false
+    }
+    server.mutex.Lock()
+    defer server.mutex.Unlock()
+    return server.random.Float64() < rate
+}
+
+func writeJSON(writer http.ResponseWriter, httpStatus int, value interface{}) {
+    writer.Header().Set("Content-Type", "application/json")
+    writer.WriteHeader(httpStatus)
+    _ = json.NewEncoder(writer).Encode(value)
+}
+
+func writeError(writer http.ResponseWriter, httpStatus int, code uint32, message string) {
+    writeJSON(writer, httpStatus, errors.ApiError{Code: code, Message: message})
+}
+
+func (server *Server) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+    // Dispatches the routes of the SDK api.Handler :
+    //   POST certificates/certify, POST certificates/{company}-{uuid}/secrets/certify
+    //   GET certificates/{company}-{uuid}, GET certificates/{company}-{uuid}/secrets
+
+    if server.draw(server.ErrorRate) {
+        writeError(writer, http.StatusServiceUnavailable, CodeUnavailable, "simulated API failure")
+        return
+    }
+    if !strings.HasPrefix(request.URL.Path, ApiPrefix) {
+        writeError(writer, http.StatusNotFound, CodeBadRoute, "unknown route")
+        return
+    }
+    parts := strings.Split(strings.TrimPrefix(request.URL.Path, ApiPrefix), "/")
+
+    switch {
+    case request.Method == http.MethodPost && len(parts) == 2 && parts[0] == "certificates" && parts[1] == "certify":
+        server.certify(writer, request, KindCertificate, "")
+    case request.Method == http.MethodPost && len(parts) == 4 && parts[0] == "certificates" && parts[2] == "secrets" && parts[3] == "certify":
+        server.certify(writer, request, KindSecret, parts[1])
+    case request.Method == http.MethodGet && len(parts) == 2 && parts[0] == "certificates":
+        server.retrieveCertificate(writer, parts[1])
+    case request.Method == http.MethodGet && len(parts) == 3 && parts[0] == "certificates" && parts[2] == "secrets":
+        server.retrieveSecrets(writer, parts[1])
+    default:
+        writeError(writer, http.StatusNotFound, CodeBadRoute, "unknown route")
+    }
+}
+
+func (server *Server) check(transaction *entityApi.Transaction, kind string, routeKey string) (string, uint32, string) {
+    // Validates a transaction as the chain would and returns the key it is stored under, or a failure code and message
+
+    valid, err := libs.VerifyTransactionSeal(transaction, server.ChainID)
+    if err != nil || !valid {
+        return "", CodeInvalidSeal, "invalid seal signature for chain " + server.ChainID
+    }
+
+    switch message := transaction.Message.(type) {
+    case *certify.MsgCreateCertificate:
+        if kind != KindCertificate || message.Certificate == nil {
+            return "", CodeBadRoute, "certificate sent to the wrong route"
+        }
+        key := message.Certificate.GetCompanyChainID() + "-" + message.Certificate.GetUuid()
+        count, err := server.Store.Count(KindCertificate, key)
+        if err != nil {
+            return "", CodeInternal, err.Error()
+        }
+        if count > 0 {
+            return "", CodeDuplicate, "certificate " + message.Certificate.GetUuid() + " already exists"
+        }
+        return key, CodeOk, ""
+    case *certify.MsgCreateSecret:
+        if kind != KindSecret || message.Secret == nil {
+            return "", CodeBadRoute, "secret sent to the wrong route"
+        }
+        key := message.Secret.GetCompanyChainID() + "-" + message.Secret.GetCertificateUuid()
+        if key != routeKey {
+            return "", CodeBadRoute, "secret does not match the route " + routeKey
+        }
+        return key, CodeOk, ""
+    default:
+        return "", CodeBadRequest, fmt.Sprintf("unsupported message type: %s", transaction.Message.GetType())
+    }
+}
+
+func (server *Server) certify(writer http.ResponseWriter, request *http.Request, kind string, routeKey string) {
+    // Checks a transaction and stores it, to be committed after the latency
+
+    body, err := ioutil.ReadAll(request.Body)
+    if err != nil {
+        writeError(writer, http.StatusBadRequest, CodeBadRequest, err.Error())
+        return
+    }
+    if len(body) > libs.MaxTransactionSize {
+        writeJSON(writer, http.StatusAccepted, entityApi.TransactionStatus{Code: CodeTooLarge,
+            Message: fmt.Sprintf("transaction of %d bytes over the %d bytes limit", len(body), libs.MaxTransactionSize)})
+        return
+    }
+    transaction, err := libs.DecodeTransaction(body)
+    if err != nil {
+        writeError(writer, http.StatusBadRequest, CodeBadRequest, "invalid transaction: "+err.Error())
+        return
+    }
+
+    server.certifyMutex.Lock()
+    defer server.certifyMutex.Unlock()
+    key, code, message := server.check(transaction, kind, routeKey)
+    if code != CodeOk {
+        // Rejected before reaching a block, like a failed check of the real chain
+        writeJSON(writer, http.StatusAccepted, entityApi.TransactionStatus{Code: code, Message: message})
+        return
+    }
+
+    record := &Record{
+        Kind:        kind,
+        Key:         key,
+        Transaction: body,
+        CommitAt:    time.Now().Add(server.Latency),
+        Code:        CodeOk,
+        Message:     "Tx committed",
+    }
+    if server.draw(server.FailureRate) {
+        record.Code = CodeRejected
+        record.Message = "simulated failure while committing"
+    }
+    if err := server.Store.Add(record); err != nil {
+        writeError(writer, http.StatusInternalServerError, CodeInternal, err.Error())
+        return
+    }
+    writeJSON(writer, http.StatusAccepted, entityApi.TransactionStatus{Code: CodeOk, Message: "Tx accepted"})
+}
+
+type transactionWrapper struct {
+    Transaction json.RawMessage             `json:"transaction"`
+    Status      entityApi.TransactionStatus `json:"status"`
+}
+
+type transactionWrappers struct {
+    Transactions []transactionWrapper `json:"transactions"`
+    Total        int                  `json:"total"`
+}
+
+func wrap(record *Record) transactionWrapper {
+    // Returns a stored transaction, with its exact sent bytes, and its status
+
+    return transactionWrapper{
+        Transaction: record.Transaction,
+        Status:      entityApi.TransactionStatus{Code: record.Code, Message: record.Message},
+    }
+}
+
+func (server *Server) retrieveCertificate(writer http.ResponseWriter, key string) {
+    records, err := server.Store.Committed(KindCertificate, key, time.Now())
+    if err != nil {
+        writeError(writer, http.StatusInternalServerError, CodeInternal, err.Error())
+        return
+    }
+    if len(records) == 0 {
+        writeError(writer, http.StatusNotFound, CodeNotFound, "certificate not found")
+        return
+    }
+    writeJSON(writer, http.StatusOK, wrap(records[0]))
+}
+
+func (server *Server) retrieveSecrets(writer http.ResponseWriter, key string) {
+    records, err := server.Store.Committed(KindSecret, key, time.Now())
+    if err != nil {
+        writeError(writer, http.StatusInternalServerError, CodeInternal, err.Error())
+        return
+    }
+
+    // Like the real API, only the last transactions are returned along with the total
+    result := transactionWrappers{
+        Transactions: []transactionWrapper{},
+        Total:        len(records),
+    }
+    for i, record := range records {
+        if i == secretsPageLimit {
+            break
+        }
+        result.Transactions = append(result.Transactions, wrap(record))
+    }
+    writeJSON(writer, http.StatusOK, result)
+}
@@ -0,0 +1,330 @@
+package mockapi
+
+import (
+    "bytes"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "path/filepath"
+    "sync"
+    "testing"
+    "time"
+
+    entityApi "github.com/katena-chain/sdk-go-client/entity/api"
+
+    "github.com/katena-chain/transactor-ui/libs"
+    "github.com/katena-chain/transactor-ui/libs/testutil"
+)
+
+func newTestServer(t *testing.T) (*Server, *httptest.Server, libs.Config) {
+    // Starts a mock API and returns the configuration of a transactor sending to it
+
+    server := NewServer(testutil.ChainID, NewMemoryStore())
+    httpServer := httptest.NewServer(server)
+    config := libs.Config{
+        PrivKey:        testutil.PrivateKey(t),
+        ChainID:        testutil.ChainID,
+        CompanyChainID: testutil.CompanyChainID,
+        ApiUrl:         httpServer.URL + "/api/v1",
+    }
+    return server, httpServer, config
+}
+
+func TestMalformedTransactionRejected(t *testing.T) {
+    // A transaction whose message does not wrap a certificate used to crash the server in the SDK decoder
+
+    _, httpServer, config := newTestServer(t)
+    defer httpServer.Close()
+
+    certHandler := libs.CertificateHandler{Config: config, UuidText: testutil.Uuid}
+    signed, err := libs.SignMessage(certHandler.Message(), testutil.ChainID, testutil.TransactorKey(t))
+    if err != nil {
+        t.Fatal(err)
+    }
+    var parts map[string]json.RawMessage
+    if err := json.Unmarshal(signed.Bytes, &parts); err != nil {
+        t.Fatal(err)
+    }
+    parts["message"] = json.RawMessage(`{"type":"certify/MsgCreateCertificate","value":{}}`)
+    body, err := json.Marshal(parts)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    response, err := http.Post(config.ApiUrl+"/certificates/certify", "application/json", bytes.NewReader(body))
+    if err != nil {
+        t.Fatal(err)
+    }
+    _ = response.Body.Close()
+    if response.StatusCode != http.StatusBadRequest {
+        t.Fatalf("malformed transaction answered with HTTP %d", response.StatusCode)
+    }
+
+    // The server still serves the well formed transactions
+    response, err = http.Post(config.ApiUrl+"/certificates/certify", "application/json", bytes.NewReader(signed.Bytes))
+    if err != nil {
+        t.Fatal(err)
+    }
+    _ = response.Body.Close()
+    if response.StatusCode != http.StatusAccepted {
+        t.Fatalf("valid transaction answered with HTTP %d", response.StatusCode)
+    }
+}
+
+func newTestDatabase(t *testing.T) (*libs.DatabaseDAO, func()) {
+    // Opens a database in a temporary directory with an unlocked keystore, returning the function removing it
+
+    directory, removeDirectory := testutil.TempDir(t)
+    dao, err := libs.OpenDb(filepath.Join(directory, "test.db"))
+    if err != nil {
+        removeDirectory()
+        t.Fatal(err)
+    }
+    cleanup := func() {
+        dao.Keystore.Lock()
+        _ = dao.Db.Close()
+        removeDirectory()
+    }
+    if err := dao.Keystore.Unlock(testutil.Passphrase); err != nil {
+        cleanup()
+        t.Fatal(err)
+    }
+    return &dao, cleanup
+}
+
+func newTestSecret(t *testing.T, config libs.Config, content string) (*libs.SecretHandler, string) {
+    // Returns a secret sealed for a new recipient key pair, along with the recipient private key
+
+    recipientPublic, recipientPrivate, err := libs.GenerateKeyPair(libs.KeyTypeX25519)
+    if err != nil {
+        t.Fatal(err)
+    }
+    senderPublic, senderPrivate, err := libs.GenerateKeyPair(libs.KeyTypeX25519)
+    if err != nil {
+        t.Fatal(err)
+    }
+    recipientPublicKey, senderPublicKey, senderPrivateKey, err := libs.ConvertKeys(recipientPublic, senderPublic, senderPrivate)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return &libs.SecretHandler{
+        Config:          config,
+        UuidText:        testutil.Uuid,
+        Content:         []byte(content),
+        RecipientPubKey: recipientPublicKey,
+        SenderPubKey:    senderPublicKey,
+        SenderPrivKey:   senderPrivateKey,
+    }, recipientPrivate
+}
+
+func TestCertify(t *testing.T) {
+    _, httpServer, config := newTestServer(t)
+    defer httpServer.Close()
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+
+    certHandler := libs.CertificateHandler{Config: config, UuidText: testutil.Uuid, SignatureText: "signature", SignerText: "signer"}
+    transactionStatus, err := certHandler.SendCertificate()
+    if err != nil || transactionStatus.Code != CodeOk {
+        t.Fatalf("certificate not accepted: %v, %v", transactionStatus, err)
+    }
+    if err := dao.AddCertificateEntry(testutil.Uuid, "signature", "signer", certHandler.Signed.Digest(), ""); err != nil {
+        t.Fatal(err)
+    }
+    summary, _, verification, err := certHandler.RetrieveAndVerifyCertificate(dao)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !summary.SealValid || verification.Result != libs.VerificationMatch {
+        t.Fatalf("unexpected certificate %s: %s", summary.String(), verification.String())
+    }
+
+    // The same UUID cannot be certified twice
+    duplicate := libs.CertificateHandler{Config: config, UuidText: testutil.Uuid, SignatureText: "other", SignerText: "signer"}
+    if transactionStatus, err = duplicate.SendCertificate(); err != nil || transactionStatus.Code != CodeDuplicate {
+        t.Fatalf("duplicate certificate not rejected: %v, %v", transactionStatus, err)
+    }
+}
+
+// Store slow to count, widening the window between the duplicate check and the insert
+type slowStore struct {
+    *MemoryStore
+}
+
+func (store slowStore) Count(kind string, key string) (int, error) {
+    count, err := store.MemoryStore.Count(kind, key)
+    time.Sleep(5 * time.Millisecond)
+    return count, err
+}
+
+func TestConcurrentDuplicates(t *testing.T) {
+    // Identical certificates sent at once are accepted only once
+
+    server, httpServer, config := newTestServer(t)
+    defer httpServer.Close()
+    server.Store = slowStore{NewMemoryStore()}
+
+    const count = 10
+    bodies := make([][]byte, count)
+    for i := range bodies {
+        certHandler := libs.CertificateHandler{Config: config, UuidText: testutil.Uuid, SignatureText: "signature", SignerText: "signer"}
+        if err := certHandler.BuildTransaction(); err != nil {
+            t.Fatal(err)
+        }
+        bodies[i] = certHandler.Signed.Bytes
+    }
+    statuses := make([]entityApi.TransactionStatus, count)
+    errs := make([]error, count)
+    var group sync.WaitGroup
+    for i := range bodies {
+        group.Add(1)
+        go func(i int) {
+            defer group.Done()
+            response, err := http.Post(config.ApiUrl+"/certificates/certify", "application/json", bytes.NewReader(bodies[i]))
+            if err == nil {
+                err = json.NewDecoder(response.Body).Decode(&statuses[i])
+                _ = response.Body.Close()
+            }
+            errs[i] = err
+        }(i)
+    }
+    group.Wait()
+
+    accepted := 0
+    for i := range statuses {
+        if errs[i] != nil {
+            t.Fatal(errs[i])
+        }
+        if statuses[i].Code == CodeOk {
+            accepted++
+        } else if statuses[i].Code != CodeDuplicate {
+            t.Fatalf("unexpected code %d", statuses[i].Code)
+        }
+    }
+    stored, err := server.Store.Count(KindCertificate, testutil.CompanyChainID+"-"+testutil.Uuid)
+    if accepted != 1 || stored != 1 || err != nil {
+        t.Fatalf("%d certificates accepted, %d stored, %v", accepted, stored, err)
+    }
+}
+
+func TestSecret(t *testing.T) {
+    _, httpServer, config := newTestServer(t)
+    defer httpServer.Close()
+
+    secHandler, recipientPrivate := newTestSecret(t, config, "secret content")
+    transactionStatus, err := secHandler.SendSecret()
+    if err != nil || transactionStatus.Code != CodeOk {
+        t.Fatalf("secret not accepted: %v, %v", transactionStatus, err)
+    }
+    decrypted, err := secHandler.DecryptSecrets(recipientPrivate)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(decrypted) != 1 || decrypted[0].Err != nil || string(decrypted[0].Content) != "secret content" {
+        t.Fatalf("unexpected secrets %+v", decrypted)
+    }
+
+    // Secrets are not unique, the same UUID can receive several
+    other, _ := newTestSecret(t, config, "other content")
+    if transactionStatus, err = other.SendSecret(); err != nil || transactionStatus.Code != CodeOk {
+        t.Fatalf("second secret not accepted: %v, %v", transactionStatus, err)
+    }
+}
+
+func TestLookup(t *testing.T) {
+    _, httpServer, config := newTestServer(t)
+    defer httpServer.Close()
+
+    result, err := libs.Lookup(config, testutil.Uuid, "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if result.Certificate != nil || result.CertificateError == "" || result.SecretCount() != 0 {
+        t.Fatalf("unexpected result for an unknown UUID %+v", result)
+    }
+
+    certHandler := libs.CertificateHandler{Config: config, UuidText: testutil.Uuid, SignatureText: "signature", SignerText: "signer"}
+    if _, err := certHandler.SendCertificate(); err != nil {
+        t.Fatal(err)
+    }
+    secHandler, _ := newTestSecret(t, config, "secret content")
+    if _, err := secHandler.SendSecret(); err != nil {
+        t.Fatal(err)
+    }
+    // Looked up from another configured company
+    otherConfig := config
+    otherConfig.CompanyChainID = "other-company"
+    if result, err = libs.Lookup(otherConfig, testutil.Uuid, testutil.CompanyChainID); err != nil {
+        t.Fatal(err)
+    }
+    if result.Certificate == nil || result.SecretCount() != 1 || result.Status(libs.EntryCertificate) != libs.StatusCommitted {
+        t.Fatalf("unexpected result %+v", result)
+    }
+}
+
+func TestBroadcast(t *testing.T) {
+    _, httpServer, config := newTestServer(t)
+    defer httpServer.Close()
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+    config.History = dao
+
+    certHandler := libs.CertificateHandler{Config: config, UuidText: testutil.Uuid, SignatureText: "signature", SignerText: "signer"}
+    if err := certHandler.BuildTransaction(); err != nil {
+        t.Fatal(err)
+    }
+    transactionStatus, err := config.Broadcast(certHandler.Signed)
+    if err != nil || transactionStatus.Code != CodeOk {
+        t.Fatalf("transaction not accepted: %v, %v", transactionStatus, err)
+    }
+
+    // A transaction sealed for another chain is rejected and recorded as failed
+    otherChain := config
+    otherChain.ChainID = "other-chain"
+    rejected := libs.CertificateHandler{Config: otherChain, UuidText: "7f0bbd3b-0d57-4f7e-a3f4-6bd0b0a3d2a8", SignatureText: "signature",
+        SignerText: "signer"}
+    if err := rejected.BuildTransaction(); err != nil {
+        t.Fatal(err)
+    }
+    if transactionStatus, err = config.Broadcast(rejected.Signed); err != nil || transactionStatus.Code != CodeInvalidSeal {
+        t.Fatalf("transaction for another chain not rejected: %v, %v", transactionStatus, err)
+    }
+
+    entries, err := dao.ListTransactions(libs.HistoryFilter{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(entries) != 2 || entries[0].Status != libs.StatusPending || entries[1].Status != libs.StatusFailed ||
+        entries[1].Code != CodeInvalidSeal {
+        t.Fatalf("unexpected history %+v", entries)
+    }
+}
+
+func TestTrackStatus(t *testing.T) {
+    server, httpServer, config := newTestServer(t)
+    defer httpServer.Close()
+    dao, cleanup := newTestDatabase(t)
+    defer cleanup()
+    server.Latency = 20 * time.Millisecond
+
+    track := func(uuid string) string {
+        certHandler := libs.CertificateHandler{Config: config, UuidText: uuid, SignatureText: "signature", SignerText: "signer"}
+        transactionStatus, err := certHandler.SendCertificate()
+        if err != nil {
+            t.Fatal(err)
+        }
+        tracker := libs.NewStatusTracker(dao, config)
+        tracker.Interval = 10 * time.Millisecond
+        status, _ := tracker.Track(libs.EntryCertificate, uuid, certHandler.Signed, transactionStatus)
+        return status
+    }
+    if status := track(testutil.Uuid); status != libs.StatusCommitted {
+        t.Fatalf("unexpected status %s", status)
+    }
+
+    // Committed with a failure code by the chain
+    server.FailureRate = 1
+    if status := track("7f0bbd3b-0d57-4f7e-a3f4-6bd0b0a3d2a8"); status != libs.StatusFailed {
+        t.Fatalf("unexpected status %s", status)
+    }
+}
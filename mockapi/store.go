@@ -0,0 +1,135 @@
+package mockapi
+
+import (
+    "database/sql"
+    "sort"
+    "sync"
+    "time"
+
+    _ "github.com/mattn/go-sqlite3"
+)
+
+// Kinds of transactions held by the mock
+const (
+    KindCertificate = "certificate"
+    KindSecret      = "secret"
+)
+
+type Record struct {
+    Kind string
+    // Company chain id and uuid joined by a dash, as found in the API routes
+    Key         string
+    Transaction []byte
+    CommitAt    time.Time
+    Code        uint32
+    Message     string
+}
+
+type Store interface {
+    Add(record *Record) error
+    // Returns the records of a kind and key committed at the given time, most recent first
+    Committed(kind string, key string, at time.Time) ([]*Record, error)
+    // Returns the number of records of a kind and key that did not fail, committed or not
+    Count(kind string, key string) (int, error)
+}
+
+type MemoryStore struct {
+    mutex   sync.Mutex
+    records []*Record
+}
+
+func NewMemoryStore() *MemoryStore {
+    return &MemoryStore{}
+}
+
+func (store *MemoryStore) Add(record *Record) error {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    store.records = append(store.records, record)
+    return nil
+}
+
+func (store *MemoryStore) Committed(kind string, key string, at time.Time) ([]*Record, error) {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    var result []*Record
+    for _, record := range store.records {
+        if record.Kind == kind && record.Key == key && !record.CommitAt.After(at) {
+            result = append(result, record)
+        }
+    }
+    sort.SliceStable(result, func(i, j int) bool {
+        return result[i].CommitAt.After(result[j].CommitAt)
+    })
+    return result, nil
+}
+
+func (store *MemoryStore) Count(kind string, key string) (int, error) {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    count := 0
+    for _, record := range store.records {
+        if record.Kind == kind && record.Key == key && record.Code == 0 {
+            count++
+        }
+    }
+    return count, nil
+}
+
+type SqliteStore struct {
+    Db *sql.DB
+}
+
+func NewSqliteStore(path string) (*SqliteStore, error) {
+    // Opens or creates a SQLite file holding the mock chain, so it survives restarts
+
+    database, err := sql.Open("sqlite3", path)
+    if err != nil {
+        return nil, err
+    }
+    _, err = database.Exec("CREATE TABLE IF NOT EXISTS transactions (id integer primary key autoincrement, kind string, key string, " +
+        "txBytes blob, commitAt integer, code integer, message string)")
+    if err != nil {
+        _ = database.Close()
+        return nil, err
+    }
+    return &SqliteStore{Db: database}, nil
+}
+
+func (store *SqliteStore) Add(record *Record) error {
+    _, err := store.Db.Exec("INSERT INTO transactions (kind, key, txBytes, commitAt, code, message) VALUES (?, ?, ?, ?, ?, ?)",
+        record.Kind, record.Key, record.Transaction, record.CommitAt.UnixNano(), record.Code, record.Message)
+    return err
+}
+
+func (store *SqliteStore) Committed(kind string, key string, at time.Time) ([]*Record, error) {
+    rows, err := store.Db.Query("SELECT txBytes, commitAt, code, message FROM transactions WHERE kind = ? AND key = ? AND commitAt <= ? "+
+        "ORDER BY commitAt DESC, id DESC", kind, key, at.UnixNano())
+    if err != nil {
+        return nil, err
+    }
+    defer func() {
+        _ = rows.Close()
+    }()
+
+    var result []*Record
+    for rows.Next() {
+        record := &Record{Kind: kind, Key: key}
+        var commitAt int64
+        if err := rows.Scan(&record.Transaction, &commitAt, &record.Code, &record.Message); err != nil {
+            return nil, err
+        }
+        record.CommitAt = time.Unix(0, commitAt)
+        result = append(result, record)
+    }
+    return result, rows.Err()
+}
+
+func (store *SqliteStore) Count(kind string, key string) (int, error) {
+    var count int
+    err := store.Db.QueryRow("SELECT COUNT(*) FROM transactions WHERE kind = ? AND key = ? AND code = 0", kind, key).Scan(&count)
+    return count, err
+}